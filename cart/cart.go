@@ -0,0 +1,43 @@
+// Package cart holds what the services share about carts: the items a cart
+// stores and the ids of guest carts.
+package cart
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Item is what a cart stores per line: the price is the one seen when the
+// line was added, so that a later price change can be pointed out.
+type Item struct {
+	ProductID  int     `db:"product_id" json:"product_id"`
+	VariantID  *int    `db:"variant_id" json:"variant_id,omitempty"`
+	Quantity   int     `db:"quantity" json:"quantity"`
+	AddedPrice float64 `db:"added_price" json:"added_price"`
+}
+
+// SameLine reports whether both items refer to the same product and variant.
+func (i Item) SameLine(productID int, variantID *int) bool {
+	if i.ProductID != productID {
+		return false
+	}
+	if i.VariantID == nil || variantID == nil {
+		return i.VariantID == nil && variantID == nil
+	}
+	return *i.VariantID == *variantID
+}
+
+// NewID returns a random id for a guest cart.
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func IsID(id string) bool {
+	b, err := hex.DecodeString(id)
+	return err == nil && len(b) == 16
+}
@@ -0,0 +1,71 @@
+package cart
+
+import "testing"
+
+func TestItemSameLine(t *testing.T) {
+	one, two := 1, 2
+
+	tests := []struct {
+		name      string
+		item      Item
+		productID int
+		variantID *int
+		want      bool
+	}{
+		{"same product", Item{ProductID: 10}, 10, nil, true},
+		{"other product", Item{ProductID: 10}, 11, nil, false},
+		{"same variant", Item{ProductID: 10, VariantID: &one}, 10, &one, true},
+		{"other variant", Item{ProductID: 10, VariantID: &one}, 10, &two, false},
+		{"variant against the product", Item{ProductID: 10, VariantID: &one}, 10, nil, false},
+		{"product against a variant", Item{ProductID: 10}, 10, &one, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.SameLine(tt.productID, tt.variantID); got != tt.want {
+				t.Errorf("SameLine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewID(t *testing.T) {
+	first, err := NewID()
+	if err != nil {
+		t.Fatalf("NewID() error = %v", err)
+	}
+	second, err := NewID()
+	if err != nil {
+		t.Fatalf("NewID() error = %v", err)
+	}
+
+	if !IsID(first) {
+		t.Errorf("IsID(%q) = false, want true", first)
+	}
+	if first == second {
+		t.Errorf("NewID() returned %q twice", first)
+	}
+}
+
+func TestIsID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{"valid", "0123456789abcdef0123456789abcdef", true},
+		{"empty", "", false},
+		{"too short", "0123456789abcdef", false},
+		{"too long", "0123456789abcdef0123456789abcdef00", false},
+		{"not hex", "0123456789abcdef0123456789abcdeg", false},
+		{"key injection", "0123456789abcdef:*", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsID(tt.id); got != tt.want {
+				t.Errorf("IsID(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
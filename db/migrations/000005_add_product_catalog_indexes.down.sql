@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_product_stock_quantity;
+DROP INDEX IF EXISTS idx_product_name;
+DROP INDEX IF EXISTS idx_product_price;
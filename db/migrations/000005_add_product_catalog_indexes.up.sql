@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_product_price ON product (price, id);
+CREATE INDEX IF NOT EXISTS idx_product_name ON product (name, id);
+CREATE INDEX IF NOT EXISTS idx_product_stock_quantity ON product (stock_quantity, id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_product_description_trgm;
+DROP INDEX IF EXISTS idx_product_name_trgm;
+DROP INDEX IF EXISTS idx_product_search_vector;
+DROP TRIGGER IF EXISTS product_search_vector_trigger ON product;
+DROP FUNCTION IF EXISTS product_search_vector_update();
+ALTER TABLE product DROP COLUMN IF EXISTS search_vector;
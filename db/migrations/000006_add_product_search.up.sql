@@ -0,0 +1,25 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE product ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+CREATE OR REPLACE FUNCTION product_search_vector_update() RETURNS TRIGGER AS $$
+BEGIN
+	NEW.search_vector :=
+		setweight(to_tsvector('english', COALESCE(NEW.name, '')), 'A') ||
+		setweight(to_tsvector('english', COALESCE(NEW.description, '')), 'B');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS product_search_vector_trigger ON product;
+CREATE TRIGGER product_search_vector_trigger
+	BEFORE INSERT OR UPDATE OF name, description ON product
+	FOR EACH ROW EXECUTE FUNCTION product_search_vector_update();
+
+UPDATE product SET search_vector =
+	setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+	setweight(to_tsvector('english', COALESCE(description, '')), 'B');
+
+CREATE INDEX IF NOT EXISTS idx_product_search_vector ON product USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_product_name_trgm ON product USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_product_description_trgm ON product USING GIN (description gin_trgm_ops);
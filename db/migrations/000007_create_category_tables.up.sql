@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS category (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	name VARCHAR(255) NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	parent_id BIGINT REFERENCES category (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_parent_id ON category (parent_id);
+
+CREATE TABLE IF NOT EXISTS product_category (
+	product_id BIGINT NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+	category_id BIGINT NOT NULL REFERENCES category (id) ON DELETE CASCADE,
+	PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_category_category_id ON product_category (category_id);
+
+INSERT INTO category (name, description, parent_id) VALUES
+	('Electronics', 'Devices and gadgets', NULL),
+	('Computers', 'Laptops and storage', 1),
+	('Peripherals', 'Monitors, webcams and hubs', 1),
+	('Audio', 'Headphones and speakers', 1),
+	('Wearables', 'Smartwatches and fitness trackers', 1),
+	('Furniture', 'Office and gaming furniture', NULL);
+
+INSERT INTO product_category (product_id, category_id) VALUES
+	(1, 2),
+	(2, 3),
+	(3, 3),
+	(4, 4),
+	(5, 5),
+	(6, 2),
+	(7, 3),
+	(8, 6);
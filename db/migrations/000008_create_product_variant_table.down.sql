@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_order_product_line;
+DELETE FROM order_product WHERE variant_id IS NOT NULL;
+ALTER TABLE order_product DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE order_product DROP COLUMN IF EXISTS id;
+ALTER TABLE order_product ADD PRIMARY KEY (order_id, product_id);
+DROP TABLE IF EXISTS product_variant;
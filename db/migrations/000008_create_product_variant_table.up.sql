@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS product_variant (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	product_id BIGINT NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+	sku VARCHAR(64) NOT NULL UNIQUE,
+	attributes JSONB NOT NULL DEFAULT '{}',
+	price NUMERIC(10, 2),
+	stock_quantity INT NOT NULL DEFAULT 0 CHECK (stock_quantity >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variant_product_id ON product_variant (product_id);
+
+ALTER TABLE order_product DROP CONSTRAINT IF EXISTS order_product_pkey;
+ALTER TABLE order_product ADD COLUMN IF NOT EXISTS id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY;
+ALTER TABLE order_product ADD COLUMN IF NOT EXISTS variant_id BIGINT REFERENCES product_variant (id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_product_line ON order_product (order_id, product_id, COALESCE(variant_id, 0));
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS warehouse_stock_sync_total_trigger ON warehouse_stock;
+DROP FUNCTION IF EXISTS warehouse_stock_sync_total();
+DROP TABLE IF EXISTS order_allocation;
+DROP TABLE IF EXISTS warehouse_stock;
+DROP TABLE IF EXISTS warehouse;
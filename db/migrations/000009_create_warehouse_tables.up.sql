@@ -0,0 +1,59 @@
+CREATE TABLE IF NOT EXISTS warehouse (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	name VARCHAR(255) NOT NULL,
+	code VARCHAR(32) NOT NULL UNIQUE,
+	latitude DOUBLE PRECISION NOT NULL DEFAULT 0,
+	longitude DOUBLE PRECISION NOT NULL DEFAULT 0,
+	priority INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+	warehouse_id BIGINT NOT NULL REFERENCES warehouse (id) ON DELETE CASCADE,
+	product_id BIGINT NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+	quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+	PRIMARY KEY (warehouse_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_warehouse_stock_product_id ON warehouse_stock (product_id);
+
+CREATE TABLE IF NOT EXISTS order_allocation (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+	product_id BIGINT NOT NULL REFERENCES product (id),
+	warehouse_id BIGINT NOT NULL REFERENCES warehouse (id),
+	quantity INT NOT NULL CHECK (quantity > 0),
+	released BOOL NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_allocation_order_id ON order_allocation (order_id);
+
+-- product.stock_quantity is kept as the total over all warehouses.
+CREATE OR REPLACE FUNCTION warehouse_stock_sync_total() RETURNS TRIGGER AS $$
+DECLARE
+	changed_product BIGINT;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		changed_product := OLD.product_id;
+	ELSE
+		changed_product := NEW.product_id;
+	END IF;
+
+	UPDATE product
+	SET stock_quantity = COALESCE((SELECT SUM(quantity) FROM warehouse_stock WHERE product_id = changed_product), 0)
+	WHERE id = changed_product;
+
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS warehouse_stock_sync_total_trigger ON warehouse_stock;
+CREATE TRIGGER warehouse_stock_sync_total_trigger
+	AFTER INSERT OR UPDATE OR DELETE ON warehouse_stock
+	FOR EACH ROW EXECUTE FUNCTION warehouse_stock_sync_total();
+
+INSERT INTO warehouse (name, code, latitude, longitude, priority) VALUES
+	('Main Warehouse', 'MAIN', 50.4501, 30.5234, 0),
+	('West Warehouse', 'WEST', 49.8397, 24.0297, 1);
+
+INSERT INTO warehouse_stock (warehouse_id, product_id, quantity)
+SELECT 1, id, stock_quantity FROM product;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS stock_movement_append_only_trigger ON stock_movement;
+DROP FUNCTION IF EXISTS stock_movement_append_only();
+DROP TABLE IF EXISTS stock_movement;
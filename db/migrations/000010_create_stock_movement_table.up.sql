@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS stock_movement (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	product_id BIGINT NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+	variant_id BIGINT REFERENCES product_variant (id) ON DELETE SET NULL,
+	warehouse_id BIGINT REFERENCES warehouse (id) ON DELETE SET NULL,
+	delta INT NOT NULL CHECK (delta <> 0),
+	reason VARCHAR(32) NOT NULL CHECK (reason IN ('order', 'cancellation', 'manual_adjustment', 'restock', 'return')),
+	reference_id VARCHAR(255),
+	actor_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movement_product_id ON stock_movement (product_id, created_at);
+
+-- The ledger is append-only; rows may only disappear together with their product.
+CREATE OR REPLACE FUNCTION stock_movement_append_only() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP = 'DELETE' AND NOT EXISTS (SELECT 1 FROM product WHERE id = OLD.product_id) THEN
+		RETURN OLD;
+	END IF;
+	IF TG_OP = 'UPDATE'
+		AND NEW.id = OLD.id AND NEW.product_id = OLD.product_id AND NEW.delta = OLD.delta
+		AND NEW.reason = OLD.reason AND NEW.reference_id IS NOT DISTINCT FROM OLD.reference_id
+		AND NEW.created_at = OLD.created_at THEN
+		-- ON DELETE SET NULL of a variant, warehouse or user
+		RETURN NEW;
+	END IF;
+	RAISE EXCEPTION 'stock_movement is append-only';
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_movement_append_only_trigger ON stock_movement;
+CREATE TRIGGER stock_movement_append_only_trigger
+	BEFORE UPDATE OR DELETE ON stock_movement
+	FOR EACH ROW EXECUTE FUNCTION stock_movement_append_only();
+
+INSERT INTO stock_movement (product_id, warehouse_id, delta, reason, reference_id)
+SELECT product_id, warehouse_id, quantity, 'restock', 'opening_balance'
+FROM warehouse_stock WHERE quantity > 0;
+
+INSERT INTO stock_movement (product_id, variant_id, delta, reason, reference_id)
+SELECT product_id, id, stock_quantity, 'restock', 'opening_balance'
+FROM product_variant WHERE stock_quantity > 0;
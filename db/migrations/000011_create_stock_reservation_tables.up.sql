@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS stock_reservation (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	status VARCHAR(16) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'confirmed', 'released', 'expired')),
+	order_id BIGINT REFERENCES orders (id) ON DELETE SET NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservation_active ON stock_reservation (expires_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS stock_reservation_item (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	reservation_id BIGINT NOT NULL REFERENCES stock_reservation (id) ON DELETE CASCADE,
+	product_id BIGINT NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+	variant_id BIGINT REFERENCES product_variant (id) ON DELETE CASCADE,
+	quantity INT NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservation_item_reservation_id ON stock_reservation_item (reservation_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservation_item_product_id ON stock_reservation_item (product_id, variant_id);
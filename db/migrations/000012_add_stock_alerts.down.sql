@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stock_subscription;
+ALTER TABLE product DROP COLUMN IF EXISTS low_stock_threshold;
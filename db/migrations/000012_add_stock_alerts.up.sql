@@ -0,0 +1,12 @@
+ALTER TABLE product ADD COLUMN IF NOT EXISTS low_stock_threshold INT NOT NULL DEFAULT 0 CHECK (low_stock_threshold >= 0);
+
+CREATE TABLE IF NOT EXISTS stock_subscription (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	product_id BIGINT NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	notified_at TIMESTAMPTZ
+);
+
+-- one waiting subscription per user and product; notified ones are kept as history
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_subscription_waiting ON stock_subscription (product_id, user_id) WHERE notified_at IS NULL;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_order_product_backordered;
+
+ALTER TABLE order_product
+	DROP COLUMN IF EXISTS backordered_quantity,
+	DROP COLUMN IF EXISTS status;
+
+ALTER TABLE product
+	DROP COLUMN IF EXISTS expected_at,
+	DROP COLUMN IF EXISTS availability;
@@ -0,0 +1,9 @@
+ALTER TABLE product
+	ADD COLUMN IF NOT EXISTS availability VARCHAR(16) NOT NULL DEFAULT 'stock' CHECK (availability IN ('stock', 'backorder', 'preorder')),
+	ADD COLUMN IF NOT EXISTS expected_at TIMESTAMPTZ;
+
+ALTER TABLE order_product
+	ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'allocated' CHECK (status IN ('allocated', 'backordered')),
+	ADD COLUMN IF NOT EXISTS backordered_quantity INT NOT NULL DEFAULT 0 CHECK (backordered_quantity >= 0 AND backordered_quantity <= quantity);
+
+CREATE INDEX IF NOT EXISTS idx_order_product_backordered ON order_product (product_id, id) WHERE backordered_quantity > 0;
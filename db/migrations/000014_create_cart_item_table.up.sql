@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS cart_item (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	product_id BIGINT NOT NULL REFERENCES product (id) ON DELETE CASCADE,
+	variant_id BIGINT REFERENCES product_variant (id) ON DELETE CASCADE,
+	quantity INT NOT NULL CHECK (quantity > 0),
+	added_price NUMERIC(10, 2) NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_item_line ON cart_item (user_id, product_id, (COALESCE(variant_id, 0)));
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS order_discount;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS discount_amount;
+
+DROP TABLE IF EXISTS promotion;
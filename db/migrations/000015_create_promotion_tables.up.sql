@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS promotion (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	code VARCHAR(64) NOT NULL UNIQUE,
+	description TEXT NOT NULL DEFAULT '',
+	type VARCHAR(16) NOT NULL CHECK (type IN ('percentage', 'fixed', 'buy_x_get_y')),
+	value NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (value >= 0),
+	product_id BIGINT REFERENCES product (id) ON DELETE CASCADE,
+	buy_quantity INT NOT NULL DEFAULT 0 CHECK (buy_quantity >= 0),
+	get_quantity INT NOT NULL DEFAULT 0 CHECK (get_quantity >= 0),
+	min_basket NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (min_basket >= 0),
+	usage_limit INT CHECK (usage_limit > 0),
+	per_user_limit INT CHECK (per_user_limit > 0),
+	starts_at TIMESTAMPTZ,
+	ends_at TIMESTAMPTZ,
+	active BOOL NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	CHECK (starts_at IS NULL OR ends_at IS NULL OR ends_at > starts_at)
+);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS order_discount (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+	promotion_id BIGINT NOT NULL REFERENCES promotion (id),
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	code VARCHAR(64) NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	amount NUMERIC(10, 2) NOT NULL CHECK (amount >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_discount_order_id ON order_discount (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_discount_promotion ON order_discount (promotion_id, user_id);
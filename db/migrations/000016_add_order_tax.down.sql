@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS order_tax_line;
+
+ALTER TABLE orders
+	DROP COLUMN IF EXISTS tax_region,
+	DROP COLUMN IF EXISTS tax_amount,
+	DROP COLUMN IF EXISTS subtotal;
+
+DROP TABLE IF EXISTS tax_rate;
+
+ALTER TABLE product DROP COLUMN IF EXISTS tax_class;
@@ -0,0 +1,36 @@
+ALTER TABLE product ADD COLUMN IF NOT EXISTS tax_class VARCHAR(32) NOT NULL DEFAULT 'standard';
+
+CREATE TABLE IF NOT EXISTS tax_rate (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	region VARCHAR(16) NOT NULL,
+	tax_class VARCHAR(32) NOT NULL,
+	name VARCHAR(64) NOT NULL DEFAULT '',
+	rate NUMERIC(6, 3) NOT NULL CHECK (rate >= 0 AND rate < 100),
+	inclusive BOOL NOT NULL DEFAULT FALSE,
+	UNIQUE (region, tax_class)
+);
+
+ALTER TABLE orders
+	ADD COLUMN IF NOT EXISTS subtotal NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS tax_region VARCHAR(16) NOT NULL DEFAULT '';
+
+-- orders placed before taxes were tracked carry no tax
+UPDATE orders SET subtotal = total_amount + discount_amount;
+
+-- tax lines copy the rate they were computed with, and outlive the product
+CREATE TABLE IF NOT EXISTS order_tax_line (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+	product_id BIGINT NOT NULL,
+	variant_id BIGINT,
+	region VARCHAR(16) NOT NULL,
+	tax_class VARCHAR(32) NOT NULL,
+	name VARCHAR(64) NOT NULL DEFAULT '',
+	rate NUMERIC(6, 3) NOT NULL,
+	inclusive BOOL NOT NULL,
+	taxable_amount NUMERIC(10, 2) NOT NULL,
+	tax_amount NUMERIC(10, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_tax_line_order_id ON order_tax_line (order_id);
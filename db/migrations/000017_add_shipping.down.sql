@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS order_address;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_cost;
+
+ALTER TABLE product DROP COLUMN IF EXISTS weight;
+
+DROP TABLE IF EXISTS user_address;
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS user_address (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	label VARCHAR(64) NOT NULL DEFAULT '',
+	name VARCHAR(255) NOT NULL,
+	line1 VARCHAR(255) NOT NULL,
+	line2 VARCHAR(255) NOT NULL DEFAULT '',
+	city VARCHAR(128) NOT NULL,
+	postal_code VARCHAR(32) NOT NULL,
+	region VARCHAR(16) NOT NULL DEFAULT '',
+	country CHAR(2) NOT NULL,
+	phone VARCHAR(32) NOT NULL DEFAULT '',
+	is_default BOOL NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_address_user_id ON user_address (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_address_default ON user_address (user_id) WHERE is_default;
+
+ALTER TABLE product ADD COLUMN IF NOT EXISTS weight NUMERIC(10, 3) NOT NULL DEFAULT 0 CHECK (weight >= 0);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_cost NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+-- the address an order ships to, copied when it is placed
+CREATE TABLE IF NOT EXISTS order_address (
+	order_id BIGINT PRIMARY KEY REFERENCES orders (id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	line1 VARCHAR(255) NOT NULL,
+	line2 VARCHAR(255) NOT NULL DEFAULT '',
+	city VARCHAR(128) NOT NULL,
+	postal_code VARCHAR(32) NOT NULL,
+	region VARCHAR(16) NOT NULL DEFAULT '',
+	country CHAR(2) NOT NULL,
+	phone VARCHAR(32) NOT NULL DEFAULT ''
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS shipment_item;
+
+DROP TABLE IF EXISTS shipment;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS shipment (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+	carrier VARCHAR(64) NOT NULL,
+	tracking_number VARCHAR(128) NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'shipped' CHECK (status IN ('shipped', 'in_transit', 'delivered')),
+	shipped_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	delivered_at TIMESTAMPTZ,
+	UNIQUE (carrier, tracking_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipment_order_id ON shipment (order_id);
+
+CREATE TABLE IF NOT EXISTS shipment_item (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	shipment_id BIGINT NOT NULL REFERENCES shipment (id) ON DELETE CASCADE,
+	product_id BIGINT NOT NULL,
+	variant_id BIGINT,
+	quantity INT NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipment_item_shipment_id ON shipment_item (shipment_id);
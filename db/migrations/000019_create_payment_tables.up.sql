@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS payment (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+	provider VARCHAR(32) NOT NULL,
+	reference VARCHAR(128),
+	status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'authorized', 'captured', 'voided', 'refunded', 'failed')),
+	amount NUMERIC(10, 2) NOT NULL CHECK (amount >= 0),
+	captured_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (captured_amount >= 0),
+	refunded_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (refunded_amount >= 0 AND refunded_amount <= captured_amount),
+	failure_reason TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	UNIQUE (provider, reference)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_order_id ON payment (order_id);
+
+-- an order has at most one payment in flight or taken
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_order_open ON payment (order_id) WHERE status IN ('pending', 'authorized', 'captured');
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS payment_event (
+	id VARCHAR(128) NOT NULL,
+	provider VARCHAR(32) NOT NULL,
+	type VARCHAR(32) NOT NULL,
+	reference VARCHAR(128) NOT NULL,
+	payload JSONB NOT NULL,
+	occurred_at TIMESTAMPTZ NOT NULL,
+	received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (provider, id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_event_reference ON payment_event (provider, reference);
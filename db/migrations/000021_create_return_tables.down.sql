@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS order_return_item;
+
+DROP TABLE IF EXISTS order_return;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS refunded_amount;
@@ -0,0 +1,27 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refunded_amount NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS order_return (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+	user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	status VARCHAR(16) NOT NULL DEFAULT 'requested' CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'refunded')),
+	note TEXT,
+	restocked BOOL NOT NULL DEFAULT FALSE,
+	refund_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (refund_amount >= 0),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_return_order_id ON order_return (order_id);
+
+CREATE TABLE IF NOT EXISTS order_return_item (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	return_id BIGINT NOT NULL REFERENCES order_return (id) ON DELETE CASCADE,
+	product_id BIGINT NOT NULL,
+	variant_id BIGINT,
+	quantity INT NOT NULL CHECK (quantity > 0),
+	reason VARCHAR(32) NOT NULL CHECK (reason IN ('damaged', 'defective', 'wrong_item', 'not_as_described', 'no_longer_needed', 'other')),
+	comment TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_return_item_return_id ON order_return_item (return_id);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS order_status_record_trigger ON orders;
+
+DROP FUNCTION IF EXISTS order_status_record();
+
+DROP TABLE IF EXISTS order_status_history;
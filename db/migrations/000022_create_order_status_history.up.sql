@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS order_status_history (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+	status VARCHAR(255) NOT NULL,
+	reason TEXT,
+	actor_id BIGINT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history (order_id);
+
+-- the reason and actor of a change are set for the transaction with
+-- set_config('order_status.reason', ...) and set_config('order_status.actor', ...)
+CREATE OR REPLACE FUNCTION order_status_record() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP = 'INSERT' OR NEW.status IS DISTINCT FROM OLD.status THEN
+		INSERT INTO order_status_history (order_id, status, reason, actor_id)
+		VALUES (
+			NEW.id,
+			NEW.status,
+			NULLIF(current_setting('order_status.reason', true), ''),
+			NULLIF(current_setting('order_status.actor', true), '')::BIGINT
+		);
+	END IF;
+
+	RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS order_status_record_trigger ON orders;
+CREATE TRIGGER order_status_record_trigger
+	AFTER INSERT OR UPDATE OF status ON orders
+	FOR EACH ROW EXECUTE FUNCTION order_status_record();
+
+INSERT INTO order_status_history (order_id, status, created_at)
+SELECT id, status, order_date FROM orders;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS order_revision;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS version;
@@ -0,0 +1,13 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS order_revision (
+	id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+	order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+	version INT NOT NULL,
+	actor_id BIGINT,
+	changes JSONB NOT NULL,
+	previous_total NUMERIC(10, 2) NOT NULL,
+	total_amount NUMERIC(10, 2) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	UNIQUE (order_id, version)
+);
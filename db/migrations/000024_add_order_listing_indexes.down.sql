@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_order_product_product_id;
+DROP INDEX IF EXISTS idx_orders_user_id;
+DROP INDEX IF EXISTS idx_orders_status;
+DROP INDEX IF EXISTS idx_orders_total_amount;
+DROP INDEX IF EXISTS idx_orders_order_date;
@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS idx_orders_order_date ON orders (order_date, id);
+CREATE INDEX IF NOT EXISTS idx_orders_total_amount ON orders (total_amount, id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (status, order_date, id);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id, order_date, id);
+CREATE INDEX IF NOT EXISTS idx_order_product_product_id ON order_product (product_id, order_id);
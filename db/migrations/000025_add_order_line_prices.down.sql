@@ -0,0 +1 @@
+ALTER TABLE order_product DROP COLUMN IF EXISTS unit_price;
@@ -0,0 +1,12 @@
+ALTER TABLE order_product ADD COLUMN IF NOT EXISTS unit_price NUMERIC(10, 2);
+
+-- lines placed before their prices were kept are valued at the current prices
+UPDATE order_product op SET unit_price = COALESCE(
+	(SELECT v.price FROM product_variant v WHERE v.id = op.variant_id),
+	(SELECT p.price FROM product p WHERE p.id = op.product_id),
+	0
+)
+WHERE unit_price IS NULL;
+
+ALTER TABLE order_product ALTER COLUMN unit_price SET DEFAULT 0;
+ALTER TABLE order_product ALTER COLUMN unit_price SET NOT NULL;
@@ -0,0 +1,4 @@
+-- imports match products on their SKU, products created without one have none
+ALTER TABLE product ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_sku ON product (sku);
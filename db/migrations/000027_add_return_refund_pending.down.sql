@@ -0,0 +1,5 @@
+UPDATE order_return SET status = 'received', refund_amount = 0 WHERE status = 'refund_pending';
+
+ALTER TABLE order_return DROP CONSTRAINT IF EXISTS order_return_status_check;
+ALTER TABLE order_return ADD CONSTRAINT order_return_status_check
+	CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'refunded'));
@@ -0,0 +1,4 @@
+-- refunds the payment provider has not completed yet
+ALTER TABLE order_return DROP CONSTRAINT IF EXISTS order_return_status_check;
+ALTER TABLE order_return ADD CONSTRAINT order_return_status_check
+	CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'refund_pending', 'refunded'));
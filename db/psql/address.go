@@ -0,0 +1,211 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/user_service/user_utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const addressColumns = "id, user_id, label, name, line1, line2, city, postal_code, region, country, phone, is_default, created_at"
+
+const postalAddressColumns = "name, line1, line2, city, postal_code, region, country, phone"
+
+func (p *PostgresRepo) GetUserAddresses(userID int) ([]user_utils.Address, error) {
+	addresses := []user_utils.Address{}
+
+	err := p.DB.Select(&addresses, "SELECT "+addressColumns+" FROM user_address WHERE user_id = $1 ORDER BY is_default DESC, id", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+func (p *PostgresRepo) GetUserAddress(userID int, id int) (user_utils.Address, error) {
+	var address user_utils.Address
+
+	err := p.DB.Get(&address, "SELECT "+addressColumns+" FROM user_address WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return user_utils.Address{}, err
+	}
+
+	return address, nil
+}
+
+func (p *PostgresRepo) GetDefaultAddress(userID int) (user_utils.Address, error) {
+	var address user_utils.Address
+
+	err := p.DB.Get(&address, "SELECT "+addressColumns+" FROM user_address WHERE user_id = $1 AND is_default", userID)
+	if err != nil {
+		return user_utils.Address{}, err
+	}
+
+	return address, nil
+}
+
+// PostUserAddress adds an address to the address book. The first address of a
+// user becomes the default one.
+func (p *PostgresRepo) PostUserAddress(address *user_utils.Address) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		err := lockUserAddresses(tx, address.UserID)
+		if err != nil {
+			return err
+		}
+
+		var count int
+		err = tx.Get(&count, "SELECT COUNT(*) FROM user_address WHERE user_id = $1", address.UserID)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			address.IsDefault = true
+		}
+
+		if address.IsDefault {
+			_, err = tx.Exec("UPDATE user_address SET is_default = FALSE WHERE user_id = $1 AND is_default", address.UserID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Get(address, `
+			INSERT INTO user_address (user_id, label, name, line1, line2, city, postal_code, region, country, phone, is_default)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING `+addressColumns,
+			address.UserID, address.Label, address.Name, address.Line1, address.Line2, address.City, address.PostalCode, address.Region, address.Country, address.Phone, address.IsDefault,
+		)
+	})
+}
+
+func (p *PostgresRepo) PutUserAddress(address user_utils.Address) (user_utils.Address, error) {
+	var updated user_utils.Address
+
+	err := p.inTx(func(tx *sqlx.Tx) error {
+		err := lockUserAddresses(tx, address.UserID)
+		if err != nil {
+			return err
+		}
+
+		if address.IsDefault {
+			_, err = tx.Exec("UPDATE user_address SET is_default = FALSE WHERE user_id = $1 AND is_default AND id <> $2", address.UserID, address.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Get(&updated, `
+			UPDATE user_address
+			SET label = $1, name = $2, line1 = $3, line2 = $4, city = $5, postal_code = $6, region = $7, country = $8, phone = $9, is_default = $10
+			WHERE id = $11 AND user_id = $12
+			RETURNING `+addressColumns,
+			address.Label, address.Name, address.Line1, address.Line2, address.City, address.PostalCode, address.Region, address.Country, address.Phone, address.IsDefault,
+			address.ID, address.UserID,
+		)
+	})
+	if err != nil {
+		return user_utils.Address{}, err
+	}
+
+	return updated, nil
+}
+
+func (p *PostgresRepo) SetDefaultAddress(userID int, id int) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		err := lockUserAddresses(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("UPDATE user_address SET is_default = FALSE WHERE user_id = $1 AND is_default", userID)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec("UPDATE user_address SET is_default = TRUE WHERE id = $1 AND user_id = $2", id, userID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return errors.New("address not found")
+		}
+		return nil
+	})
+}
+
+// DeleteUserAddress removes an address. When it was the default one, the
+// oldest remaining address takes its place.
+func (p *PostgresRepo) DeleteUserAddress(userID int, id int) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		err := lockUserAddresses(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		var wasDefault bool
+		err = tx.Get(&wasDefault, "DELETE FROM user_address WHERE id = $1 AND user_id = $2 RETURNING is_default", id, userID)
+		if err != nil {
+			return errors.New("address not found")
+		}
+
+		if wasDefault {
+			_, err = tx.Exec("UPDATE user_address SET is_default = TRUE WHERE id = (SELECT id FROM user_address WHERE user_id = $1 ORDER BY id LIMIT 1)", userID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lockUserAddresses locks the user row, so that concurrent changes of the
+// addresses of a user cannot both decide which one is the default.
+func lockUserAddresses(tx *sqlx.Tx, userID int) error {
+	var id int
+	err := tx.Get(&id, "SELECT id FROM users WHERE id = $1 FOR UPDATE", userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("user not found")
+	}
+	return err
+}
+
+func (p *PostgresRepo) GetOrderAddress(orderID int) (*user_utils.PostalAddress, error) {
+	var address user_utils.PostalAddress
+
+	err := p.DB.Get(&address, "SELECT "+postalAddressColumns+" FROM order_address WHERE order_id = $1", orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// orders placed before shipping addresses were recorded have none
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+func insertOrderAddress(tx *sqlx.Tx, order *models.Order) error {
+	if order.ShippingAddress == nil {
+		return nil
+	}
+
+	a := order.ShippingAddress
+	_, err := tx.Exec(`
+		INSERT INTO order_address (order_id, `+postalAddressColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		order.ID, a.Name, a.Line1, a.Line2, a.City, a.PostalCode, a.Region, a.Country, a.Phone,
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package psql
+
+import (
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/product_service/utils"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type backorderedLine struct {
+	ID          int  `db:"id"`
+	OrderID     int  `db:"order_id"`
+	UserID      int  `db:"user_id"`
+	VariantID   *int `db:"variant_id"`
+	Backordered int  `db:"backordered_quantity"`
+}
+
+// AllocateBackorders hands the available stock of a product to its waiting
+// backordered lines, oldest order first. Stock held by active reservations is
+// left alone.
+func (p *PostgresRepo) AllocateBackorders(productID int) ([]models.BackorderFill, error) {
+	var filled []models.BackorderFill
+
+	err := p.inTx(func(tx *sqlx.Tx) error {
+		// the product row is locked first, like every order and reservation does
+		var onHand int
+		err := tx.Get(&onHand, "SELECT stock_quantity FROM product WHERE id = $1 FOR UPDATE", productID)
+		if err != nil {
+			return err
+		}
+
+		var waiting []backorderedLine
+		err = tx.Select(&waiting, `
+			SELECT op.id, op.order_id, o.user_id, op.variant_id, op.backordered_quantity
+			FROM order_product op
+			JOIN orders o ON o.id = op.order_id
+			WHERE op.product_id = $1 AND op.backordered_quantity > 0 AND o.status <> 'cancelled'
+			ORDER BY o.order_date, op.id
+			FOR UPDATE OF op`,
+			productID,
+		)
+		if err != nil {
+			return err
+		}
+
+		available := map[int]int{}
+		for _, line := range waiting {
+			key := 0
+			if line.VariantID != nil {
+				key = *line.VariantID
+			}
+
+			free, ok := available[key]
+			if !ok {
+				free, err = availableStock(tx, productID, line.VariantID, onHand)
+				if err != nil {
+					return err
+				}
+			}
+
+			quantity := min(free, line.Backordered)
+			available[key] = free - max(quantity, 0)
+			if quantity <= 0 {
+				continue
+			}
+
+			movement := utils.NewMovement(utils.ReasonOrder, strconv.Itoa(line.OrderID), line.UserID)
+			if line.VariantID != nil {
+				err = decreaseStock(tx, "UPDATE product_variant SET stock_quantity = stock_quantity - $1 WHERE id = $2 AND stock_quantity >= $1", quantity, *line.VariantID)
+				if err != nil {
+					return err
+				}
+				err = recordMovement(tx, variantMovement(movement, productID, *line.VariantID, -quantity))
+			} else {
+				err = allocateFromWarehouses(tx, line.OrderID, productID, quantity, movement)
+			}
+			if err != nil {
+				return err
+			}
+
+			remaining := line.Backordered - quantity
+			status := models.LineBackordered
+			if remaining == 0 {
+				status = models.LineAllocated
+			}
+			_, err = tx.Exec("UPDATE order_product SET backordered_quantity = $1, status = $2 WHERE id = $3", remaining, status, line.ID)
+			if err != nil {
+				return err
+			}
+
+			filled = append(filled, models.BackorderFill{
+				OrderID:   line.OrderID,
+				UserID:    line.UserID,
+				ProductID: productID,
+				VariantID: line.VariantID,
+				Quantity:  quantity,
+				Remaining: remaining,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return filled, nil
+}
+
+func availableStock(tx *sqlx.Tx, productID int, variantID *int, productOnHand int) (int, error) {
+	onHand := productOnHand
+	if variantID != nil {
+		err := tx.Get(&onHand, "SELECT stock_quantity FROM product_variant WHERE id = $1 FOR UPDATE", *variantID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var reserved int
+	err := tx.Get(&reserved, reservedQuantity, productID, variantID)
+	if err != nil {
+		return 0, err
+	}
+	return onHand - reserved, nil
+}
+
+// allocateFromWarehouses takes the quantity from the warehouses in priority
+// order and records the allocations, so that a cancellation returns the stock
+// where it came from.
+func allocateFromWarehouses(tx *sqlx.Tx, orderID int, productID int, quantity int, movement utils.StockMovement) error {
+	var levels []models.Allocation
+	err := tx.Select(&levels, `
+		SELECT ws.product_id, ws.warehouse_id, ws.quantity
+		FROM warehouse_stock ws
+		JOIN warehouse w ON w.id = ws.warehouse_id
+		WHERE ws.product_id = $1 AND ws.quantity > 0
+		ORDER BY w.priority, w.id
+		FOR UPDATE OF ws`,
+		productID,
+	)
+	if err != nil {
+		return err
+	}
+
+	remaining := quantity
+	for _, level := range levels {
+		if remaining == 0 {
+			break
+		}
+		take := min(level.Quantity, remaining)
+		remaining -= take
+
+		err = decreaseStock(tx, "UPDATE warehouse_stock SET quantity = quantity - $1 WHERE warehouse_id = $2 AND product_id = $3 AND quantity >= $1", take, level.WarehouseID, productID)
+		if err != nil {
+			return err
+		}
+
+		err = recordMovement(tx, warehouseMovement(movement, productID, level.WarehouseID, -take))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO order_allocation (order_id, product_id, warehouse_id, quantity) VALUES ($1, $2, $3, $4)", orderID, productID, level.WarehouseID, take)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package psql
+
+import (
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (p *PostgresRepo) GetCartItems(userID int) ([]models.CartItem, error) {
+	items := []models.CartItem{}
+	err := p.DB.Select(&items, "SELECT product_id, variant_id, quantity, added_price FROM cart_item WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// PutCartItems replaces the cart of a user with the given items.
+func (p *PostgresRepo) PutCartItems(userID int, items []models.CartItem) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("DELETE FROM cart_item WHERE user_id = $1", userID)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			_, err = tx.Exec("INSERT INTO cart_item (user_id, product_id, variant_id, quantity, added_price) VALUES ($1, $2, $3, $4, $5)", userID, item.ProductID, item.VariantID, item.Quantity, item.AddedPrice)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MergeCartItems adds the items of a guest cart to the cart of a user.
+// Quantities of lines already in the user's cart are summed.
+func (p *PostgresRepo) MergeCartItems(userID int, items []models.CartItem) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		for _, item := range items {
+			_, err := tx.Exec(`
+				INSERT INTO cart_item (user_id, product_id, variant_id, quantity, added_price) VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (user_id, product_id, (COALESCE(variant_id, 0)))
+				DO UPDATE SET quantity = cart_item.quantity + EXCLUDED.quantity, updated_at = NOW()`,
+				userID, item.ProductID, item.VariantID, item.Quantity, item.AddedPrice,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
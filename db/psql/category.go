@@ -0,0 +1,133 @@
+package psql
+
+import (
+	"errors"
+	"order_processing_system/product_service/utils"
+)
+
+const categorySubtree = `
+	WITH RECURSIVE tree AS (
+		SELECT id FROM category WHERE id = $1
+		UNION ALL
+		SELECT c.id FROM category c JOIN tree t ON c.parent_id = t.id
+	)`
+
+func (p *PostgresRepo) GetCategories() ([]utils.Category, error) {
+	categories := []utils.Category{}
+
+	err := p.DB.Select(&categories, "SELECT id, name, description, parent_id FROM category ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (p *PostgresRepo) GetCategoryByID(id int) (utils.Category, error) {
+	var category utils.Category
+
+	err := p.DB.Get(&category, "SELECT id, name, description, parent_id FROM category WHERE id = $1", id)
+	if err != nil {
+		return utils.Category{}, err
+	}
+
+	return category, nil
+}
+
+func (p *PostgresRepo) PostCategory(category *utils.Category) error {
+	return p.DB.Get(category, "INSERT INTO category (name, description, parent_id) VALUES ($1, $2, $3) RETURNING id, name, description, parent_id", category.Name, category.Description, category.ParentID)
+}
+
+func (p *PostgresRepo) PutCategory(category utils.Category) (utils.Category, error) {
+	var updated utils.Category
+
+	err := p.DB.Get(&updated, `
+		UPDATE category
+		SET name = $1, description = $2, parent_id = $3
+		WHERE id = $4
+		RETURNING id, name, description, parent_id`,
+		category.Name, category.Description, category.ParentID, category.ID,
+	)
+	if err != nil {
+		return utils.Category{}, err
+	}
+
+	return updated, nil
+}
+
+func (p *PostgresRepo) DeleteCategory(id int) error {
+	var children int
+	err := p.DB.Get(&children, "SELECT COUNT(*) FROM category WHERE parent_id = $1", id)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return errors.New("category has subcategories")
+	}
+
+	res, err := p.DB.Exec("DELETE FROM category WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("category not found")
+	}
+	return nil
+}
+
+// IsCategoryInSubtree reports whether candidate is root itself or one of its
+// descendants. It is used to reject parent changes that would create a cycle.
+func (p *PostgresRepo) IsCategoryInSubtree(root int, candidate int) (bool, error) {
+	var found bool
+
+	err := p.DB.Get(&found, categorySubtree+" SELECT EXISTS (SELECT 1 FROM tree WHERE id = $2)", root, candidate)
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+func (p *PostgresRepo) GetProductCategoryIDs(productID int) ([]int, error) {
+	ids := []int{}
+
+	err := p.DB.Select(&ids, "SELECT category_id FROM product_category WHERE product_id = $1 ORDER BY category_id", productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (p *PostgresRepo) SetProductCategories(productID int, categoryIDs []int) error {
+	tx, err := p.DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
+	_, err = tx.Exec("DELETE FROM product_category WHERE product_id = $1", productID)
+	if err != nil {
+		return err
+	}
+
+	for _, categoryID := range categoryIDs {
+		_, err = tx.Exec("INSERT INTO product_category (product_id, category_id) VALUES ($1, $2)", productID, categoryID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package psql
+
+import (
+	"fmt"
+	"order_processing_system/order_service/order_utils/models"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// orderCursorCasts types the sort value of a cursor for the comparison with
+// its column.
+var orderCursorCasts = map[string]string{
+	"id":           "bigint",
+	"order_date":   "timestamptz",
+	"total_amount": "numeric",
+}
+
+// GetOrderList returns the orders matching the query with their lines, and
+// how many match over all pages. It reads one order beyond the limit, which
+// tells the caller whether another page follows.
+func (p *PostgresRepo) GetOrderList(query models.OrderQuery) ([]models.Order, int, error) {
+	conditions, args := orderFilters(query)
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := p.DB.Get(&total, "SELECT COUNT(*) FROM orders"+where, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	column, direction, err := query.SortColumn()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value, afterID, err := query.After()
+	if err != nil {
+		return nil, 0, err
+	}
+	if afterID != 0 {
+		operator := ">"
+		if direction == "DESC" {
+			operator = "<"
+		}
+		args = append(args, value, afterID)
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d::%s, $%d)", column, operator, len(args)-1, orderCursorCasts[column], len(args)))
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, query.Limit+1)
+	sqlQuery := fmt.Sprintf("SELECT * FROM orders%s ORDER BY %s %s, id %s LIMIT $%d", where, column, direction, direction, len(args))
+
+	orders := []models.Order{}
+	err = p.DB.Select(&orders, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = p.loadOrderLines(orders)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
+
+// GetOrdersByID returns the orders with the ids given and their lines, ids
+// without an order are left out.
+func (p *PostgresRepo) GetOrdersByID(ids []int) ([]models.Order, error) {
+	orders := []models.Order{}
+	if len(ids) == 0 {
+		return orders, nil
+	}
+
+	sqlQuery, args, err := sqlx.In("SELECT * FROM orders WHERE id IN (?) ORDER BY id", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.DB.Select(&orders, p.DB.Rebind(sqlQuery), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.loadOrderLines(orders)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// loadOrderLines fills in the lines of a list of orders with one query.
+func (p *PostgresRepo) loadOrderLines(orders []models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(orders))
+	index := map[int]int{}
+	for i, order := range orders {
+		ids[i] = order.ID
+		index[order.ID] = i
+		orders[i].Products = []models.OrderProduct{}
+	}
+
+	sqlQuery, args, err := sqlx.In("SELECT order_id, "+orderLineColumns+" FROM order_product WHERE order_id IN (?) ORDER BY id", ids)
+	if err != nil {
+		return err
+	}
+
+	var lines []struct {
+		OrderID int `db:"order_id"`
+		models.OrderProduct
+	}
+	err = p.DB.Select(&lines, p.DB.Rebind(sqlQuery), args...)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		i := index[line.OrderID]
+		orders[i].Products = append(orders[i].Products, line.OrderProduct)
+	}
+	return nil
+}
+
+// orderFilters turns the filters of an order query into conditions on the
+// orders table, numbering their arguments from $1.
+func orderFilters(query models.OrderQuery) ([]string, []any) {
+	var conditions []string
+	var args []any
+
+	if len(query.Statuses) > 0 {
+		placeholders := make([]string, len(query.Statuses))
+		for i, status := range query.Statuses {
+			args = append(args, status)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, "orders.status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if query.UserID != 0 {
+		args = append(args, query.UserID)
+		conditions = append(conditions, fmt.Sprintf("orders.user_id = $%d", len(args)))
+	}
+	if query.ProductID != 0 {
+		args = append(args, query.ProductID)
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM order_product op WHERE op.order_id = orders.id AND op.product_id = $%d)", len(args)))
+	}
+	if query.From != nil {
+		args = append(args, *query.From)
+		conditions = append(conditions, fmt.Sprintf("orders.order_date >= $%d", len(args)))
+	}
+	if query.To != nil {
+		args = append(args, *query.To)
+		conditions = append(conditions, fmt.Sprintf("orders.order_date < $%d", len(args)))
+	}
+	if query.MinTotal != nil {
+		args = append(args, *query.MinTotal)
+		conditions = append(conditions, fmt.Sprintf("orders.total_amount >= $%d", len(args)))
+	}
+	if query.MaxTotal != nil {
+		args = append(args, *query.MaxTotal)
+		conditions = append(conditions, fmt.Sprintf("orders.total_amount <= $%d", len(args)))
+	}
+
+	return conditions, args
+}
@@ -0,0 +1,127 @@
+package psql
+
+import (
+	"errors"
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/product_service/utils"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// orderStatusReason tells the status history trigger why the order statuses
+// change in this transaction and who changes them.
+func orderStatusReason(tx *sqlx.Tx, reason string, actorID int) error {
+	actor := ""
+	if actorID != 0 {
+		actor = strconv.Itoa(actorID)
+	}
+	_, err := tx.Exec("SELECT set_config('order_status.reason', $1, true), set_config('order_status.actor', $2, true)", reason, actor)
+	return err
+}
+
+func (p *PostgresRepo) GetOrderStatusHistory(orderID int) ([]models.StatusChange, error) {
+	history := []models.StatusChange{}
+	err := p.DB.Select(&history, "SELECT status, reason, actor_id, created_at FROM order_status_history WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetUnpaidOrders lists the orders still waiting for payment that were placed
+// before the time given.
+func (p *PostgresRepo) GetUnpaidOrders(before time.Time) ([]int, error) {
+	var ids []int
+	err := p.DB.Select(&ids, "SELECT id FROM orders WHERE status = $1 AND order_date < $2 ORDER BY id", models.OrderCreated, before)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ExpireOrder cancels an order that is still waiting for payment. Orders with
+// a payment in progress are left alone, the order row lock keeps payments
+// from starting meanwhile. Its stock is returned in the same transaction. It
+// returns whether the order was cancelled.
+func (p *PostgresRepo) ExpireOrder(orderID int, reason string) (bool, error) {
+	var expired bool
+
+	err := p.inTx(func(tx *sqlx.Tx) error {
+		var status string
+		err := tx.Get(&status, "SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID)
+		if err != nil {
+			return errors.New("order not found")
+		}
+
+		var payments int
+		err = tx.Get(&payments, "SELECT COUNT(*) FROM payment WHERE order_id = $1 AND status IN ('pending', 'authorized', 'captured')", orderID)
+		if err != nil {
+			return err
+		}
+		if !models.Expirable(status, payments) {
+			return nil
+		}
+
+		err = orderStatusReason(tx, reason, 0)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("UPDATE orders SET status = $1 WHERE id = $2", models.OrderCancelled, orderID)
+		if err != nil {
+			return err
+		}
+
+		err = restockOrder(tx, orderID, utils.NewMovement(utils.ReasonCancellation, strconv.Itoa(orderID), 0))
+		if err != nil {
+			return err
+		}
+
+		expired = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return expired, nil
+}
+
+// restockOrder returns the stock taken by the lines of an order: product
+// lines go back to the warehouses they were allocated from, variant lines and
+// orders placed before warehouse allocation to the variant or the default
+// warehouse. Backordered quantities were never taken.
+func restockOrder(tx *sqlx.Tx, orderID int, movement utils.StockMovement) error {
+	var lines []models.OrderProduct
+	err := tx.Select(&lines, "SELECT "+orderLineColumns+" FROM order_product WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		return err
+	}
+
+	released, err := releaseOrderAllocations(tx, orderID, movement)
+	if err != nil {
+		return err
+	}
+
+	allocated := map[int]bool{}
+	for _, allocation := range released {
+		allocated[allocation.ProductID] = true
+	}
+
+	for _, line := range lines {
+		if line.Fulfilled() == 0 || (line.VariantID == nil && allocated[line.ProductID]) {
+			continue
+		}
+		if line.VariantID != nil {
+			err = addVariantStock(tx, *line.VariantID, line.Fulfilled(), movement)
+		} else {
+			err = changeDefaultWarehouseStock(tx, line.ProductID, line.Fulfilled(), movement)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package psql
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const paymentColumns = "id, order_id, provider, reference, status, amount, captured_amount, refunded_amount, failure_reason, created_at, updated_at"
+
+// PostPayment records a pending payment before the provider is called. Only
+// one payment of an order can be pending, authorized or captured at a time,
+// and only while the order waits for payment. The order row is locked so that
+// the expiry of unpaid orders cannot cancel it meanwhile.
+func (p *PostgresRepo) PostPayment(payment *models.Payment) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		var status string
+		err := tx.Get(&status, "SELECT status FROM orders WHERE id = $1 FOR UPDATE", payment.OrderID)
+		if err != nil {
+			return errors.New("order not found")
+		}
+		if status != models.OrderCreated {
+			return fmt.Errorf("%s orders cannot be paid", status)
+		}
+
+		err = tx.Get(payment, "INSERT INTO payment (order_id, provider, amount) VALUES ($1, $2, $3) RETURNING "+paymentColumns, payment.OrderID, payment.Provider, payment.Amount)
+		if err != nil {
+			log.Println(err)
+			return errors.New("the order already has a payment in progress")
+		}
+		return nil
+	})
+}
+
+func (p *PostgresRepo) GetPayment(orderID int, paymentID int) (models.Payment, error) {
+	var payment models.Payment
+	err := p.DB.Get(&payment, "SELECT "+paymentColumns+" FROM payment WHERE id = $1 AND order_id = $2", paymentID, orderID)
+	if err != nil {
+		log.Println(err)
+		return models.Payment{}, errors.New("payment not found")
+	}
+	return payment, nil
+}
+
+func (p *PostgresRepo) GetOrderPayments(orderID int) ([]models.Payment, error) {
+	payments := []models.Payment{}
+	err := p.DB.Select(&payments, "SELECT "+paymentColumns+" FROM payment WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// PutPayment saves the outcome of a provider call on a payment that is still
+// in the status it was read in. It returns whether the order was paid by
+// this update.
+func (p *PostgresRepo) PutPayment(payment *models.Payment, from string) (bool, error) {
+	var paid bool
+
+	err := p.inTx(func(tx *sqlx.Tx) error {
+		var err error
+		paid, err = updatePayment(tx, payment, from)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return paid, nil
+}
+
+// ApplyPaymentEvent records a webhook event of a provider and applies it to
+// the payment it is about. It returns a nil payment for an event that was
+// already received. An event for a payment that is not known yet is not
+// recorded, so that the provider's retry applies it later.
+func (p *PostgresRepo) ApplyPaymentEvent(provider string, event models.PaymentEvent, payload []byte) (*models.Payment, bool, error) {
+	var payment *models.Payment
+	var paid bool
+
+	err := p.inTx(func(tx *sqlx.Tx) error {
+		res, err := tx.Exec("INSERT INTO payment_event (id, provider, type, reference, payload, occurred_at) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING", event.ID, provider, event.Type, event.Reference, payload, event.OccurredAt)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		var current models.Payment
+		err = tx.Get(&current, "SELECT "+paymentColumns+" FROM payment WHERE provider = $1 AND reference = $2 FOR UPDATE", provider, event.Reference)
+		if err != nil {
+			log.Println(err)
+			return fmt.Errorf("payment %s not found", event.Reference)
+		}
+
+		payment = &current
+		from := current.Status
+		if !current.ApplyEvent(event) {
+			return nil
+		}
+
+		paid, err = updatePayment(tx, payment, from)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return payment, paid, nil
+}
+
+// updatePayment saves a payment still in the from status. A captured payment
+// moves its order from created to paid, which is reported back, and refunds
+// are carried over to the order.
+func updatePayment(tx *sqlx.Tx, payment *models.Payment, from string) (bool, error) {
+	err := tx.Get(payment, `
+		UPDATE payment
+		SET reference = $1, status = $2, captured_amount = $3, refunded_amount = $4, failure_reason = $5, updated_at = NOW()
+		WHERE id = $6 AND status = $7
+		RETURNING `+paymentColumns,
+		payment.Reference, payment.Status, payment.CapturedAmount, payment.RefundedAmount, payment.FailureReason, payment.ID, from,
+	)
+	if err != nil {
+		log.Println(err)
+		return false, errors.New("payment was updated concurrently")
+	}
+
+	err = orderStatusReason(tx, fmt.Sprintf("payment %d %s", payment.ID, payment.Status), 0)
+	if err != nil {
+		return false, err
+	}
+
+	if payment.RefundedAmount > 0 {
+		err = syncOrderRefunds(tx, payment.OrderID)
+		if err != nil {
+			return false, err
+		}
+
+		err = settleReturnRefunds(tx, payment.OrderID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if payment.Status != models.PaymentCaptured {
+		return false, nil
+	}
+	return markOrderPaid(tx, payment.OrderID)
+}
+
+// syncOrderRefunds sets the refunded amount of an order to what its payments
+// refunded, and its status to the one models.RefundStatus gives.
+func syncOrderRefunds(tx *sqlx.Tx, orderID int) error {
+	var order models.Order
+	err := tx.Get(&order, "SELECT id, status, total_amount FROM orders WHERE id = $1 FOR UPDATE", orderID)
+	if err != nil {
+		return err
+	}
+
+	var refunded float64
+	err = tx.Get(&refunded, "SELECT COALESCE(SUM(refunded_amount), 0) FROM payment WHERE order_id = $1", orderID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE orders SET refunded_amount = $1, status = $2 WHERE id = $3",
+		refunded, models.RefundStatus(order.Status, refunded, order.TotalAmount), orderID,
+	)
+	return err
+}
+
+// settleReturnRefunds marks the returns waiting for their refund as refunded,
+// oldest first, as long as the refunds of the order cover them.
+func settleReturnRefunds(tx *sqlx.Tx, orderID int) error {
+	_, err := tx.Exec(`
+		UPDATE order_return r
+		SET status = $2, updated_at = NOW()
+		WHERE r.order_id = $1 AND r.status = $3
+			AND (
+				SELECT COALESCE(SUM(refund_amount), 0) FROM order_return
+				WHERE order_id = $1 AND (status = $2 OR (status = $3 AND id <= r.id))
+			) <= (SELECT refunded_amount FROM orders WHERE id = $1)`,
+		orderID, models.ReturnRefunded, models.ReturnRefundPending,
+	)
+	return err
+}
+
+func markOrderPaid(tx *sqlx.Tx, orderID int) (bool, error) {
+	res, err := tx.Exec("UPDATE orders SET status = $1 WHERE id = $2 AND status = $3", models.OrderPaid, orderID, models.OrderCreated)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
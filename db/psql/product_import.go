@@ -0,0 +1,106 @@
+package psql
+
+import (
+	"fmt"
+	"order_processing_system/product_service/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetProductIDsBySKU maps the SKUs of existing products to their ids.
+func (p *PostgresRepo) GetProductIDsBySKU(skus []string) (map[string]int, error) {
+	ids := map[string]int{}
+	if len(skus) == 0 {
+		return ids, nil
+	}
+
+	query, args, err := sqlx.In("SELECT id, sku FROM product WHERE sku IN (?)", skus)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []struct {
+		ID  int    `db:"id"`
+		SKU string `db:"sku"`
+	}
+	err = p.DB.Select(&products, p.DB.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		ids[product.SKU] = product.ID
+	}
+	return ids, nil
+}
+
+// UpsertProducts writes the products in one transaction, matching them on
+// their SKU. New products get their stock in the default warehouse, existing
+// ones keep their stock like PutProduct does. The products are replaced by
+// the stored rows and the returned flags tell which ones were created.
+func (p *PostgresRepo) UpsertProducts(products []utils.Product, movement utils.StockMovement) ([]bool, error) {
+	created := make([]bool, len(products))
+
+	err := p.inTx(func(tx *sqlx.Tx) error {
+		for i, product := range products {
+			var upserted struct {
+				utils.Product
+				Created bool `db:"created"`
+			}
+			// xmax is only zero for a row this statement inserted
+			err := tx.Get(&upserted, `
+				INSERT INTO product (sku, name, description, price, stock_quantity, availability, expected_at, tax_class, weight)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+				ON CONFLICT (sku) DO UPDATE
+				SET name = EXCLUDED.name, description = EXCLUDED.description, price = EXCLUDED.price,
+					availability = EXCLUDED.availability, expected_at = EXCLUDED.expected_at,
+					tax_class = EXCLUDED.tax_class, weight = EXCLUDED.weight
+				RETURNING `+productColumns+`, xmax = 0 AS created`,
+				product.SKU, product.Name, product.Description, product.Price, product.StockQuantity, product.Availability, product.ExpectedAt, product.TaxClass, product.Weight,
+			)
+			if err != nil {
+				return fmt.Errorf("sku %s: %w", *product.SKU, err)
+			}
+
+			if upserted.Created {
+				err = stockNewProduct(tx, upserted.Product, movement)
+				if err != nil {
+					return err
+				}
+			}
+
+			products[i] = upserted.Product
+			created[i] = upserted.Created
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// ExportProducts reads the whole catalog by id and hands the products to fn
+// one at a time without holding the export in memory.
+func (p *PostgresRepo) ExportProducts(fn func(utils.Product) error) error {
+	rows, err := p.DB.Queryx("SELECT " + productColumns + " FROM product ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product utils.Product
+		err = rows.StructScan(&product)
+		if err != nil {
+			return err
+		}
+
+		err = fn(product)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,158 @@
+package psql
+
+import (
+	"errors"
+	"log"
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const promotionColumns = "id, code, description, type, value, product_id, buy_quantity, get_quantity, min_basket, usage_limit, per_user_limit, starts_at, ends_at, active, created_at"
+
+// promotionUsage counts the orders that used a promotion, overall and by one
+// user. Cancelled orders give their use back.
+const promotionUsage = `
+	SELECT COUNT(*) AS used, COUNT(*) FILTER (WHERE d.user_id = $2) AS used_by_user
+	FROM order_discount d
+	JOIN orders o ON o.id = d.order_id
+	WHERE d.promotion_id = $1 AND o.status <> 'cancelled'`
+
+type usage struct {
+	Used       int `db:"used"`
+	UsedByUser int `db:"used_by_user"`
+}
+
+func (p *PostgresRepo) GetPromotions() ([]models.Promotion, error) {
+	promotions := []models.Promotion{}
+
+	err := p.DB.Select(&promotions, "SELECT "+promotionColumns+" FROM promotion ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+func (p *PostgresRepo) GetPromotionByID(id int) (models.Promotion, error) {
+	var promotion models.Promotion
+
+	err := p.DB.Get(&promotion, "SELECT "+promotionColumns+" FROM promotion WHERE id = $1", id)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+func (p *PostgresRepo) GetPromotionByCode(code string) (models.Promotion, error) {
+	var promotion models.Promotion
+
+	err := p.DB.Get(&promotion, "SELECT "+promotionColumns+" FROM promotion WHERE code = $1", code)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	return promotion, nil
+}
+
+func (p *PostgresRepo) PostPromotion(promotion *models.Promotion) error {
+	return p.DB.Get(promotion, `
+		INSERT INTO promotion (code, description, type, value, product_id, buy_quantity, get_quantity, min_basket, usage_limit, per_user_limit, starts_at, ends_at, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING `+promotionColumns,
+		promotion.Code, promotion.Description, promotion.Type, promotion.Value, promotion.ProductID, promotion.BuyQuantity, promotion.GetQuantity,
+		promotion.MinBasket, promotion.UsageLimit, promotion.PerUserLimit, promotion.StartsAt, promotion.EndsAt, promotion.Active,
+	)
+}
+
+// PutPromotion replaces a promotion, keeping its active flag unless the
+// update sets it.
+func (p *PostgresRepo) PutPromotion(promotion models.PromotionUpdate) (models.Promotion, error) {
+	var updated models.Promotion
+
+	err := p.DB.Get(&updated, `
+		UPDATE promotion
+		SET code = $1, description = $2, type = $3, value = $4, product_id = $5, buy_quantity = $6, get_quantity = $7,
+			min_basket = $8, usage_limit = $9, per_user_limit = $10, starts_at = $11, ends_at = $12, active = COALESCE($13, active)
+		WHERE id = $14
+		RETURNING `+promotionColumns,
+		promotion.Code, promotion.Description, promotion.Type, promotion.Value, promotion.ProductID, promotion.BuyQuantity, promotion.GetQuantity,
+		promotion.MinBasket, promotion.UsageLimit, promotion.PerUserLimit, promotion.StartsAt, promotion.EndsAt, promotion.Active, promotion.ID,
+	)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	return updated, nil
+}
+
+// DeactivatePromotion stops a promotion from being used. It is kept because
+// orders refer to it.
+func (p *PostgresRepo) DeactivatePromotion(id int) error {
+	res, err := p.DB.Exec("UPDATE promotion SET active = FALSE WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("promotion not found")
+	}
+	return nil
+}
+
+func (p *PostgresRepo) GetPromotionUsage(promotionID int, userID int) (int, int, error) {
+	var u usage
+	err := p.DB.Get(&u, promotionUsage, promotionID, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return u.Used, u.UsedByUser, nil
+}
+
+func (p *PostgresRepo) GetOrderDiscounts(orderID int) ([]models.OrderDiscount, error) {
+	discounts := []models.OrderDiscount{}
+
+	err := p.DB.Select(&discounts, "SELECT promotion_id, code, description, amount FROM order_discount WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return discounts, nil
+}
+
+// redeemPromotions stores the discounts of an order. The promotion rows are
+// locked and the limits checked again, so that concurrent orders cannot both
+// take the last use of a coupon.
+func redeemPromotions(tx *sqlx.Tx, order *models.Order) error {
+	for _, discount := range order.Discounts {
+		var promotion models.Promotion
+		err := tx.Get(&promotion, "SELECT "+promotionColumns+" FROM promotion WHERE id = $1 FOR UPDATE", discount.PromotionID)
+		if err != nil {
+			return err
+		}
+
+		var u usage
+		err = tx.Get(&u, promotionUsage, promotion.ID, order.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = promotion.CheckUsage(u.Used, u.UsedByUser)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO order_discount (order_id, promotion_id, user_id, code, description, amount) VALUES ($1, $2, $3, $4, $5, $6)", order.ID, promotion.ID, order.UserID, discount.Code, discount.Description, discount.Amount)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+	return nil
+}
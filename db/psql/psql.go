@@ -7,12 +7,18 @@ import (
 	"order_processing_system/order_service/order_utils/models"
 	"order_processing_system/product_service/utils"
 	"order_processing_system/user_service/user_utils"
+	"strconv"
+	"strings"
 
 	_ "github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 )
 
+const productColumns = "id, sku, name, description, price, stock_quantity, availability, expected_at, tax_class, weight"
+
+const orderLineColumns = "product_id, variant_id, quantity, status, backordered_quantity, unit_price"
+
 type PSQLConfig struct {
 	Host     string
 	Port     string
@@ -23,10 +29,12 @@ type PSQLConfig struct {
 
 type PostgresRepo struct {
 	DB *sqlx.DB
+	// stockLevels is set on the repos of WithStockLevels.
+	stockLevels *stockLevelTracker
 }
 
 type PostgresCRUD interface {
-	GetProductsList() ([]utils.Product, error)
+	GetProductsList(query utils.ProductQuery) ([]utils.Product, int, error)
 	GetProductByID(id int) (utils.Product, error)
 	GetProductQuantity(id int) (utils.Product, error)
 	PostProduct(product *utils.Product) error
@@ -60,21 +68,119 @@ func NewPSQLRepo(db *sqlx.DB) *PostgresRepo {
 	}
 }
 
-func (p *PostgresRepo) GetProductsList() ([]utils.Product, error) {
-	var products []utils.Product
+func (p *PostgresRepo) GetProductsList(query utils.ProductQuery) ([]utils.Product, int, error) {
+	var conditions []string
+	var args []any
+
+	if query.MinPrice != nil {
+		args = append(args, *query.MinPrice)
+		conditions = append(conditions, fmt.Sprintf("price >= $%d", len(args)))
+	}
+	if query.MaxPrice != nil {
+		args = append(args, *query.MaxPrice)
+		conditions = append(conditions, fmt.Sprintf("price <= $%d", len(args)))
+	}
+	if query.InStock {
+		conditions = append(conditions, "stock_quantity > 0")
+	}
+	if query.CategoryID != 0 {
+		args = append(args, query.CategoryID)
+		conditions = append(conditions, fmt.Sprintf(`id IN (
+			SELECT product_id FROM product_category WHERE category_id IN (
+				WITH RECURSIVE tree AS (
+					SELECT id FROM category WHERE id = $%d
+					UNION ALL
+					SELECT c.id FROM category c JOIN tree t ON c.parent_id = t.id
+				)
+				SELECT id FROM tree
+			)
+		)`, len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := p.DB.Get(&total, "SELECT COUNT(*) FROM product"+where, args...)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	err := p.DB.Select(&products, "SELECT * FROM product")
+	column, direction, err := query.SortColumn()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, query.Limit, query.Offset())
+	sqlQuery := fmt.Sprintf("SELECT "+productColumns+" FROM product%s ORDER BY %s %s, id %s LIMIT $%d OFFSET $%d",
+		where, column, direction, direction, len(args)-1, len(args))
+
+	products := []utils.Product{}
+	err = p.DB.Select(&products, sqlQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, nil
+}
+
+func (p *PostgresRepo) SearchProducts(tsQuery string, text string, limit int) ([]utils.ProductSearchResult, error) {
+	results := []utils.ProductSearchResult{}
+
+	err := p.DB.Select(&results, `
+		SELECT `+productColumns+`,
+			ts_rank_cd(search_vector, query) AS rank,
+			ts_headline('english', name, query, $3 || ', HighlightAll=true') AS name_highlight,
+			ts_headline('english', COALESCE(description, ''), query, $3 || ', MinWords=5, MaxWords=20') AS snippet
+		FROM product, to_tsquery('english', $1) AS query
+		WHERE search_vector @@ query
+		ORDER BY rank DESC, id
+		LIMIT $2`,
+		tsQuery, limit, highlightOptions,
+	)
 	if err != nil {
 		return nil, err
 	}
+	if len(results) > 0 {
+		escapeHighlights(results)
+		return results, nil
+	}
 
-	return products, nil
+	err = p.DB.Select(&results, `
+		SELECT `+productColumns+`,
+			GREATEST(similarity(name, $1), word_similarity($1, name), word_similarity($1, COALESCE(description, ''))) AS rank,
+			name AS name_highlight,
+			COALESCE(LEFT(description, 160), '') AS snippet
+		FROM product
+		WHERE name % $1 OR $1 <% name OR $1 <% description
+		ORDER BY rank DESC, id
+		LIMIT $2`,
+		text, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	escapeHighlights(results)
+	return results, nil
+}
+
+// highlightOptions has ts_headline mark matches with markers that are not
+// HTML, the results are escaped before they become <mark> tags.
+var highlightOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s"`, utils.HighlightStart, utils.HighlightStop)
+
+func escapeHighlights(results []utils.ProductSearchResult) {
+	for i := range results {
+		results[i].EscapeHighlights()
+	}
 }
 
 func (p *PostgresRepo) GetProductByID(id int) (utils.Product, error) {
 	var product utils.Product
 
-	err := p.DB.Get(&product, "SELECT * FROM product WHERE id = $1", id)
+	err := p.DB.Get(&product, "SELECT "+productColumns+" FROM product WHERE id = $1", id)
 	if err != nil {
 		return utils.Product{}, err
 	}
@@ -85,16 +191,40 @@ func (p *PostgresRepo) GetProductByID(id int) (utils.Product, error) {
 func (p *PostgresRepo) GetProductQuantity(id int) (utils.ProductStock, error) {
 	var product utils.ProductStock
 
-	err := p.DB.Get(&product, "SELECT id, stock_quantity FROM product WHERE id = $1", id)
+	err := p.DB.Get(&product, "SELECT id, stock_quantity, low_stock_threshold FROM product WHERE id = $1", id)
 	if err != nil {
 		return utils.ProductStock{}, err
 	}
 
+	product.Reserved, err = p.GetReservedQuantity(id, nil)
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+	product.Available = product.StockQuantity - product.Reserved
+
 	return product, nil
 }
 
-func (p *PostgresRepo) PostProduct(product *utils.Product) error {
-	err := p.DB.Get(product, "INSERT INTO product (name, description, price, stock_quantity) VALUES ($1, $2, $3, $4) RETURNING *", product.Name, product.Description, product.Price, product.StockQuantity)
+func (p *PostgresRepo) PostProduct(product *utils.Product, movement utils.StockMovement) error {
+	tx, err := p.DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
+	err = tx.Get(product, "INSERT INTO product (sku, name, description, price, stock_quantity, availability, expected_at, tax_class, weight) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING "+productColumns, product.SKU, product.Name, product.Description, product.Price, product.StockQuantity, product.Availability, product.ExpectedAt, product.TaxClass, product.Weight)
+	if err != nil {
+		return err
+	}
+
+	err = stockNewProduct(tx, *product, movement)
 	if err != nil {
 		return err
 	}
@@ -102,15 +232,29 @@ func (p *PostgresRepo) PostProduct(product *utils.Product) error {
 	return nil
 }
 
+// stockNewProduct puts the initial stock of a new product into the default
+// warehouse and records it in the ledger.
+func stockNewProduct(tx *sqlx.Tx, product utils.Product, movement utils.StockMovement) error {
+	var warehouseID int
+	err := tx.Get(&warehouseID, "INSERT INTO warehouse_stock (warehouse_id, product_id, quantity) VALUES ("+defaultWarehouse+", $1, $2) RETURNING warehouse_id", product.ID, product.StockQuantity)
+	if err != nil {
+		return err
+	}
+
+	return recordMovement(tx, warehouseMovement(movement, product.ID, warehouseID, product.StockQuantity))
+}
+
+// PutProduct updates the descriptive fields only. Stock is changed through
+// AdjustStock so that concurrent orders are never overwritten. A product
+// keeps its SKU when none is given.
 func (p *PostgresRepo) PutProduct(newProduct utils.Product) (utils.Product, error) {
 	var updated utils.Product
-
 	err := p.DB.Get(&updated, `
 		UPDATE product 
-		SET name = $1, description = $2, price = $3, stock_quantity = $4 
-		WHERE id = $5 
-		RETURNING *`,
-		newProduct.Name, newProduct.Description, newProduct.Price, newProduct.StockQuantity, newProduct.ID,
+		SET name = $1, description = $2, price = $3, availability = $4, expected_at = $5, tax_class = $6, weight = $7, sku = COALESCE($8, sku) 
+		WHERE id = $9 
+		RETURNING `+productColumns,
+		newProduct.Name, newProduct.Description, newProduct.Price, newProduct.Availability, newProduct.ExpectedAt, newProduct.TaxClass, newProduct.Weight, newProduct.SKU, newProduct.ID,
 	)
 
 	if err != nil {
@@ -127,19 +271,57 @@ func (p *PostgresRepo) DeleteProduct(id int) error {
 	return nil
 }
 
-func (p *PostgresRepo) DecreaseProductStock(productID int, quantity int) error {
-	_, err := p.DB.Exec("UPDATE product SET stock_quantity = stock_quantity - $1 WHERE id = $2", quantity, productID)
+func (p *PostgresRepo) DecreaseProductStock(productID int, quantity int, movement utils.StockMovement) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		return changeDefaultWarehouseStock(tx, productID, -quantity, movement)
+	})
+}
+
+func (p *PostgresRepo) IncreaseProductStock(productID int, quantity int, movement utils.StockMovement) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		return changeDefaultWarehouseStock(tx, productID, quantity, movement)
+	})
+}
+
+func changeDefaultWarehouseStock(tx *sqlx.Tx, productID int, delta int, movement utils.StockMovement) error {
+	var warehouseID int
+	err := tx.Get(&warehouseID, `
+		INSERT INTO warehouse_stock (warehouse_id, product_id, quantity) VALUES (`+defaultWarehouse+`, $1, $2)
+		ON CONFLICT (warehouse_id, product_id) DO UPDATE SET quantity = warehouse_stock.quantity + EXCLUDED.quantity
+		RETURNING warehouse_id`,
+		productID, delta,
+	)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return recordMovement(tx, warehouseMovement(movement, productID, warehouseID, delta))
 }
 
-func (p *PostgresRepo) IncreaseProductStock(productID int, quantity int) error {
-	_, err := p.DB.Exec("UPDATE product SET stock_quantity = stock_quantity + $1 WHERE id = $2", quantity, productID)
+func (p *PostgresRepo) inTx(fn func(tx *sqlx.Tx) error) error {
+	tx, err := p.DB.Beginx()
 	if err != nil {
 		return err
 	}
+
+	var changes []utils.StockLevelChange
+	if p.stockLevels != nil {
+		changes, err = p.stockLevels.track(tx, fn)
+	} else {
+		err = fn(tx)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	if p.stockLevels != nil {
+		p.stockLevels.changes = append(p.stockLevels.changes, changes...)
+	}
 	return nil
 }
 
@@ -188,44 +370,128 @@ func (p *PostgresRepo) PutUser(user *user_utils.UserInput, id int) error {
 }
 
 func (p *PostgresRepo) PostOrder(order *models.Order) (*models.Order, error) {
-	tx, err := p.DB.Beginx()
-	if err != nil {
-		return nil, err
-	}
+	err := p.inTx(func(tx *sqlx.Tx) error {
+		err := orderStatusReason(tx, "order placed", order.UserID)
+		if err != nil {
+			return err
+		}
 
-	defer func() {
+		err = tx.Get(order, `
+			INSERT INTO orders (user_id, status, subtotal, discount_amount, tax_amount, tax_region, shipping_cost, total_amount, order_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING *`,
+			order.UserID, order.Status, order.Subtotal, order.DiscountAmount, order.TaxAmount, order.TaxRegion, order.ShippingCost, order.TotalAmount, order.OrderDate,
+		)
 		if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
+			log.Println(err)
+			return err
 		}
-	}()
 
-	err = tx.Get(order, "INSERT INTO orders (user_id, status, total_amount, order_date) VALUES ($1, $2, $3, $4) RETURNING *", order.UserID, order.Status, order.TotalAmount, order.OrderDate)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
+		if order.ReservationID != nil {
+			err = confirmReservation(tx, *order.ReservationID, order.ID)
+			if err != nil {
+				return err
+			}
+		}
 
-	for _, product := range order.Products {
-		_, err = tx.Exec("INSERT INTO order_product (order_id, product_id, quantity) VALUES ($1, $2, $3)", order.ID, product.ProductID, product.Quantity)
+		err = checkAvailable(tx, order.Products)
 		if err != nil {
-			log.Println(err)
-			return nil, err
+			return err
+		}
+
+		err = redeemPromotions(tx, order)
+		if err != nil {
+			return err
+		}
+
+		err = insertTaxLines(tx, order)
+		if err != nil {
+			return err
+		}
+
+		err = insertOrderAddress(tx, order)
+		if err != nil {
+			return err
 		}
+
+		movement := utils.NewMovement(utils.ReasonOrder, strconv.Itoa(order.ID), order.UserID)
+
+		for _, product := range order.Products {
+			status := models.LineAllocated
+			if product.Backordered > 0 {
+				status = models.LineBackordered
+			}
+
+			_, err = tx.Exec("INSERT INTO order_product (order_id, product_id, variant_id, quantity, status, backordered_quantity, unit_price) VALUES ($1, $2, $3, $4, $5, $6, $7)", order.ID, product.ProductID, product.VariantID, product.Quantity, status, product.Backordered, product.UnitPrice)
+			if err != nil {
+				log.Println(err)
+				return err
+			}
+
+			if product.VariantID != nil && product.Fulfilled() > 0 {
+				err = decreaseStock(tx, "UPDATE product_variant SET stock_quantity = stock_quantity - $1 WHERE id = $2 AND stock_quantity >= $1", product.Fulfilled(), *product.VariantID)
+				if err != nil {
+					return err
+				}
+
+				err = recordMovement(tx, variantMovement(movement, product.ProductID, *product.VariantID, -product.Fulfilled()))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, allocation := range order.Allocations {
+			err = decreaseStock(tx, "UPDATE warehouse_stock SET quantity = quantity - $1 WHERE warehouse_id = $2 AND product_id = $3 AND quantity >= $1", allocation.Quantity, allocation.WarehouseID, allocation.ProductID)
+			if err != nil {
+				return err
+			}
+
+			err = recordMovement(tx, warehouseMovement(movement, allocation.ProductID, allocation.WarehouseID, -allocation.Quantity))
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec("INSERT INTO order_allocation (order_id, product_id, warehouse_id, quantity) VALUES ($1, $2, $3, $4)", order.ID, allocation.ProductID, allocation.WarehouseID, allocation.Quantity)
+			if err != nil {
+				log.Println(err)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return order, nil
 }
 
+func decreaseStock(tx *sqlx.Tx, query string, args ...any) error {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("not enough stock to fulfil the order")
+	}
+	return nil
+}
+
 func (p *PostgresRepo) GetOrder(o_id int) (*models.Order, error) {
 	var order models.Order
 	err := p.DB.Get(&order, "SELECT * FROM orders WHERE id = $1", o_id)
 	if err != nil {
 		log.Println(err)
-		return nil, errors.New("order not found")
+		return nil, models.ErrOrderNotFound
 	}
 	var order_products []models.OrderProduct
-	err = p.DB.Select(&order_products, "SELECT product_id, quantity FROM order_product WHERE order_id = $1", o_id)
+	err = p.DB.Select(&order_products, "SELECT "+orderLineColumns+" FROM order_product WHERE order_id = $1 ORDER BY id", o_id)
 	if err != nil {
 		log.Println(err)
 		return nil, errors.New("order not found")
@@ -244,8 +510,34 @@ func (p *PostgresRepo) GetUserOrders(user_id int) ([]models.Order, error) {
 	return orders, nil
 }
 
-func (p *PostgresRepo) PutOrderStatus(o_id int, status string) error {
-	res, err := p.DB.Exec("UPDATE orders SET status = $1 WHERE id = $2", status, o_id)
+// PutOrderStatus moves an order from the status it was read with to a new
+// one, and fails when the status changed meanwhile.
+func (p *PostgresRepo) PutOrderStatus(o_id int, from string, status string, actorID int) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		return putOrderStatus(tx, o_id, from, status, actorID)
+	})
+}
+
+// CancelOrder cancels an order that is still in the status it was read with
+// and returns its stock in the same transaction.
+func (p *PostgresRepo) CancelOrder(o_id int, from string, actorID int) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		err := putOrderStatus(tx, o_id, from, models.OrderCancelled, actorID)
+		if err != nil {
+			return err
+		}
+
+		return restockOrder(tx, o_id, utils.NewMovement(utils.ReasonCancellation, strconv.Itoa(o_id), actorID))
+	})
+}
+
+func putOrderStatus(tx *sqlx.Tx, o_id int, from string, status string, actorID int) error {
+	err := orderStatusReason(tx, "", actorID)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("UPDATE orders SET status = $1 WHERE id = $2 AND status = $3", status, o_id, from)
 	if err != nil {
 		log.Println(err)
 		return err
@@ -258,14 +550,14 @@ func (p *PostgresRepo) PutOrderStatus(o_id int, status string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("order not found")
+		return fmt.Errorf("%w: order %d is no longer %s", models.ErrIllegalTransition, o_id, from)
 	}
 	return nil
 }
 
 func (p *PostgresRepo) GetOrderProducts(o_id int) ([]models.OrderProduct, error) {
 	var order_products []models.OrderProduct
-	err := p.DB.Select(&order_products, "SELECT product_id, quantity FROM order_product WHERE order_id = $1", o_id)
+	err := p.DB.Select(&order_products, "SELECT "+orderLineColumns+" FROM order_product WHERE order_id = $1 ORDER BY id", o_id)
 	if err != nil {
 		log.Println(err)
 		return nil, errors.New("order products not found")
@@ -0,0 +1,137 @@
+package psql
+
+import (
+	"order_processing_system/order_service/order_utils/models"
+	"strings"
+)
+
+const exportColumns = `
+	orders.id, orders.user_id, orders.order_date, orders.status, orders.subtotal, orders.discount_amount,
+	orders.tax_amount, orders.tax_region, orders.shipping_cost, orders.total_amount, orders.refunded_amount,
+	line.id IS NOT NULL, COALESCE(line.product_id, 0), COALESCE(product.name, ''), line.variant_id,
+	COALESCE(line.quantity, 0), COALESCE(line.status, ''), COALESCE(line.backordered_quantity, 0),
+	COALESCE(line.unit_price, 0)`
+
+// sales holds the orders of a report period, $1 and $2 bound it and either
+// may be NULL. Sold orders are the paid ones that were not refunded in full.
+const sales = `
+	WITH sales AS (
+		SELECT o.id, (o.order_date AT TIME ZONE 'UTC')::date AS day, o.status = 'cancelled' AS cancelled,
+			o.status IN ('paid', 'processing', 'partially_shipped', 'shipped', 'delivered', 'partially_refunded') AS sold,
+			o.status = 'refunded' AS refunded, o.total_amount, o.refunded_amount,
+			(SELECT COALESCE(SUM(quantity), 0) FROM order_product WHERE order_id = o.id) AS units
+		FROM orders o
+		WHERE ($1::timestamptz IS NULL OR o.order_date >= $1) AND ($2::timestamptz IS NULL OR o.order_date < $2)
+	)`
+
+const salesFigures = `
+	COUNT(*) AS orders,
+	COUNT(*) FILTER (WHERE cancelled) AS cancelled_orders,
+	COALESCE(ROUND(COUNT(*) FILTER (WHERE cancelled)::numeric / NULLIF(COUNT(*), 0), 4), 0) AS cancellation_rate,
+	COUNT(*) FILTER (WHERE sold) AS sold_orders,
+	COALESCE(SUM(total_amount - refunded_amount) FILTER (WHERE sold), 0) AS revenue,
+	COALESCE(SUM(refunded_amount) FILTER (WHERE sold OR refunded), 0) AS refunded,
+	COALESCE(SUM(units) FILTER (WHERE sold), 0)::bigint AS units_sold,
+	COALESCE(ROUND(AVG(total_amount - refunded_amount) FILTER (WHERE sold), 2), 0) AS average_order_value`
+
+// ExportOrders reads the orders matching the filters of the query with their
+// lines, oldest first, and hands them to fn one at a time without holding the
+// whole export in memory.
+func (p *PostgresRepo) ExportOrders(query models.OrderQuery, fn func(models.OrderExport) error) error {
+	conditions, args := orderFilters(query)
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := p.DB.Query(`
+		SELECT `+exportColumns+`
+		FROM orders
+		LEFT JOIN order_product line ON line.order_id = orders.id
+		LEFT JOIN product ON product.id = line.product_id`+where+`
+		ORDER BY orders.order_date, orders.id, line.id`,
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var current *models.OrderExport
+	for rows.Next() {
+		order := models.OrderExport{Lines: []models.ExportLine{}}
+		var line models.ExportLine
+		var hasLine bool
+		err = rows.Scan(
+			&order.ID, &order.UserID, &order.OrderDate, &order.Status, &order.Subtotal, &order.DiscountAmount,
+			&order.TaxAmount, &order.TaxRegion, &order.ShippingCost, &order.TotalAmount, &order.RefundedAmount,
+			&hasLine, &line.ProductID, &line.ProductName, &line.VariantID, &line.Quantity, &line.Status,
+			&line.Backordered, &line.UnitPrice,
+		)
+		if err != nil {
+			return err
+		}
+
+		if current != nil && current.ID != order.ID {
+			err = fn(*current)
+			if err != nil {
+				return err
+			}
+			current = nil
+		}
+		if current == nil {
+			current = &order
+		}
+		if hasLine {
+			current.Lines = append(current.Lines, line)
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		return fn(*current)
+	}
+	return nil
+}
+
+// GetSalesReport aggregates the orders placed in the period of the query,
+// in total, per day and per product.
+func (p *PostgresRepo) GetSalesReport(query models.SalesQuery) (*models.SalesReport, error) {
+	report := &models.SalesReport{
+		From:     query.From,
+		To:       query.To,
+		Days:     []models.DailySales{},
+		Products: []models.ProductSales{},
+	}
+
+	err := p.DB.Get(&report.Summary, sales+" SELECT "+salesFigures+" FROM sales", query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.DB.Select(&report.Days, sales+" SELECT to_char(day, 'YYYY-MM-DD') AS day, "+salesFigures+" FROM sales GROUP BY sales.day ORDER BY sales.day", query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.DB.Select(&report.Products, sales+`
+		SELECT line.product_id, product.name, COUNT(DISTINCT sales.id) AS orders,
+			SUM(line.quantity)::bigint AS units_sold, ROUND(SUM(line.quantity * line.unit_price), 2) AS revenue
+		FROM sales
+		JOIN order_product line ON line.order_id = sales.id
+		JOIN product ON product.id = line.product_id
+		WHERE sales.sold
+		GROUP BY line.product_id, product.name
+		ORDER BY revenue DESC, line.product_id`,
+		query.From, query.To,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
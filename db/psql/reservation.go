@@ -0,0 +1,199 @@
+package psql
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/order_service/order_utils/models"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// reservedQuantity sums what active, unexpired reservations hold for a line.
+// Variant lines match on the variant, product lines on items without one.
+const reservedQuantity = `
+	SELECT COALESCE(SUM(i.quantity), 0)
+	FROM stock_reservation_item i
+	JOIN stock_reservation r ON r.id = i.reservation_id
+	WHERE r.status = 'active' AND r.expires_at > NOW()
+		AND i.product_id = $1 AND i.variant_id IS NOT DISTINCT FROM $2`
+
+func (p *PostgresRepo) GetReservedQuantity(productID int, variantID *int) (int, error) {
+	var reserved int
+	err := p.DB.Get(&reserved, reservedQuantity, productID, variantID)
+	if err != nil {
+		return 0, err
+	}
+	return reserved, nil
+}
+
+func (p *PostgresRepo) PostReservation(reservation *models.Reservation) error {
+	tx, err := p.DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
+	err = checkAvailable(tx, reservation.Products)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Get(reservation, "INSERT INTO stock_reservation (user_id, expires_at) VALUES ($1, $2) RETURNING *", reservation.UserID, reservation.ExpiresAt)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	for _, line := range reservation.Products {
+		_, err = tx.Exec("INSERT INTO stock_reservation_item (reservation_id, product_id, variant_id, quantity) VALUES ($1, $2, $3, $4)", reservation.ID, line.ProductID, line.VariantID, line.Quantity)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *PostgresRepo) GetReservation(id int) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := p.DB.Get(&reservation, "SELECT * FROM stock_reservation WHERE id = $1", id)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New("reservation not found")
+	}
+
+	err = p.DB.Select(&reservation.Products, "SELECT product_id, variant_id, quantity FROM stock_reservation_item WHERE reservation_id = $1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (p *PostgresRepo) ReleaseReservation(id int) error {
+	res, err := p.DB.Exec("UPDATE stock_reservation SET status = 'released' WHERE id = $1 AND status = 'active'", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("reservation is not active")
+	}
+	return nil
+}
+
+// ExpireReservations marks every active reservation past its expiry as
+// expired and returns them, so that the caller can announce the release.
+func (p *PostgresRepo) ExpireReservations() ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := p.DB.Select(&reservations, "UPDATE stock_reservation SET status = 'expired' WHERE status = 'active' AND expires_at <= NOW() RETURNING *")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range reservations {
+		err = p.DB.Select(&reservations[i].Products, "SELECT product_id, variant_id, quantity FROM stock_reservation_item WHERE reservation_id = $1 ORDER BY id", reservations[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return reservations, nil
+}
+
+// confirmReservation turns an active reservation into the order being placed.
+// Once confirmed it no longer counts as held, so the order's own stock checks
+// see the quantity it reserved.
+func confirmReservation(tx *sqlx.Tx, reservationID int, orderID int) error {
+	res, err := tx.Exec("UPDATE stock_reservation SET status = 'confirmed', order_id = $1 WHERE id = $2 AND status = 'active' AND expires_at > NOW()", orderID, reservationID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("reservation has expired or is no longer active")
+	}
+	return nil
+}
+
+// checkAvailable locks the stock rows of the lines in a fixed order and checks
+// that the stock not held by active reservations covers every line.
+func checkAvailable(tx *sqlx.Tx, lines []models.OrderProduct) error {
+	for _, line := range mergeLines(lines) {
+		if line.Quantity == 0 {
+			continue
+		}
+
+		var onHand int
+		var err error
+		if line.VariantID != nil {
+			err = tx.Get(&onHand, "SELECT stock_quantity FROM product_variant WHERE id = $1 AND product_id = $2 FOR UPDATE", *line.VariantID, line.ProductID)
+		} else {
+			err = tx.Get(&onHand, "SELECT stock_quantity FROM product WHERE id = $1 FOR UPDATE", line.ProductID)
+		}
+		if err != nil {
+			log.Println(err)
+			return fmt.Errorf("product %d not found", line.ProductID)
+		}
+
+		var reserved int
+		err = tx.Get(&reserved, reservedQuantity, line.ProductID, line.VariantID)
+		if err != nil {
+			return err
+		}
+
+		if available := onHand - reserved; line.Quantity > available {
+			return fmt.Errorf("not enough stock for product %d, available stock: %d", line.ProductID, available)
+		}
+	}
+	return nil
+}
+
+// mergeLines sums the fulfilled quantities of repeated lines and sorts them by
+// product and variant, which is the order stock rows are locked in.
+func mergeLines(lines []models.OrderProduct) []models.OrderProduct {
+	type key struct{ product, variant int }
+	index := map[key]int{}
+	merged := []models.OrderProduct{}
+
+	for _, line := range lines {
+		k := key{line.ProductID, variantKey(line)}
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(merged)
+			merged = append(merged, models.OrderProduct{ProductID: line.ProductID, VariantID: line.VariantID})
+			i = len(merged) - 1
+		}
+		merged[i].Quantity += line.Fulfilled()
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].ProductID != merged[j].ProductID {
+			return merged[i].ProductID < merged[j].ProductID
+		}
+		return variantKey(merged[i]) < variantKey(merged[j])
+	})
+	return merged
+}
+
+func variantKey(line models.OrderProduct) int {
+	if line.VariantID == nil {
+		return 0
+	}
+	return *line.VariantID
+}
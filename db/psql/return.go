@@ -0,0 +1,165 @@
+package psql
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/product_service/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const returnColumns = "id, order_id, user_id, status, note, restocked, refund_amount, created_at, updated_at"
+
+// PostReturn records a return request. Only goods that were shipped, or taken
+// from stock for orders delivered without shipments, can be returned, less
+// what other returns that were not rejected already hold. The order status
+// does not tell, a refund can come before or after the shipments.
+func (p *PostgresRepo) PostReturn(ret *models.Return) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		var delivered bool
+		err := tx.Get(&delivered, `
+			SELECT o.status = $2 OR EXISTS (SELECT 1 FROM order_status_history h WHERE h.order_id = o.id AND h.status = $2)
+			FROM orders o WHERE o.id = $1 FOR UPDATE`,
+			ret.OrderID, models.OrderDelivered,
+		)
+		if err != nil {
+			return errors.New("order not found")
+		}
+
+		returnable, err := shippedItems(tx, ret.OrderID)
+		if err != nil {
+			return err
+		}
+		if len(returnable) == 0 {
+			if !delivered {
+				return errors.New("nothing of the order was shipped yet")
+			}
+
+			var lines []models.OrderProduct
+			err = tx.Select(&lines, "SELECT "+orderLineColumns+" FROM order_product WHERE order_id = $1", ret.OrderID)
+			if err != nil {
+				return err
+			}
+			returnable, _ = models.ShipmentBalance(lines, nil)
+		}
+
+		var returned []models.ShipmentItem
+		err = tx.Select(&returned, `
+			SELECT i.product_id, i.variant_id, SUM(i.quantity) AS quantity
+			FROM order_return_item i
+			JOIN order_return r ON r.id = i.return_id
+			WHERE r.order_id = $1 AND r.status <> 'rejected'
+			GROUP BY i.product_id, i.variant_id`,
+			ret.OrderID,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range ret.Quantities() {
+			left := models.ShippableQuantity(returnable, item.ProductID, item.VariantID) - models.ShippableQuantity(returned, item.ProductID, item.VariantID)
+			if item.Quantity > left {
+				return fmt.Errorf("product %d: only %d can be returned", item.ProductID, max(left, 0))
+			}
+		}
+
+		err = tx.Get(ret, "INSERT INTO order_return (order_id, user_id) VALUES ($1, $2) RETURNING "+returnColumns, ret.OrderID, ret.UserID)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+
+		for _, item := range ret.Items {
+			_, err = tx.Exec("INSERT INTO order_return_item (return_id, product_id, variant_id, quantity, reason, comment) VALUES ($1, $2, $3, $4, $5, $6)", ret.ID, item.ProductID, item.VariantID, item.Quantity, item.Reason, item.Comment)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *PostgresRepo) GetReturn(orderID int, returnID int) (models.Return, error) {
+	var ret models.Return
+	err := p.DB.Get(&ret, "SELECT "+returnColumns+" FROM order_return WHERE id = $1 AND order_id = $2", returnID, orderID)
+	if err != nil {
+		log.Println(err)
+		return models.Return{}, errors.New("return not found")
+	}
+
+	err = p.getReturnItems(&ret)
+	if err != nil {
+		return models.Return{}, err
+	}
+	return ret, nil
+}
+
+func (p *PostgresRepo) GetOrderReturns(orderID int) ([]models.Return, error) {
+	returns := []models.Return{}
+	err := p.DB.Select(&returns, "SELECT "+returnColumns+" FROM order_return WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range returns {
+		err = p.getReturnItems(&returns[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return returns, nil
+}
+
+// PutReturn saves a return that is still in the from status, so that two
+// admins cannot act on the same return at once.
+func (p *PostgresRepo) PutReturn(ret *models.Return, from string) error {
+	return putReturn(p.DB, ret, from)
+}
+
+// ReceiveReturn saves an approved return as received and, when it is
+// restocked, puts its goods back in stock in the same transaction.
+func (p *PostgresRepo) ReceiveReturn(ret *models.Return, movement utils.StockMovement) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		err := putReturn(tx, ret, models.ReturnApproved)
+		if err != nil || !ret.Restocked {
+			return err
+		}
+
+		for _, line := range ret.Lines() {
+			if line.VariantID != nil {
+				err = addVariantStock(tx, *line.VariantID, line.Quantity, movement)
+			} else {
+				err = changeDefaultWarehouseStock(tx, line.ProductID, line.Quantity, movement)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func putReturn(q sqlx.Queryer, ret *models.Return, from string) error {
+	items := ret.Items
+	err := sqlx.Get(q, ret, `
+		UPDATE order_return
+		SET status = $1, note = $2, restocked = $3, refund_amount = $4, updated_at = NOW()
+		WHERE id = $5 AND status = $6
+		RETURNING `+returnColumns,
+		ret.Status, ret.Note, ret.Restocked, ret.RefundAmount, ret.ID, from,
+	)
+	if err != nil {
+		log.Println(err)
+		return errors.New("return was updated concurrently")
+	}
+
+	ret.Items = items
+	return nil
+}
+
+func (p *PostgresRepo) getReturnItems(ret *models.Return) error {
+	ret.Items = []models.ReturnItem{}
+	return p.DB.Select(&ret.Items, "SELECT product_id, variant_id, quantity, reason, comment FROM order_return_item WHERE return_id = $1 ORDER BY id", ret.ID)
+}
@@ -0,0 +1,196 @@
+package psql
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/product_service/utils"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const revisionColumns = "id, order_id, version, actor_id, changes, previous_total, total_amount, created_at"
+
+type allocationRow struct {
+	ID          int `db:"id"`
+	WarehouseID int `db:"warehouse_id"`
+	Quantity    int `db:"quantity"`
+}
+
+// EditOrder stores the edited lines and totals of an order along with the
+// stock they take or give back, and records the edit as the next version. The
+// order must still be editable, at the version it was edited from and without
+// a payment in progress.
+func (p *PostgresRepo) EditOrder(order *models.Order, changes []models.LineChange, previousTotal float64, actorID int) (*models.OrderRevision, error) {
+	var revision models.OrderRevision
+
+	err := p.inTx(func(tx *sqlx.Tx) error {
+		var current struct {
+			Status  string `db:"status"`
+			Version int    `db:"version"`
+		}
+		err := tx.Get(&current, "SELECT status, version FROM orders WHERE id = $1 FOR UPDATE", order.ID)
+		if err != nil {
+			return errors.New("order not found")
+		}
+		if !models.Editable(current.Status) {
+			return fmt.Errorf("order %d can no longer be edited", order.ID)
+		}
+		if current.Version != order.Version {
+			return models.ErrVersionConflict
+		}
+
+		var payments int
+		err = tx.Get(&payments, "SELECT COUNT(*) FROM payment WHERE order_id = $1 AND status IN ('pending', 'authorized', 'captured')", order.ID)
+		if err != nil {
+			return err
+		}
+		if payments > 0 {
+			return errors.New("the order has a payment in progress and can no longer be edited")
+		}
+
+		var taken []models.OrderProduct
+		for _, change := range changes {
+			if change.Stock > 0 {
+				taken = append(taken, models.OrderProduct{ProductID: change.ProductID, VariantID: change.VariantID, Quantity: change.Stock})
+			}
+		}
+		err = checkAvailable(tx, taken)
+		if err != nil {
+			return err
+		}
+
+		movement := utils.NewMovement(utils.ReasonOrder, strconv.Itoa(order.ID), actorID)
+		for _, change := range changes {
+			err = changeOrderStock(tx, order.ID, change, movement)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec("DELETE FROM order_product WHERE order_id = $1", order.ID)
+		if err != nil {
+			return err
+		}
+		for _, line := range order.Products {
+			_, err = tx.Exec("INSERT INTO order_product (order_id, product_id, variant_id, quantity, status, backordered_quantity, unit_price) VALUES ($1, $2, $3, $4, $5, $6, $7)", order.ID, line.ProductID, line.VariantID, line.Quantity, line.Status, line.Backordered, line.UnitPrice)
+			if err != nil {
+				log.Println(err)
+				return err
+			}
+		}
+
+		_, err = tx.Exec("DELETE FROM order_discount WHERE order_id = $1", order.ID)
+		if err != nil {
+			return err
+		}
+		err = redeemPromotions(tx, order)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("DELETE FROM order_tax_line WHERE order_id = $1", order.ID)
+		if err != nil {
+			return err
+		}
+		err = insertTaxLines(tx, order)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Get(&order.Version, `
+			UPDATE orders SET subtotal = $1, discount_amount = $2, tax_amount = $3, shipping_cost = $4, total_amount = $5, version = version + 1
+			WHERE id = $6
+			RETURNING version`,
+			order.Subtotal, order.DiscountAmount, order.TaxAmount, order.ShippingCost, order.TotalAmount, order.ID,
+		)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+
+		return tx.Get(&revision, `
+			INSERT INTO order_revision (order_id, version, actor_id, changes, previous_total, total_amount)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING `+revisionColumns,
+			order.ID, order.Version, actorID, models.LineChanges(changes), previousTotal, order.TotalAmount,
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+func (p *PostgresRepo) GetOrderRevisions(orderID int) ([]models.OrderRevision, error) {
+	revisions := []models.OrderRevision{}
+	err := p.DB.Select(&revisions, "SELECT "+revisionColumns+" FROM order_revision WHERE order_id = $1 ORDER BY version", orderID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// changeOrderStock takes the stock of a line change, or gives it back when the
+// change is negative. Product stock is taken from the warehouses like a new
+// order does and goes back to the ones it was allocated from.
+func changeOrderStock(tx *sqlx.Tx, orderID int, change models.LineChange, movement utils.StockMovement) error {
+	switch {
+	case change.Stock == 0:
+		return nil
+	case change.VariantID != nil && change.Stock < 0:
+		return addVariantStock(tx, *change.VariantID, -change.Stock, movement)
+	case change.VariantID != nil:
+		err := decreaseStock(tx, "UPDATE product_variant SET stock_quantity = stock_quantity - $1 WHERE id = $2 AND stock_quantity >= $1", change.Stock, *change.VariantID)
+		if err != nil {
+			return err
+		}
+		return recordMovement(tx, variantMovement(movement, change.ProductID, *change.VariantID, -change.Stock))
+	case change.Stock > 0:
+		return allocateFromWarehouses(tx, orderID, change.ProductID, change.Stock, movement)
+	}
+	return releaseAllocations(tx, orderID, change.ProductID, -change.Stock, movement)
+}
+
+// releaseAllocations gives back part of the stock allocated to an order, the
+// latest allocation first. Orders placed before warehouse allocation have
+// none, their stock goes back to the default warehouse.
+func releaseAllocations(tx *sqlx.Tx, orderID int, productID int, quantity int, movement utils.StockMovement) error {
+	var allocations []allocationRow
+	err := tx.Select(&allocations, "SELECT id, warehouse_id, quantity FROM order_allocation WHERE order_id = $1 AND product_id = $2 AND NOT released ORDER BY id DESC FOR UPDATE", orderID, productID)
+	if err != nil {
+		return err
+	}
+
+	remaining := quantity
+	for _, allocation := range allocations {
+		if remaining == 0 {
+			break
+		}
+		give := min(allocation.Quantity, remaining)
+		remaining -= give
+
+		if give == allocation.Quantity {
+			_, err = tx.Exec("UPDATE order_allocation SET released = TRUE WHERE id = $1", allocation.ID)
+		} else {
+			_, err = tx.Exec("UPDATE order_allocation SET quantity = quantity - $1 WHERE id = $2", give, allocation.ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = returnToWarehouse(tx, allocation.WarehouseID, productID, give, movement)
+		if err != nil {
+			return err
+		}
+	}
+
+	if remaining > 0 {
+		return changeDefaultWarehouseStock(tx, productID, remaining, movement)
+	}
+	return nil
+}
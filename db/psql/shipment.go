@@ -0,0 +1,177 @@
+package psql
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const shipmentColumns = "id, order_id, carrier, tracking_number, status, shipped_at, delivered_at"
+
+// PostShipment ships items of an order and moves the order to shipped, or to
+// partially_shipped while some of it has not been shipped. The order row is
+// locked so that concurrent shipments cannot ship the same items twice. Only
+// orders that may move to shipped, paid or processing ones and partially
+// shipped ones, can be shipped.
+func (p *PostgresRepo) PostShipment(shipment *models.Shipment) (string, error) {
+	var orderStatus string
+
+	err := p.inTx(func(tx *sqlx.Tx) error {
+		var status string
+		err := tx.Get(&status, "SELECT status FROM orders WHERE id = $1 FOR UPDATE", shipment.OrderID)
+		if err != nil {
+			return errors.New("order not found")
+		}
+		err = models.CheckTransition(status, models.OrderShipped)
+		if err != nil {
+			return fmt.Errorf("%s orders cannot be shipped: %w", status, err)
+		}
+
+		var lines []models.OrderProduct
+		err = tx.Select(&lines, "SELECT "+orderLineColumns+" FROM order_product WHERE order_id = $1", shipment.OrderID)
+		if err != nil {
+			return err
+		}
+
+		shipped, err := shippedItems(tx, shipment.OrderID)
+		if err != nil {
+			return err
+		}
+
+		shippable, _ := models.ShipmentBalance(lines, shipped)
+		if len(shipment.Items) == 0 {
+			shipment.Items = shippable
+		}
+		shipment.Items = models.MergeShipmentItems(shipment.Items)
+		if len(shipment.Items) == 0 {
+			return errors.New("nothing left to ship")
+		}
+
+		for _, item := range shipment.Items {
+			left := models.ShippableQuantity(shippable, item.ProductID, item.VariantID)
+			if item.Quantity > left {
+				return fmt.Errorf("product %d: only %d left to ship", item.ProductID, left)
+			}
+		}
+
+		err = tx.Get(shipment, "INSERT INTO shipment (order_id, carrier, tracking_number) VALUES ($1, $2, $3) RETURNING "+shipmentColumns, shipment.OrderID, shipment.Carrier, shipment.TrackingNumber)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+
+		for _, item := range shipment.Items {
+			_, err = tx.Exec("INSERT INTO shipment_item (shipment_id, product_id, variant_id, quantity) VALUES ($1, $2, $3, $4)", shipment.ID, item.ProductID, item.VariantID, item.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, outstanding := models.ShipmentBalance(lines, append(shipped, shipment.Items...))
+		orderStatus = models.OrderPartiallyShipped
+		if outstanding == 0 {
+			orderStatus = models.OrderShipped
+		}
+		err = orderStatusReason(tx, fmt.Sprintf("shipment %d", shipment.ID), 0)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("UPDATE orders SET status = $1 WHERE id = $2", orderStatus, shipment.OrderID)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return orderStatus, nil
+}
+
+// PutShipmentStatus moves a shipment forward. Once every shipment of a fully
+// shipped order is delivered, the order is delivered too.
+func (p *PostgresRepo) PutShipmentStatus(orderID int, shipmentID int, status string) (string, error) {
+	var orderStatus string
+
+	err := p.inTx(func(tx *sqlx.Tx) error {
+		err := tx.Get(&orderStatus, "SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID)
+		if err != nil {
+			return errors.New("order not found")
+		}
+
+		var current string
+		err = tx.Get(&current, "SELECT status FROM shipment WHERE id = $1 AND order_id = $2 FOR UPDATE", shipmentID, orderID)
+		if err != nil {
+			return errors.New("shipment not found")
+		}
+		if !models.ValidShipmentStep(current, status) {
+			return fmt.Errorf("shipment cannot move from %s to %s", current, status)
+		}
+
+		_, err = tx.Exec("UPDATE shipment SET status = $1, delivered_at = CASE WHEN $1 = 'delivered' THEN NOW() END WHERE id = $2", status, shipmentID)
+		if err != nil {
+			return err
+		}
+
+		if orderStatus != models.OrderShipped {
+			return nil
+		}
+
+		var undelivered int
+		err = tx.Get(&undelivered, "SELECT COUNT(*) FROM shipment WHERE order_id = $1 AND status <> 'delivered'", orderID)
+		if err != nil {
+			return err
+		}
+		if undelivered == 0 {
+			err = orderStatusReason(tx, fmt.Sprintf("shipment %d delivered", shipmentID), 0)
+			if err != nil {
+				return err
+			}
+
+			orderStatus = models.OrderDelivered
+			_, err = tx.Exec("UPDATE orders SET status = $1 WHERE id = $2", orderStatus, orderID)
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return orderStatus, nil
+}
+
+func (p *PostgresRepo) GetOrderShipments(orderID int) ([]models.Shipment, error) {
+	shipments := []models.Shipment{}
+
+	err := p.DB.Select(&shipments, "SELECT "+shipmentColumns+" FROM shipment WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range shipments {
+		shipments[i].Items = []models.ShipmentItem{}
+		err = p.DB.Select(&shipments[i].Items, "SELECT product_id, variant_id, quantity FROM shipment_item WHERE shipment_id = $1 ORDER BY id", shipments[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return shipments, nil
+}
+
+func shippedItems(tx *sqlx.Tx, orderID int) ([]models.ShipmentItem, error) {
+	var items []models.ShipmentItem
+	err := tx.Select(&items, `
+		SELECT i.product_id, i.variant_id, SUM(i.quantity) AS quantity
+		FROM shipment_item i
+		JOIN shipment s ON s.id = i.shipment_id
+		WHERE s.order_id = $1
+		GROUP BY i.product_id, i.variant_id`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
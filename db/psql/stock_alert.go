@@ -0,0 +1,158 @@
+package psql
+
+import (
+	"errors"
+	"order_processing_system/product_service/utils"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// stockLevelTracker holds the products whose stock levels a repo returned by
+// WithStockLevels reads around each of its transactions.
+type stockLevelTracker struct {
+	productIDs []int
+	changes    []utils.StockLevelChange
+}
+
+// WithStockLevels runs a stock change through a repo whose transactions lock
+// the products first and read their stock levels before and after the
+// change, in the transaction itself. Stock changes of the same product wait
+// for each other, so the levels only differ by the change. The levels of the
+// transactions that committed are returned even when the change failed.
+func (p *PostgresRepo) WithStockLevels(productIDs []int, change func(repo *PostgresRepo) error) ([]utils.StockLevelChange, error) {
+	tracker := &stockLevelTracker{}
+	for _, id := range productIDs {
+		if !slices.Contains(tracker.productIDs, id) {
+			tracker.productIDs = append(tracker.productIDs, id)
+		}
+	}
+	slices.Sort(tracker.productIDs)
+
+	tracked := *p
+	tracked.stockLevels = tracker
+	err := change(&tracked)
+	return tracker.changes, err
+}
+
+// track runs fn in the transaction between two reads of the stock levels and
+// returns the changes to keep once the transaction commits.
+func (t *stockLevelTracker) track(tx *sqlx.Tx, fn func(tx *sqlx.Tx) error) ([]utils.StockLevelChange, error) {
+	if len(t.productIDs) == 0 {
+		return nil, fn(tx)
+	}
+
+	query, args, err := sqlx.In("SELECT id FROM product WHERE id IN (?) ORDER BY id FOR NO KEY UPDATE", t.productIDs)
+	if err != nil {
+		return nil, err
+	}
+	var locked []int
+	err = tx.Select(&locked, tx.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := stockLevels(tx, t.productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := stockLevels(tx, t.productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]utils.StockLevelChange, 0, len(after))
+	for _, level := range after {
+		changes = append(changes, utils.StockLevelChange{Before: before[level.ProductID], After: level})
+	}
+	return changes, nil
+}
+
+func stockLevels(q sqlx.Queryer, productIDs []int) (map[int]utils.StockLevel, error) {
+	query, args, err := sqlx.In(`
+		SELECT
+			p.id AS product_id,
+			p.low_stock_threshold,
+			COALESCE((SELECT SUM(quantity) FROM warehouse_stock WHERE product_id = p.id), 0) +
+			COALESCE((SELECT SUM(stock_quantity) FROM product_variant WHERE product_id = p.id), 0) AS on_hand
+		FROM product p
+		WHERE p.id IN (?)
+		ORDER BY p.id`,
+		productIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []utils.StockLevel
+	err = sqlx.Select(q, &rows, sqlx.Rebind(sqlx.DOLLAR, query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := make(map[int]utils.StockLevel, len(rows))
+	for _, level := range rows {
+		levels[level.ProductID] = level
+	}
+	return levels, nil
+}
+
+func (p *PostgresRepo) SetLowStockThreshold(productID int, threshold int) error {
+	res, err := p.DB.Exec("UPDATE product SET low_stock_threshold = $1 WHERE id = $2", threshold, productID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("product not found")
+	}
+	return nil
+}
+
+func (p *PostgresRepo) PostStockSubscription(productID int, userID int) error {
+	_, err := p.DB.Exec(`
+		INSERT INTO stock_subscription (product_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (product_id, user_id) WHERE notified_at IS NULL DO NOTHING`,
+		productID, userID,
+	)
+	return err
+}
+
+func (p *PostgresRepo) DeleteStockSubscription(productID int, userID int) error {
+	_, err := p.DB.Exec("DELETE FROM stock_subscription WHERE product_id = $1 AND user_id = $2 AND notified_at IS NULL", productID, userID)
+	return err
+}
+
+// ClaimStockSubscriptions marks the waiting subscriptions of a product as
+// notified and returns them, so each subscriber is notified only once even if
+// several restock events race.
+func (p *PostgresRepo) ClaimStockSubscriptions(productID int) ([]utils.BackInStockNotification, error) {
+	notifications := []utils.BackInStockNotification{}
+	err := p.DB.Select(&notifications, `
+		WITH claimed AS (
+			UPDATE stock_subscription SET notified_at = NOW()
+			WHERE product_id = $1 AND notified_at IS NULL
+			RETURNING id, product_id, user_id
+		)
+		SELECT c.id, c.product_id, p.name AS product_name, c.user_id, u.email
+		FROM claimed c
+		JOIN product p ON p.id = c.product_id
+		JOIN users u ON u.id = c.user_id
+		ORDER BY c.id`,
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
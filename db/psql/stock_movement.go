@@ -0,0 +1,135 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"order_processing_system/product_service/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AdjustStock applies a signed delta to one stock location in a single
+// guarded statement, so it cannot race with orders or push stock below zero.
+func (p *PostgresRepo) AdjustStock(productID int, adjustment utils.StockAdjustment, movement utils.StockMovement) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		if adjustment.VariantID != nil {
+			var variantID int
+			err := tx.Get(&variantID, `
+				UPDATE product_variant SET stock_quantity = stock_quantity + $1
+				WHERE id = $2 AND product_id = $3 AND stock_quantity + $1 >= 0
+				RETURNING id`,
+				adjustment.Delta, *adjustment.VariantID, productID,
+			)
+			if errors.Is(err, sql.ErrNoRows) {
+				return utils.ErrNegativeStock
+			}
+			if err != nil {
+				return err
+			}
+			return recordMovement(tx, variantMovement(movement, productID, variantID, adjustment.Delta))
+		}
+
+		warehouse := defaultWarehouse
+		args := []any{adjustment.Delta, productID}
+		if adjustment.WarehouseID != nil {
+			warehouse = "$3"
+			args = append(args, *adjustment.WarehouseID)
+		}
+
+		var warehouseID int
+		var err error
+		if adjustment.Delta > 0 {
+			err = tx.Get(&warehouseID, `
+				INSERT INTO warehouse_stock (warehouse_id, product_id, quantity) VALUES (`+warehouse+`, $2, $1)
+				ON CONFLICT (warehouse_id, product_id) DO UPDATE SET quantity = warehouse_stock.quantity + EXCLUDED.quantity
+				RETURNING warehouse_id`,
+				args...,
+			)
+		} else {
+			err = tx.Get(&warehouseID, `
+				UPDATE warehouse_stock SET quantity = quantity + $1
+				WHERE warehouse_id = `+warehouse+` AND product_id = $2 AND quantity + $1 >= 0
+				RETURNING warehouse_id`,
+				args...,
+			)
+			if errors.Is(err, sql.ErrNoRows) {
+				err = utils.ErrNegativeStock
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		return recordMovement(tx, warehouseMovement(movement, productID, warehouseID, adjustment.Delta))
+	})
+}
+
+func recordMovement(tx *sqlx.Tx, movement utils.StockMovement) error {
+	if movement.Delta == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO stock_movement (product_id, variant_id, warehouse_id, delta, reason, reference_id, actor_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		movement.ProductID, movement.VariantID, movement.WarehouseID, movement.Delta, movement.Reason, movement.ReferenceID, movement.ActorID,
+	)
+	return err
+}
+
+func warehouseMovement(movement utils.StockMovement, productID int, warehouseID int, delta int) utils.StockMovement {
+	movement.ProductID = productID
+	movement.WarehouseID = &warehouseID
+	movement.VariantID = nil
+	movement.Delta = delta
+	return movement
+}
+
+func variantMovement(movement utils.StockMovement, productID int, variantID int, delta int) utils.StockMovement {
+	movement.ProductID = productID
+	movement.VariantID = &variantID
+	movement.WarehouseID = nil
+	movement.Delta = delta
+	return movement
+}
+
+func (p *PostgresRepo) GetStockMovements(productID int, limit int) ([]utils.StockMovement, error) {
+	movements := []utils.StockMovement{}
+
+	err := p.DB.Select(&movements, `
+		SELECT id, product_id, variant_id, warehouse_id, delta, reason, reference_id, actor_id, created_at
+		FROM stock_movement
+		WHERE product_id = $1
+		ORDER BY id DESC
+		LIMIT $2`,
+		productID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
+
+// GetStockBalances returns the stock derived from the ledger and the stock
+// currently held in the warehouse and variant tables, for reconciliation.
+func (p *PostgresRepo) GetStockBalances(productID int) (int, int, error) {
+	var ledgerTotal int
+	err := p.DB.Get(&ledgerTotal, "SELECT COALESCE(SUM(delta), 0) FROM stock_movement WHERE product_id = $1", productID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var onHand int
+	err = p.DB.Get(&onHand, `
+		SELECT
+			COALESCE((SELECT SUM(quantity) FROM warehouse_stock WHERE product_id = $1), 0) +
+			COALESCE((SELECT SUM(stock_quantity) FROM product_variant WHERE product_id = $1), 0)`,
+		productID,
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return ledgerTotal, onHand, nil
+}
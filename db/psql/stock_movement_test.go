@@ -0,0 +1,52 @@
+package psql
+
+import (
+	"order_processing_system/product_service/utils"
+	"testing"
+)
+
+// A movement is recorded against exactly one location, whatever the movement
+// it starts from carries.
+func TestMovementLocation(t *testing.T) {
+	warehouse, variant := 9, 8
+	base := utils.NewMovement(utils.ReasonOrder, "order_1", 0)
+	base.WarehouseID = &warehouse
+	base.VariantID = &variant
+
+	tests := []struct {
+		name      string
+		movement  utils.StockMovement
+		delta     int
+		warehouse *int
+		variant   *int
+	}{
+		{"warehouse", warehouseMovement(base, 1, 2, -3), -3, intPtr(2), nil},
+		{"variant", variantMovement(base, 1, 5, 4), 4, nil, intPtr(5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.movement
+			if m.ProductID != 1 || m.Delta != tt.delta || m.Reason != utils.ReasonOrder || *m.ReferenceID != "order_1" {
+				t.Errorf("movement = %+v, want product 1 and delta %d with the reason and reference kept", m, tt.delta)
+			}
+			if !equalIntPtr(m.WarehouseID, tt.warehouse) || !equalIntPtr(m.VariantID, tt.variant) {
+				t.Errorf("location = warehouse %v variant %v, want warehouse %v variant %v", m.WarehouseID, m.VariantID, tt.warehouse, tt.variant)
+			}
+		})
+	}
+	if *base.WarehouseID != 9 || *base.VariantID != 8 {
+		t.Errorf("the base movement was changed: %+v", base)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,98 @@
+package psql
+
+import (
+	"errors"
+	"log"
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const taxRateColumns = "id, region, tax_class, name, rate, inclusive"
+
+func (p *PostgresRepo) GetTaxRateList() ([]models.TaxRate, error) {
+	rates := []models.TaxRate{}
+
+	err := p.DB.Select(&rates, "SELECT "+taxRateColumns+" FROM tax_rate ORDER BY region, tax_class")
+	if err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// GetTaxRates returns the rates of a region and of its country.
+func (p *PostgresRepo) GetTaxRates(region string, country string) ([]models.TaxRate, error) {
+	rates := []models.TaxRate{}
+
+	err := p.DB.Select(&rates, "SELECT "+taxRateColumns+" FROM tax_rate WHERE region = $1 OR region = $2", region, country)
+	if err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func (p *PostgresRepo) PostTaxRate(rate *models.TaxRate) error {
+	return p.DB.Get(rate, "INSERT INTO tax_rate (region, tax_class, name, rate, inclusive) VALUES ($1, $2, $3, $4, $5) RETURNING "+taxRateColumns, rate.Region, rate.TaxClass, rate.Name, rate.Rate, rate.Inclusive)
+}
+
+func (p *PostgresRepo) PutTaxRate(rate models.TaxRate) (models.TaxRate, error) {
+	var updated models.TaxRate
+
+	err := p.DB.Get(&updated, `
+		UPDATE tax_rate
+		SET region = $1, tax_class = $2, name = $3, rate = $4, inclusive = $5
+		WHERE id = $6
+		RETURNING `+taxRateColumns,
+		rate.Region, rate.TaxClass, rate.Name, rate.Rate, rate.Inclusive, rate.ID,
+	)
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+
+	return updated, nil
+}
+
+func (p *PostgresRepo) DeleteTaxRate(id int) error {
+	res, err := p.DB.Exec("DELETE FROM tax_rate WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("tax rate not found")
+	}
+	return nil
+}
+
+func (p *PostgresRepo) GetOrderTaxLines(orderID int) ([]models.TaxLine, error) {
+	lines := []models.TaxLine{}
+
+	err := p.DB.Select(&lines, "SELECT product_id, variant_id, region, tax_class, name, rate, inclusive, taxable_amount, tax_amount FROM order_tax_line WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+func insertTaxLines(tx *sqlx.Tx, order *models.Order) error {
+	for _, line := range order.TaxLines {
+		_, err := tx.Exec(`
+			INSERT INTO order_tax_line (order_id, product_id, variant_id, region, tax_class, name, rate, inclusive, taxable_amount, tax_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			order.ID, line.ProductID, line.VariantID, line.Region, line.TaxClass, line.Name, line.Rate, line.Inclusive, line.TaxableAmount, line.TaxAmount,
+		)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+	return nil
+}
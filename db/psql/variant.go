@@ -0,0 +1,120 @@
+package psql
+
+import (
+	"database/sql"
+	"errors"
+	"order_processing_system/product_service/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const variantColumns = "id, product_id, sku, attributes, price, stock_quantity"
+
+func (p *PostgresRepo) GetProductVariants(productID int) ([]utils.ProductVariant, error) {
+	variants := []utils.ProductVariant{}
+
+	err := p.DB.Select(&variants, "SELECT "+variantColumns+" FROM product_variant WHERE product_id = $1 ORDER BY id", productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+func (p *PostgresRepo) GetVariantByID(id int) (utils.ProductVariant, error) {
+	var variant utils.ProductVariant
+
+	err := p.DB.Get(&variant, "SELECT "+variantColumns+" FROM product_variant WHERE id = $1", id)
+	if err != nil {
+		return utils.ProductVariant{}, err
+	}
+
+	return variant, nil
+}
+
+func (p *PostgresRepo) PostVariant(variant *utils.ProductVariant, movement utils.StockMovement) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		err := tx.Get(variant, "INSERT INTO product_variant (product_id, sku, attributes, price, stock_quantity) VALUES ($1, $2, $3, $4, $5) RETURNING "+variantColumns, variant.ProductID, variant.SKU, variant.Attributes, variant.Price, variant.StockQuantity)
+		if err != nil {
+			return err
+		}
+
+		return recordMovement(tx, variantMovement(movement, variant.ProductID, variant.ID, variant.StockQuantity))
+	})
+}
+
+// PutVariant updates the details of a variant. Its stock is left alone, it
+// only changes through stock adjustments.
+func (p *PostgresRepo) PutVariant(variant utils.ProductVariant) (utils.ProductVariant, error) {
+	var updated utils.ProductVariant
+
+	err := p.DB.Get(&updated, `
+		UPDATE product_variant
+		SET sku = $1, attributes = $2, price = $3
+		WHERE id = $4 AND product_id = $5
+		RETURNING `+variantColumns,
+		variant.SKU, variant.Attributes, variant.Price, variant.ID, variant.ProductID,
+	)
+	if err != nil {
+		return utils.ProductVariant{}, err
+	}
+
+	return updated, nil
+}
+
+// DeleteVariant deletes a variant and records the removal of its stock. The
+// movement is recorded before the variant goes, the ledger keeps it with its
+// variant set to NULL. Variants that were ordered are kept.
+func (p *PostgresRepo) DeleteVariant(productID int, id int, movement utils.StockMovement) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		var stock int
+		err := tx.Get(&stock, "SELECT stock_quantity FROM product_variant WHERE id = $1 AND product_id = $2 FOR UPDATE", id, productID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("variant not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		var ordered bool
+		err = tx.Get(&ordered, "SELECT EXISTS (SELECT 1 FROM order_product WHERE variant_id = $1)", id)
+		if err != nil {
+			return err
+		}
+		if ordered {
+			return utils.ErrVariantHasOrders
+		}
+
+		err = recordMovement(tx, variantMovement(movement, productID, id, -stock))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("DELETE FROM product_variant WHERE id = $1", id)
+		return err
+	})
+}
+
+func (p *PostgresRepo) DecreaseVariantStock(variantID int, quantity int, movement utils.StockMovement) error {
+	return p.changeVariantStock(variantID, -quantity, movement)
+}
+
+func (p *PostgresRepo) IncreaseVariantStock(variantID int, quantity int, movement utils.StockMovement) error {
+	return p.changeVariantStock(variantID, quantity, movement)
+}
+
+func (p *PostgresRepo) changeVariantStock(variantID int, delta int, movement utils.StockMovement) error {
+	return p.inTx(func(tx *sqlx.Tx) error {
+		return addVariantStock(tx, variantID, delta, movement)
+	})
+}
+
+func addVariantStock(tx *sqlx.Tx, variantID int, delta int, movement utils.StockMovement) error {
+	var productID int
+	err := tx.Get(&productID, "UPDATE product_variant SET stock_quantity = stock_quantity + $1 WHERE id = $2 RETURNING product_id", delta, variantID)
+	if err != nil {
+		return err
+	}
+
+	return recordMovement(tx, variantMovement(movement, productID, variantID, delta))
+}
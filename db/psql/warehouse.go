@@ -0,0 +1,110 @@
+package psql
+
+import (
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/product_service/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const defaultWarehouse = "(SELECT id FROM warehouse ORDER BY priority, id LIMIT 1)"
+
+const warehouseColumns = "id, name, code, latitude, longitude, priority"
+
+func (p *PostgresRepo) GetWarehouses() ([]utils.Warehouse, error) {
+	warehouses := []utils.Warehouse{}
+
+	err := p.DB.Select(&warehouses, "SELECT "+warehouseColumns+" FROM warehouse ORDER BY priority, id")
+	if err != nil {
+		return nil, err
+	}
+
+	return warehouses, nil
+}
+
+func (p *PostgresRepo) GetWarehouseByID(id int) (utils.Warehouse, error) {
+	var warehouse utils.Warehouse
+
+	err := p.DB.Get(&warehouse, "SELECT "+warehouseColumns+" FROM warehouse WHERE id = $1", id)
+	if err != nil {
+		return utils.Warehouse{}, err
+	}
+
+	return warehouse, nil
+}
+
+func (p *PostgresRepo) PostWarehouse(warehouse *utils.Warehouse) error {
+	return p.DB.Get(warehouse, "INSERT INTO warehouse (name, code, latitude, longitude, priority) VALUES ($1, $2, $3, $4, $5) RETURNING "+warehouseColumns, warehouse.Name, warehouse.Code, warehouse.Latitude, warehouse.Longitude, warehouse.Priority)
+}
+
+func (p *PostgresRepo) PutWarehouse(warehouse utils.Warehouse) (utils.Warehouse, error) {
+	var updated utils.Warehouse
+
+	err := p.DB.Get(&updated, `
+		UPDATE warehouse
+		SET name = $1, code = $2, latitude = $3, longitude = $4, priority = $5
+		WHERE id = $6
+		RETURNING `+warehouseColumns,
+		warehouse.Name, warehouse.Code, warehouse.Latitude, warehouse.Longitude, warehouse.Priority, warehouse.ID,
+	)
+	if err != nil {
+		return utils.Warehouse{}, err
+	}
+
+	return updated, nil
+}
+
+func (p *PostgresRepo) GetWarehouseStock(productID int) ([]utils.WarehouseStock, error) {
+	stock := []utils.WarehouseStock{}
+
+	err := p.DB.Select(&stock, `
+		SELECT w.id AS warehouse_id, w.code AS warehouse_code, w.name AS warehouse_name,
+			w.latitude, w.longitude, w.priority, COALESCE(ws.quantity, 0) AS quantity
+		FROM warehouse w
+		LEFT JOIN warehouse_stock ws ON ws.warehouse_id = w.id AND ws.product_id = $1
+		ORDER BY w.priority, w.id`,
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return stock, nil
+}
+
+// releaseOrderAllocations returns all the stock still allocated to an order
+// to the warehouses it came from.
+func releaseOrderAllocations(tx *sqlx.Tx, orderID int, movement utils.StockMovement) ([]models.Allocation, error) {
+	var allocations []models.Allocation
+	err := tx.Select(&allocations, `
+		UPDATE order_allocation SET released = TRUE
+		WHERE order_id = $1 AND NOT released
+		RETURNING product_id, warehouse_id, quantity`,
+		orderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, allocation := range allocations {
+		err = returnToWarehouse(tx, allocation.WarehouseID, allocation.ProductID, allocation.Quantity, movement)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return allocations, nil
+}
+
+func returnToWarehouse(tx *sqlx.Tx, warehouseID int, productID int, quantity int, movement utils.StockMovement) error {
+	_, err := tx.Exec(`
+		INSERT INTO warehouse_stock (warehouse_id, product_id, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (warehouse_id, product_id) DO UPDATE SET quantity = warehouse_stock.quantity + EXCLUDED.quantity`,
+		warehouseID, productID, quantity,
+	)
+	if err != nil {
+		return err
+	}
+
+	return recordMovement(tx, warehouseMovement(movement, productID, warehouseID, quantity))
+}
@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"order_processing_system/cart"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// guest carts outlive the cache, they are kept for a week after the last change
+const cartTTL = 7 * 24 * time.Hour
+
+func cartKey(id string) string {
+	return "cart_" + id
+}
+
+// GetGuestCart returns the items of a guest cart, or nil when there is none
+// because it never existed or expired.
+func (r *RedisRepo) GetGuestCart(id string) ([]cart.Item, error) {
+	data, err := r.Client.Get(context.Background(), cartKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := []cart.Item{}
+	err = json.Unmarshal(data, &items)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *RedisRepo) SetGuestCart(id string, items []cart.Item) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return r.Client.Set(context.Background(), cartKey(id), data, cartTTL).Err()
+}
+
+func (r *RedisRepo) DeleteGuestCart(id string) error {
+	return r.Client.Del(context.Background(), cartKey(id)).Err()
+}
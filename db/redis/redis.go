@@ -65,6 +65,25 @@ func (r *RedisRepo) Delete(key string) error {
 	return r.Client.Del(context.Background(), key).Err()
 }
 
+// catalogGenerationKey counts the writes to the product catalog. Cached product
+// lists and searches are keyed on it, so one write makes all of them stale.
+const catalogGenerationKey = "catalog_generation"
+
+// CatalogKey versions the cache key of a product list or search with the
+// current catalog generation.
+func (r *RedisRepo) CatalogKey(key string) string {
+	generation, err := r.GetData(catalogGenerationKey)
+	if err != nil {
+		generation = "0"
+	}
+	return "catalog_" + generation + "_" + key
+}
+
+// InvalidateCatalog moves the catalog to its next generation.
+func (r *RedisRepo) InvalidateCatalog() error {
+	return r.Client.Incr(context.Background(), catalogGenerationKey).Err()
+}
+
 func (r *RedisRepo) SetAccessToken(email string, accessToken string) error {
 	return r.Client.Set(context.Background(), accessToken, email, 3*time.Minute).Err()
 }
@@ -0,0 +1,74 @@
+// Package export holds what the services share to stream exports: a CSV
+// writer and the response an export streams to.
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"net/http"
+)
+
+// CSVWriter writes the header before the first record.
+type CSVWriter struct {
+	w      *csv.Writer
+	header []string
+}
+
+func NewCSVWriter(w io.Writer, header []string) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w), header: header}
+}
+
+func (c *CSVWriter) Write(record []string) error {
+	err := c.writeHeader()
+	if err != nil {
+		return err
+	}
+	return c.w.Write(record)
+}
+
+// Flush writes the header of an empty export too.
+func (c *CSVWriter) Flush() error {
+	err := c.writeHeader()
+	if err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *CSVWriter) writeHeader() error {
+	if c.header == nil {
+		return nil
+	}
+	header := c.header
+	c.header = nil
+	return c.w.Write(header)
+}
+
+// Stream tells whether an export started to stream, after which its status
+// has been sent and an error can only cut it short.
+type Stream struct {
+	http.ResponseWriter
+	started bool
+}
+
+func NewStream(w http.ResponseWriter) *Stream {
+	return &Stream{ResponseWriter: w}
+}
+
+func (s *Stream) Write(b []byte) (int, error) {
+	s.started = true
+	return s.ResponseWriter.Write(b)
+}
+
+// Fail answers with the error of an export that did not start to stream. An
+// export cut short can only be logged.
+func (s *Stream) Fail(err error) {
+	if s.started {
+		log.Println(err)
+		return
+	}
+	s.Header().Del("Content-Disposition")
+	http.Error(s.ResponseWriter, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,114 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+//go:embed specs/*.json
+var specFiles embed.FS
+
+type Spec struct {
+	raw        []byte
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type PathItem struct {
+	Get    *Operation `json:"get"`
+	Post   *Operation `json:"post"`
+	Put    *Operation `json:"put"`
+	Patch  *Operation `json:"patch"`
+	Delete *Operation `json:"delete"`
+}
+
+type Operation struct {
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+func Load(service string) (*Spec, error) {
+	raw, err := specFiles.ReadFile("specs/" + service + ".json")
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{raw: raw}
+	err = json.Unmarshal(raw, spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s spec: %w", service, err)
+	}
+
+	return spec, nil
+}
+
+func MustLoad(service string) *Spec {
+	spec, err := Load(service)
+	if err != nil {
+		panic(err)
+	}
+	return spec
+}
+
+func (s *Spec) operation(path, method string) *Operation {
+	item, ok := s.Paths[path]
+	if !ok {
+		return nil
+	}
+
+	switch method {
+	case http.MethodGet:
+		return item.Get
+	case http.MethodPost:
+		return item.Post
+	case http.MethodPut:
+		return item.Put
+	case http.MethodPatch:
+		return item.Patch
+	case http.MethodDelete:
+		return item.Delete
+	}
+	return nil
+}
+
+func (s *Spec) Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(s.raw)
+}
+
+const swaggerPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<title>API documentation</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>
+		window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+	</script>
+</body>
+</html>`
+
+func SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(swaggerPage))
+}
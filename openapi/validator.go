@@ -0,0 +1,283 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/badoux/checkmail"
+	"github.com/gorilla/mux"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Nullable             bool               `json:"nullable"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*Schema `json:"properties"`
+	AdditionalProperties *Additional        `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+}
+
+// Additional is the additionalProperties of an object schema: either a
+// boolean, or the schema the properties it does not list must match.
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (a *Additional) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, &a.Allowed)
+	if err == nil {
+		return nil
+	}
+	a.Allowed = true
+	return json.Unmarshal(data, &a.Schema)
+}
+
+func (s *Spec) ValidateRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		op := s.operation(path, r.Method)
+		if op == nil || op.RequestBody == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// bodies of the other media types an operation accepts are left to
+		// its handler
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if _, other := op.RequestBody.Content[mediaType]; err == nil && other && mediaType != "application/json" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		media, ok := op.RequestBody.Content["application/json"]
+		if !ok || media.Schema == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body.Close()
+
+		if len(bytes.TrimSpace(body)) == 0 {
+			if op.RequestBody.Required {
+				http.Error(w, "Request body is required", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var data any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		err = decoder.Decode(&data)
+		if err != nil {
+			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			return
+		}
+
+		err = s.validate(media.Schema, data, "body")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Spec) resolve(schema *Schema) (*Schema, error) {
+	for schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		resolved, ok := s.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown schema reference %s", schema.Ref)
+		}
+		schema = resolved
+	}
+	return schema, nil
+}
+
+func (s *Spec) validate(schema *Schema, value any, field string) error {
+	schema, err := s.resolve(schema)
+	if err != nil {
+		return err
+	}
+
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s must not be null", field)
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		return fmt.Errorf("%s must be one of %v", field, schema.Enum)
+	}
+
+	switch schema.Type {
+	case "object":
+		return s.validateObject(schema, value, field)
+	case "array":
+		return s.validateArray(schema, value, field)
+	case "string":
+		return validateString(schema, value, field)
+	case "integer", "number":
+		return validateNumber(schema, value, field)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", field)
+		}
+	}
+	return nil
+}
+
+func (s *Spec) validateObject(schema *Schema, value any, field string) error {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s must be an object", field)
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s.%s is required", field, name)
+		}
+	}
+
+	for name, v := range obj {
+		prop, ok := schema.Properties[name]
+		if !ok {
+			additional := schema.AdditionalProperties
+			if additional == nil || (additional.Allowed && additional.Schema == nil) {
+				continue
+			}
+			if !additional.Allowed {
+				return fmt.Errorf("%s.%s is not allowed", field, name)
+			}
+			prop = additional.Schema
+		}
+		err := s.validate(prop, v, field+"."+name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Spec) validateArray(schema *Schema, value any, field string) error {
+	arr, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("%s must be an array", field)
+	}
+
+	if schema.MinItems != nil && len(arr) < *schema.MinItems {
+		return fmt.Errorf("%s must contain at least %d items", field, *schema.MinItems)
+	}
+	if schema.MaxItems != nil && len(arr) > *schema.MaxItems {
+		return fmt.Errorf("%s must contain at most %d items", field, *schema.MaxItems)
+	}
+
+	if schema.Items == nil {
+		return nil
+	}
+	for i, v := range arr {
+		err := s.validate(schema.Items, v, fmt.Sprintf("%s[%d]", field, i))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateString(schema *Schema, value any, field string) error {
+	str, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("%s must be a string", field)
+	}
+
+	length := len([]rune(str))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		return fmt.Errorf("%s must be at least %d characters", field, *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return fmt.Errorf("%s must be at most %d characters", field, *schema.MaxLength)
+	}
+
+	if schema.Format == "email" {
+		if err := checkmail.ValidateFormat(str); err != nil {
+			return fmt.Errorf("%s is not a valid email", field)
+		}
+	}
+	return nil
+}
+
+func validateNumber(schema *Schema, value any, field string) error {
+	num, ok := value.(json.Number)
+	if !ok {
+		return fmt.Errorf("%s must be a %s", field, schema.Type)
+	}
+
+	f, err := num.Float64()
+	if err != nil {
+		return fmt.Errorf("%s must be a %s", field, schema.Type)
+	}
+	if schema.Type == "integer" && f != math.Trunc(f) {
+		return fmt.Errorf("%s must be an integer", field)
+	}
+
+	if schema.Minimum != nil {
+		if schema.ExclusiveMinimum && f <= *schema.Minimum {
+			return fmt.Errorf("%s must be greater than %v", field, *schema.Minimum)
+		}
+		if f < *schema.Minimum {
+			return fmt.Errorf("%s must be at least %v", field, *schema.Minimum)
+		}
+	}
+	if schema.Maximum != nil && f > *schema.Maximum {
+		return fmt.Errorf("%s must be at most %v", field, *schema.Maximum)
+	}
+	return nil
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSpecReferences(t *testing.T) {
+	ref := regexp.MustCompile(`"#/components/schemas/([^"]+)"`)
+
+	for _, service := range []string{"order", "product", "user"} {
+		t.Run(service, func(t *testing.T) {
+			spec, err := Load(service)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, match := range ref.FindAllStringSubmatch(string(spec.raw), -1) {
+				if _, ok := spec.Components.Schemas[match[1]]; !ok {
+					t.Errorf("unknown schema reference %s", match[1])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	spec := MustLoad("product")
+
+	router := mux.NewRouter()
+	router.Use(spec.ValidateRequest)
+	router.HandleFunc("/api/products/{id}/stock/adjustments", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}).Methods(http.MethodPost)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		err    string
+	}{
+		{"valid", `{"delta": -2, "reason": "manual_adjustment"}`, http.StatusOK, ""},
+		{"nullable field", `{"delta": 5, "reason": "restock", "warehouse_id": null}`, http.StatusOK, ""},
+		{"empty body", ``, http.StatusBadRequest, "Request body is required"},
+		{"invalid JSON", `{"delta":`, http.StatusBadRequest, "Invalid JSON format"},
+		{"missing field", `{"delta": 1}`, http.StatusBadRequest, "body.reason is required"},
+		{"unknown field", `{"delta": 1, "reason": "restock", "stock": 3}`, http.StatusBadRequest, "body.stock is not allowed"},
+		{"not in enum", `{"delta": 1, "reason": "theft"}`, http.StatusBadRequest, "body.reason must be one of"},
+		{"not an integer", `{"delta": 1.5, "reason": "restock"}`, http.StatusBadRequest, "body.delta must be an integer"},
+		{"wrong type", `{"delta": "1", "reason": "restock"}`, http.StatusBadRequest, "body.delta must be a integer"},
+		{"below minimum", `{"delta": 1, "reason": "restock", "warehouse_id": 0}`, http.StatusBadRequest, "body.warehouse_id must be at least 1"},
+		{"too long", `{"delta": 1, "reason": "restock", "reference": "` + strings.Repeat("x", 256) + `"}`, http.StatusBadRequest, "body.reference must be at most 255 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/products/1/stock/adjustments", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.err != "" && !strings.Contains(w.Body.String(), tt.err) {
+				t.Errorf("error = %q, want it to contain %q", w.Body.String(), tt.err)
+			}
+			if tt.status == http.StatusOK && w.Body.String() != tt.body {
+				t.Errorf("handler read %q, want the original body %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestValidateAdditionalProperties(t *testing.T) {
+	spec, err := Load("product")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		err  string
+	}{
+		{"string attributes", `{"sku": "TS-RED-M", "attributes": {"color": "red", "size": "M"}}`, ""},
+		{"no attributes", `{"sku": "TS-RED-M", "attributes": {}}`, ""},
+		{"attribute of another type", `{"sku": "TS-RED-M", "attributes": {"size": 40}}`, "body.attributes.size must be a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data any
+			decoder := json.NewDecoder(bytes.NewReader([]byte(tt.body)))
+			decoder.UseNumber()
+			if err := decoder.Decode(&data); err != nil {
+				t.Fatal(err)
+			}
+
+			err := spec.validate(spec.Components.Schemas["ProductVariantInput"], data, "body")
+			if tt.err == "" && err != nil {
+				t.Errorf("validate() = %v, want nil", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("validate() = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
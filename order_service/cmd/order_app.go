@@ -9,6 +9,7 @@ import (
 	"order_processing_system/order_service/internal/natsclient"
 	"order_processing_system/order_service/internal/server"
 	"order_processing_system/order_service/internal/services"
+	"order_processing_system/order_service/order_utils"
 	"os"
 	"os/signal"
 	"strconv"
@@ -60,14 +61,77 @@ func Run() error {
 	nats_url := os.Getenv("NATS_URL")
 	nats := natsclient.NewNATS(nats_url)
 
+	// allocation
+	allocator, err := order_utils.NewAllocationStrategy(os.Getenv("ORDER_ALLOCATION_STRATEGY"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// taxes
+	taxEngine, err := order_utils.NewTaxEngine(os.Getenv("ORDER_TAX_ENGINE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// shipping
+	shippingConfig := order_utils.ShippingConfig{Method: os.Getenv("SHIPPING_METHOD")}
+	for key, value := range map[string]*float64{
+		"SHIPPING_FLAT_RATE":   &shippingConfig.Flat,
+		"SHIPPING_BASE_RATE":   &shippingConfig.Base,
+		"SHIPPING_RATE_PER_KG": &shippingConfig.PerKg,
+		"SHIPPING_FREE_OVER":   &shippingConfig.FreeOver,
+	} {
+		*value, err = amountEnv(key)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	shipping, err := order_utils.NewShippingCalculator(shippingConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// payments
+	paymentConfig := order_utils.PaymentConfig{
+		Provider:      os.Getenv("ORDER_PAYMENT_PROVIDER"),
+		WebhookURL:    os.Getenv("PAYMENT_WEBHOOK_URL"),
+		WebhookSecret: os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+	}
+	payments, err := order_utils.NewPaymentProvider(paymentConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// reservations
+	reservationTTL, err := durationEnv("RESERVATION_TTL_MINUTES", 15, time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sweepInterval, err := durationEnv("RESERVATION_SWEEP_SECONDS", 60, time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// unpaid orders
+	paymentWindow, err := durationEnv("ORDER_PAYMENT_WINDOW_MINUTES", 60, time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	expirySweepInterval, err := durationEnv("ORDER_EXPIRY_SWEEP_SECONDS", 60, time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// service
-	productService := services.NewService(psqlRepo, redisRepo, nats)
+	productService := services.NewService(psqlRepo, redisRepo, nats, allocator, taxEngine, shipping, payments, paymentConfig.WebhookSecret, reservationTTL)
 	go func() {
 		err := productService.ListenProductUpdates()
 		if err != nil {
 			log.Print(err)
 		}
 	}()
+	go productService.SweepReservations(sweepInterval)
+	go productService.SweepUnpaidOrders(paymentWindow, expirySweepInterval)
 
 	// controller
 	orderController := controllers.NewController(errChan, productService)
@@ -106,3 +170,30 @@ func Run() error {
 	server.StartServer(orderSrv)
 	return nil
 }
+
+func durationEnv(key string, fallback int, unit time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Duration(fallback) * unit, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// amountEnv reads a money amount, unset means 0.
+func amountEnv(key string) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative amount", key)
+	}
+	return amount, nil
+}
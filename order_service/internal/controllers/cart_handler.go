@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"order_processing_system/cart"
+	"order_processing_system/order_service/internal/services"
+	"order_processing_system/order_service/order_utils/models"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type CartHandler interface {
+	CartDetail(w http.ResponseWriter, r *http.Request)
+	CartClear(w http.ResponseWriter, r *http.Request)
+	CartAddItem(w http.ResponseWriter, r *http.Request)
+	CartUpdateItem(w http.ResponseWriter, r *http.Request)
+	CartRemoveItem(w http.ResponseWriter, r *http.Request)
+	CartCheckout(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) CartDetail(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cartOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	cart, err := c.s.GetCart(owner)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.writeCart(w, cart)
+}
+
+func (c *Controller) CartClear(w http.ResponseWriter, r *http.Request) {
+	owner, ok := cartOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	err := c.s.ClearCart(owner)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Cart cleared successfully"))
+}
+
+func (c *Controller) CartAddItem(w http.ResponseWriter, r *http.Request) {
+	var input models.CartItemInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	owner, ok := cartOwner(w, r, true)
+	if !ok {
+		return
+	}
+
+	cart, err := c.s.AddCartItem(owner, &input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.writeCart(w, cart)
+}
+
+func (c *Controller) CartUpdateItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID := vars["product_id"]
+
+	var input models.CartItemUpdate
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	owner, ok := cartOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	cart, err := c.s.UpdateCartItem(owner, productID, input.VariantID, input.Quantity)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.writeCart(w, cart)
+}
+
+func (c *Controller) CartRemoveItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID := vars["product_id"]
+
+	var variantID *int
+	if v := r.URL.Query().Get("variant_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid variant_id", http.StatusBadRequest)
+			return
+		}
+		variantID = &id
+	}
+
+	owner, ok := cartOwner(w, r, false)
+	if !ok {
+		return
+	}
+
+	cart, err := c.s.RemoveCartItem(owner, productID, variantID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.writeCart(w, cart)
+}
+
+func (c *Controller) CartCheckout(w http.ResponseWriter, r *http.Request) {
+	// the body is optional, it only carries the delivery location
+	var input models.CartCheckout
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := c.s.CheckoutCart(info.ID, &input)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	respMsg := fmt.Sprintf("Order %d created successfully", order.ID)
+	w.Write([]byte(respMsg))
+}
+
+func (c *Controller) writeCart(w http.ResponseWriter, cart models.Cart) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err := json.NewEncoder(w).Encode(cart)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+// cartOwner resolves whose cart a request is about. A bearer token selects the
+// user's cart, otherwise the X-Cart-ID header selects a guest cart; create
+// hands out a new guest id when there is none yet. The guest id is echoed in
+// the response so that clients can keep it.
+func cartOwner(w http.ResponseWriter, r *http.Request, create bool) (services.CartOwner, bool) {
+	if r.Header.Get("Authorization") != "" {
+		info, err := requestClaims(r)
+		if err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return services.CartOwner{}, false
+		}
+		return services.CartOwner{UserID: info.ID}, true
+	}
+
+	id := r.Header.Get("X-Cart-ID")
+	if id == "" && create {
+		var err error
+		id, err = cart.NewID()
+		if err != nil {
+			http.Error(w, "Failed to create cart", http.StatusInternalServerError)
+			return services.CartOwner{}, false
+		}
+	}
+	if id != "" && !cart.IsID(id) {
+		http.Error(w, "Invalid X-Cart-ID header", http.StatusBadRequest)
+		return services.CartOwner{}, false
+	}
+
+	if id != "" {
+		w.Header().Set("X-Cart-ID", id)
+	}
+	return services.CartOwner{GuestID: id}, true
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/gorilla/mux"
+)
+
+type EditHandler interface {
+	OrderEdit(w http.ResponseWriter, r *http.Request)
+	OrderRevisions(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) OrderEdit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var edit models.OrderEdit
+	err := json.NewDecoder(r.Body).Decode(&edit)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revision, err := c.s.EditOrder(id, &edit, info.Root, info.ID)
+	if errors.Is(err, models.ErrVersionConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(revision)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) OrderRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revisions, err := c.s.GetOrderRevisions(id, info.Root, info.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(revisions)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
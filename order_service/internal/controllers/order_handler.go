@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"order_processing_system/order_service/internal/services"
 	"order_processing_system/order_service/order_utils"
 	"order_processing_system/order_service/order_utils/models"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -22,8 +24,10 @@ type Controller struct {
 type Handler interface {
 	OrderList(w http.ResponseWriter, r *http.Request)
 	OrderDetail(w http.ResponseWriter, r *http.Request)
+	OrderSearch(w http.ResponseWriter, r *http.Request)
 	UserOrders(w http.ResponseWriter, r *http.Request)
 	UpdateOrderStatus(w http.ResponseWriter, r *http.Request)
+	BulkUpdateOrderStatus(w http.ResponseWriter, r *http.Request)
 }
 
 func NewController(ch chan error, s *services.Service) *Controller {
@@ -63,14 +67,6 @@ func (c *Controller) OrderList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, product := range order.Products {
-		err = c.s.PSQLRepo.DecreaseProductStock(product.ProductID, product.Quantity)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	respMsg := fmt.Sprintf("Order %d created successfully", order.ID)
@@ -110,6 +106,35 @@ func (c *Controller) OrderDetail(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (c *Controller) OrderSearch(w http.ResponseWriter, r *http.Request) {
+	query, err := models.ParseOrderQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := c.s.ListOrders(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		next := query
+		next.Cursor = page.NextCursor
+		w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, next.Values().Encode()))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(page.Orders)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
 func (c *Controller) UserOrders(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
@@ -145,6 +170,14 @@ func (c *Controller) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
+	authHeader := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+
+	token := strings.TrimPrefix(authHeader, prefix)
+	token = strings.TrimSpace(token)
+
+	info, _ := redis.ParseToken(token)
+
 	var status models.StatusUpdate
 	err := json.NewDecoder(r.Body).Decode(&status)
 
@@ -154,7 +187,11 @@ func (c *Controller) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.s.UpdateOrderStatus(id, status.Status)
+	err = c.s.UpdateOrderStatus(id, status.Status, info.ID)
+	if errors.Is(err, models.ErrIllegalTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		log.Println(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -167,3 +204,33 @@ func (c *Controller) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	respMsg := fmt.Sprintf("Order %s updated successfully", id)
 	w.Write([]byte(respMsg))
 }
+
+func (c *Controller) BulkUpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	var update models.BulkStatusUpdate
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	report, err := c.s.BulkUpdateOrderStatus(&update, info.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(report)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"order_processing_system/order_service/order_utils"
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/gorilla/mux"
+)
+
+// maxWebhookBytes bounds the size of a payment provider event.
+const maxWebhookBytes = 1 << 20
+
+type PaymentHandler interface {
+	PaymentCreate(w http.ResponseWriter, r *http.Request)
+	PaymentList(w http.ResponseWriter, r *http.Request)
+	PaymentCapture(w http.ResponseWriter, r *http.Request)
+	PaymentVoid(w http.ResponseWriter, r *http.Request)
+	PaymentRefund(w http.ResponseWriter, r *http.Request)
+	PaymentWebhook(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) PaymentCreate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var input models.PaymentInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	payment, err := c.s.PayOrder(id, &input, info.Root, info.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// a declined payment is recorded too, the status tells the outcome
+	status := http.StatusCreated
+	if payment.Status == models.PaymentFailed {
+		status = http.StatusPaymentRequired
+	}
+	c.writePayment(w, status, payment)
+}
+
+func (c *Controller) PaymentList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	payments, err := c.s.GetOrderPayments(id, info.Root, info.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(payments)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) PaymentCapture(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	payment, err := c.s.CapturePayment(vars["id"], vars["payment_id"])
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.writePayment(w, http.StatusOK, payment)
+}
+
+func (c *Controller) PaymentVoid(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	payment, err := c.s.VoidPayment(vars["id"], vars["payment_id"])
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.writePayment(w, http.StatusOK, payment)
+}
+
+func (c *Controller) PaymentRefund(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// the body is optional, without an amount everything left is refunded
+	var input models.RefundInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := c.s.RefundPayment(vars["id"], vars["payment_id"], &input)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.writePayment(w, http.StatusOK, payment)
+}
+
+func (c *Controller) writePayment(w http.ResponseWriter, status int, payment *models.Payment) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(payment)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+// PaymentWebhook receives the events of the payment provider. It answers with
+// an error status whenever the event was not applied, so that the provider
+// delivers it again.
+func (c *Controller) PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	err = c.s.HandlePaymentWebhook(body, r.Header.Get(order_utils.WebhookSignatureHeader))
+	if errors.Is(err, order_utils.ErrInvalidSignature) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Event received"))
+}
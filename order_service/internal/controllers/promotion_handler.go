@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/gorilla/mux"
+)
+
+type PromotionHandler interface {
+	PromotionList(w http.ResponseWriter, r *http.Request)
+	PromotionDetail(w http.ResponseWriter, r *http.Request)
+	PromotionCreate(w http.ResponseWriter, r *http.Request)
+	PromotionUpdate(w http.ResponseWriter, r *http.Request)
+	PromotionDeactivate(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) PromotionList(w http.ResponseWriter, r *http.Request) {
+	promotions, err := c.s.GetPromotions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(promotions)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) PromotionDetail(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	promotion, err := c.s.GetPromotion(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(promotion)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) PromotionCreate(w http.ResponseWriter, r *http.Request) {
+	// promotions are active unless created otherwise
+	promotion := models.Promotion{Active: true}
+	err := json.NewDecoder(r.Body).Decode(&promotion)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	err = c.s.CreatePromotion(&promotion)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(promotion)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) PromotionUpdate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var newPromotion models.PromotionUpdate
+	err := json.NewDecoder(r.Body).Decode(&newPromotion)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	promotion, err := c.s.UpdatePromotion(id, newPromotion)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(promotion)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) PromotionDeactivate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	err := c.s.DeactivatePromotion(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	respMsg := fmt.Sprintf("Promotion %s deactivated successfully", id)
+	w.Write([]byte(respMsg))
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"order_processing_system/export"
+	"order_processing_system/order_service/order_utils"
+	"order_processing_system/order_service/order_utils/models"
+)
+
+type ReportHandler interface {
+	OrderExport(w http.ResponseWriter, r *http.Request)
+	SalesReport(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) OrderExport(w http.ResponseWriter, r *http.Request) {
+	query, err := models.ParseOrderQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stream := export.NewStream(w)
+	exporter, err := order_utils.NewOrderExporter(r.URL.Query().Get("format"), stream)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", exporter.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"orders.%s\"", exporter.Extension()))
+
+	err = c.s.ExportOrders(query, exporter)
+	if err != nil {
+		stream.Fail(err)
+	}
+}
+
+func (c *Controller) SalesReport(w http.ResponseWriter, r *http.Request) {
+	query, err := models.ParseSalesQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := c.s.GetSalesReport(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(report)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
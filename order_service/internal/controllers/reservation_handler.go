@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"order_processing_system/db/redis"
+	"order_processing_system/order_service/order_utils/models"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type ReservationHandler interface {
+	ReservationCreate(w http.ResponseWriter, r *http.Request)
+	ReservationDetail(w http.ResponseWriter, r *http.Request)
+	ReservationRelease(w http.ResponseWriter, r *http.Request)
+	ReservationConfirm(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) ReservationCreate(w http.ResponseWriter, r *http.Request) {
+	var input models.ReservationInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reservation, err := c.s.ReserveStock(&input, info.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(reservation)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) ReservationDetail(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reservation, err := c.s.GetReservation(id, info.Root, info.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(reservation)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) ReservationRelease(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = c.s.ReleaseReservation(id, info.Root, info.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	respMsg := fmt.Sprintf("Reservation %s released successfully", id)
+	w.Write([]byte(respMsg))
+}
+
+func (c *Controller) ReservationConfirm(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// the body is optional, it only carries the delivery location
+	var input models.ReservationConfirm
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := c.s.ConfirmReservation(id, &input, info.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	respMsg := fmt.Sprintf("Order %d created successfully", order.ID)
+	w.Write([]byte(respMsg))
+}
+
+func requestClaims(r *http.Request) (*redis.Claims, error) {
+	authHeader := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+
+	token := strings.TrimPrefix(authHeader, prefix)
+	token = strings.TrimSpace(token)
+
+	return redis.ParseToken(token)
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/gorilla/mux"
+)
+
+type ReturnHandler interface {
+	ReturnCreate(w http.ResponseWriter, r *http.Request)
+	ReturnList(w http.ResponseWriter, r *http.Request)
+	ReturnApprove(w http.ResponseWriter, r *http.Request)
+	ReturnReject(w http.ResponseWriter, r *http.Request)
+	ReturnReceive(w http.ResponseWriter, r *http.Request)
+	ReturnRefund(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) ReturnCreate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var input models.ReturnInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ret, err := c.s.RequestReturn(id, &input, info.Root, info.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.writeReturn(w, http.StatusCreated, ret)
+}
+
+func (c *Controller) ReturnList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	returns, err := c.s.GetOrderReturns(id, info.Root, info.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(returns)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) ReturnApprove(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// the body is optional, it only carries a note for the customer
+	var decision models.ReturnDecision
+	err := json.NewDecoder(r.Body).Decode(&decision)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	ret, err := c.s.ApproveReturn(vars["id"], vars["return_id"], &decision)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.writeReturn(w, http.StatusOK, ret)
+}
+
+func (c *Controller) ReturnReject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var decision models.ReturnDecision
+	err := json.NewDecoder(r.Body).Decode(&decision)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	ret, err := c.s.RejectReturn(vars["id"], vars["return_id"], &decision)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.writeReturn(w, http.StatusOK, ret)
+}
+
+func (c *Controller) ReturnReceive(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var receipt models.ReturnReceipt
+	err := json.NewDecoder(r.Body).Decode(&receipt)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	info, err := requestClaims(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ret, err := c.s.ReceiveReturn(vars["id"], vars["return_id"], &receipt, info.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.writeReturn(w, http.StatusOK, ret)
+}
+
+func (c *Controller) ReturnRefund(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// without an amount the returned items are refunded at their value
+	var input models.RefundInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	ret, err := c.s.RefundReturn(vars["id"], vars["return_id"], &input)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.writeReturn(w, http.StatusOK, ret)
+}
+
+func (c *Controller) writeReturn(w http.ResponseWriter, status int, ret *models.Return) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(ret)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
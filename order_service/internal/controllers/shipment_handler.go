@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/gorilla/mux"
+)
+
+type ShipmentHandler interface {
+	ShipmentCreate(w http.ResponseWriter, r *http.Request)
+	ShipmentUpdateStatus(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) ShipmentCreate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var input models.ShipmentInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	shipment, err := c.s.ShipOrder(id, &input)
+	if errors.Is(err, models.ErrIllegalTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(shipment)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) ShipmentUpdateStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	shipmentID := vars["shipment_id"]
+
+	var status models.ShipmentStatusUpdate
+	err := json.NewDecoder(r.Body).Decode(&status)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	err = c.s.UpdateShipmentStatus(id, shipmentID, status.Status)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	respMsg := fmt.Sprintf("Shipment %s updated successfully", shipmentID)
+	w.Write([]byte(respMsg))
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"order_processing_system/order_service/order_utils/models"
+
+	"github.com/gorilla/mux"
+)
+
+type TaxHandler interface {
+	TaxRateList(w http.ResponseWriter, r *http.Request)
+	TaxRateCreate(w http.ResponseWriter, r *http.Request)
+	TaxRateUpdate(w http.ResponseWriter, r *http.Request)
+	TaxRateDelete(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) TaxRateList(w http.ResponseWriter, r *http.Request) {
+	rates, err := c.s.GetTaxRates()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(rates)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) TaxRateCreate(w http.ResponseWriter, r *http.Request) {
+	var rate models.TaxRate
+	err := json.NewDecoder(r.Body).Decode(&rate)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	err = c.s.CreateTaxRate(&rate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(rate)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) TaxRateUpdate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var newRate models.TaxRate
+	err := json.NewDecoder(r.Body).Decode(&newRate)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	rate, err := c.s.UpdateTaxRate(id, newRate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(rate)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) TaxRateDelete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	err := c.s.DeleteTaxRate(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	respMsg := fmt.Sprintf("Tax rate %s deleted successfully", id)
+	w.Write([]byte(respMsg))
+}
@@ -17,3 +17,7 @@ func NewNATS(url string) *OrderNATS {
 func (n *OrderNATS) Subscribe(subject string, cb nats.MsgHandler) (*nats.Subscription, error) {
 	return n.Conn.Subscribe(subject, cb)
 }
+
+func (n *OrderNATS) Publish(subject string, data []byte) error {
+	return n.Conn.Publish(subject, data)
+}
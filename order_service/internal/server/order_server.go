@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"order_processing_system/openapi"
 	"order_processing_system/order_service/internal/controllers"
 	"order_processing_system/order_service/internal/middleware"
 
@@ -12,17 +13,88 @@ import (
 func NewServer(c *controllers.Controller) *http.Server {
 	r := mux.NewRouter().StrictSlash(true)
 
+	spec := openapi.MustLoad("order")
+	r.Use(spec.ValidateRequest)
+
+	r.HandleFunc("/openapi.json", spec.Handler).Methods("GET")
+	r.HandleFunc("/docs", openapi.SwaggerUI).Methods("GET")
+
 	orderRouter := r.PathPrefix("/api/orders").Subrouter()
 	orderRouter.Use(middleware.IsAuthenticated)
 
 	orderRouter.HandleFunc("", c.OrderList).Methods("POST")
 	orderRouter.HandleFunc("/{id}", c.OrderDetail).Methods("GET")
+	orderRouter.HandleFunc("/{id}", c.OrderEdit).Methods("PUT")
+	orderRouter.HandleFunc("/{id}/revisions", c.OrderRevisions).Methods("GET")
+	orderRouter.HandleFunc("/{id}/payments", c.PaymentCreate).Methods("POST")
+	orderRouter.HandleFunc("/{id}/payments", c.PaymentList).Methods("GET")
+	orderRouter.HandleFunc("/{id}/returns", c.ReturnCreate).Methods("POST")
+	orderRouter.HandleFunc("/{id}/returns", c.ReturnList).Methods("GET")
 	orderRouter.HandleFunc("/user/{id}", c.UserOrders).Methods("GET")
 
+	reservationRouter := r.PathPrefix("/api/reservations").Subrouter()
+	reservationRouter.Use(middleware.IsAuthenticated)
+
+	reservationRouter.HandleFunc("", c.ReservationCreate).Methods("POST")
+	reservationRouter.HandleFunc("/{id}", c.ReservationDetail).Methods("GET")
+	reservationRouter.HandleFunc("/{id}", c.ReservationRelease).Methods("DELETE")
+	reservationRouter.HandleFunc("/{id}/confirm", c.ReservationConfirm).Methods("POST")
+
+	// payment providers authenticate their webhooks with a signature
+	r.HandleFunc("/api/payments/webhook", c.PaymentWebhook).Methods("POST")
+
+	// guests use the cart without logging in, identified by X-Cart-ID
+	cartRouter := r.PathPrefix("/api/cart").Subrouter()
+
+	cartRouter.HandleFunc("", c.CartDetail).Methods("GET")
+	cartRouter.HandleFunc("", c.CartClear).Methods("DELETE")
+	cartRouter.HandleFunc("/items", c.CartAddItem).Methods("POST")
+	cartRouter.HandleFunc("/items/{product_id}", c.CartUpdateItem).Methods("PUT")
+	cartRouter.HandleFunc("/items/{product_id}", c.CartRemoveItem).Methods("DELETE")
+
+	checkoutRouter := r.PathPrefix("/api/cart/checkout").Subrouter()
+	checkoutRouter.Use(middleware.IsAuthenticated)
+
+	checkoutRouter.HandleFunc("", c.CartCheckout).Methods("POST")
+
 	adminRouter := r.PathPrefix("/api/orders").Subrouter()
 	adminRouter.Use(middleware.IsAdmin)
 
+	adminRouter.HandleFunc("", c.OrderSearch).Methods("GET")
+	adminRouter.HandleFunc("/bulk-status", c.BulkUpdateOrderStatus).Methods("POST")
 	adminRouter.HandleFunc("/{id}/status", c.UpdateOrderStatus).Methods("PUT")
+	adminRouter.HandleFunc("/{id}/shipments", c.ShipmentCreate).Methods("POST")
+	adminRouter.HandleFunc("/{id}/shipments/{shipment_id}", c.ShipmentUpdateStatus).Methods("PUT")
+	adminRouter.HandleFunc("/{id}/payments/{payment_id}/capture", c.PaymentCapture).Methods("POST")
+	adminRouter.HandleFunc("/{id}/payments/{payment_id}/void", c.PaymentVoid).Methods("POST")
+	adminRouter.HandleFunc("/{id}/payments/{payment_id}/refund", c.PaymentRefund).Methods("POST")
+	adminRouter.HandleFunc("/{id}/returns/{return_id}/approve", c.ReturnApprove).Methods("POST")
+	adminRouter.HandleFunc("/{id}/returns/{return_id}/reject", c.ReturnReject).Methods("POST")
+	adminRouter.HandleFunc("/{id}/returns/{return_id}/receive", c.ReturnReceive).Methods("POST")
+	adminRouter.HandleFunc("/{id}/returns/{return_id}/refund", c.ReturnRefund).Methods("POST")
+
+	reportRouter := r.PathPrefix("/api/reports").Subrouter()
+	reportRouter.Use(middleware.IsAdmin)
+
+	reportRouter.HandleFunc("/orders/export", c.OrderExport).Methods("GET")
+	reportRouter.HandleFunc("/sales", c.SalesReport).Methods("GET")
+
+	promotionRouter := r.PathPrefix("/api/promotions").Subrouter()
+	promotionRouter.Use(middleware.IsAdmin)
+
+	promotionRouter.HandleFunc("", c.PromotionList).Methods("GET")
+	promotionRouter.HandleFunc("", c.PromotionCreate).Methods("POST")
+	promotionRouter.HandleFunc("/{id}", c.PromotionDetail).Methods("GET")
+	promotionRouter.HandleFunc("/{id}", c.PromotionUpdate).Methods("PUT")
+	promotionRouter.HandleFunc("/{id}", c.PromotionDeactivate).Methods("DELETE")
+
+	taxRouter := r.PathPrefix("/api/tax-rates").Subrouter()
+	taxRouter.Use(middleware.IsAdmin)
+
+	taxRouter.HandleFunc("", c.TaxRateList).Methods("GET")
+	taxRouter.HandleFunc("", c.TaxRateCreate).Methods("POST")
+	taxRouter.HandleFunc("/{id}", c.TaxRateUpdate).Methods("PUT")
+	taxRouter.HandleFunc("/{id}", c.TaxRateDelete).Methods("DELETE")
 
 	fmt.Println("http://localhost:8002/api/orders/")
 
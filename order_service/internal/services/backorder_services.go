@@ -0,0 +1,42 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils/models"
+)
+
+// FillBackorders allocates incoming stock of a product to its backordered
+// order lines in FIFO order and announces every fill.
+func (s *Service) FillBackorders(productID int) error {
+	var filled []models.BackorderFill
+	err := s.trackStockLevels([]models.OrderProduct{{ProductID: productID}}, func(repo *psql.PostgresRepo) error {
+		var err error
+		filled, err = repo.AllocateBackorders(productID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, fill := range filled {
+		s.RedisRepo.Delete(fmt.Sprintf("order_%d", fill.OrderID))
+		s.RedisRepo.Delete(fmt.Sprintf("user_%d_orders", fill.UserID))
+
+		// NATS backorder allocated
+
+		fillData, err := json.Marshal(fill)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		err = s.NATSClient.Publish("order.backorder_allocated", fillData)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"order_processing_system/order_service/order_utils/models"
+)
+
+// bulkStatusBatch is how many orders a bulk status update reads at once.
+const bulkStatusBatch = 100
+
+// BulkUpdateOrderStatus moves many orders to the same status, each through the
+// transitions of a single update. The orders are read in batches and a failing
+// order does not stop the others, the report tells what became of each one.
+func (s *Service) BulkUpdateOrderStatus(update *models.BulkStatusUpdate, actorID int) (*models.BulkStatusReport, error) {
+	err := update.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	seen := map[int]bool{}
+	for _, id := range update.OrderIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	report := &models.BulkStatusReport{
+		Status:  update.Status,
+		Results: make([]models.BulkStatusResult, 0, len(ids)),
+	}
+
+	for start := 0; start < len(ids); start += bulkStatusBatch {
+		batch := ids[start:min(start+bulkStatusBatch, len(ids))]
+
+		orders, err := s.PSQLRepo.GetOrdersByID(batch)
+		if err != nil {
+			log.Println(err)
+			for _, id := range batch {
+				report.Results = append(report.Results, models.BulkStatusResult{OrderID: id, Result: models.BulkFailed, Error: err.Error()})
+			}
+			continue
+		}
+
+		found := map[int]*models.Order{}
+		for i := range orders {
+			found[orders[i].ID] = &orders[i]
+		}
+
+		for _, id := range batch {
+			report.Results = append(report.Results, s.bulkChangeStatus(id, found[id], update.Status, actorID))
+		}
+	}
+
+	for _, result := range report.Results {
+		if result.Result == models.BulkSucceeded {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+func (s *Service) bulkChangeStatus(id int, order *models.Order, status string, actorID int) models.BulkStatusResult {
+	result := models.BulkStatusResult{OrderID: id}
+	if order == nil {
+		result.Result = models.BulkNotFound
+		result.Error = models.ErrOrderNotFound.Error()
+		return result
+	}
+	result.From = order.Status
+
+	err := s.changeOrderStatus(order, status, actorID)
+	switch {
+	case err == nil:
+		result.Result = models.BulkSucceeded
+	case errors.Is(err, models.ErrIllegalTransition):
+		result.Result = models.BulkIllegalTransition
+		result.Error = err.Error()
+	default:
+		result.Result = models.BulkFailed
+		result.Error = err.Error()
+	}
+	return result
+}
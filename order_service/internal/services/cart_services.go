@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/order_service/order_utils"
+	"order_processing_system/order_service/order_utils/models"
+	"strconv"
+	"strings"
+)
+
+// CartOwner identifies a cart. Logged in users keep their cart in Postgres,
+// guests in Redis under the id they send in the X-Cart-ID header.
+type CartOwner struct {
+	UserID  int
+	GuestID string
+}
+
+func (s *Service) GetCart(owner CartOwner) (models.Cart, error) {
+	items, err := s.loadCartItems(owner)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	return s.cartView(owner, items), nil
+}
+
+// AddCartItem adds a line to the cart, or raises the quantity of the line
+// already holding the same product and variant.
+func (s *Service) AddCartItem(owner CartOwner, input *models.CartItemInput) (models.Cart, error) {
+	if input.Quantity <= 0 {
+		return models.Cart{}, errors.New("quantity must be greater than 0")
+	}
+
+	line := models.OrderProduct{ProductID: input.ProductID, VariantID: input.VariantID, Quantity: input.Quantity}
+	_, price, err := order_utils.LinePrice(line, s.PSQLRepo)
+	if err != nil {
+		return models.Cart{}, fmt.Errorf("product %d not found", input.ProductID)
+	}
+	_, err = order_utils.GetAvailableLineAmount(line, s.PSQLRepo)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	items, err := s.loadCartItems(owner)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	added := false
+	for i := range items {
+		if items[i].SameLine(input.ProductID, input.VariantID) {
+			items[i].Quantity += input.Quantity
+			items[i].AddedPrice = price
+			added = true
+			break
+		}
+	}
+	if !added {
+		items = append(items, models.CartItem{
+			ProductID:  input.ProductID,
+			VariantID:  input.VariantID,
+			Quantity:   input.Quantity,
+			AddedPrice: price,
+		})
+	}
+
+	err = s.saveCartItems(owner, items)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	return s.cartView(owner, items), nil
+}
+
+// UpdateCartItem sets the quantity of a cart line, a quantity of 0 removes it.
+func (s *Service) UpdateCartItem(owner CartOwner, productID string, variantID *int, quantity int) (models.Cart, error) {
+	if quantity < 0 {
+		return models.Cart{}, errors.New("quantity must not be negative")
+	}
+	if quantity == 0 {
+		return s.RemoveCartItem(owner, productID, variantID)
+	}
+
+	p_id, err := strconv.Atoi(productID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	items, err := s.loadCartItems(owner)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	i := cartLineIndex(items, p_id, variantID)
+	if i < 0 {
+		return models.Cart{}, fmt.Errorf("product %d is not in the cart", p_id)
+	}
+	items[i].Quantity = quantity
+
+	err = s.saveCartItems(owner, items)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	return s.cartView(owner, items), nil
+}
+
+func (s *Service) RemoveCartItem(owner CartOwner, productID string, variantID *int) (models.Cart, error) {
+	p_id, err := strconv.Atoi(productID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	items, err := s.loadCartItems(owner)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	i := cartLineIndex(items, p_id, variantID)
+	if i < 0 {
+		return models.Cart{}, fmt.Errorf("product %d is not in the cart", p_id)
+	}
+	items = append(items[:i], items[i+1:]...)
+
+	err = s.saveCartItems(owner, items)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	return s.cartView(owner, items), nil
+}
+
+func (s *Service) ClearCart(owner CartOwner) error {
+	if owner.UserID != 0 {
+		return s.PSQLRepo.PutCartItems(owner.UserID, nil)
+	}
+	if owner.GuestID == "" {
+		return nil
+	}
+	return s.RedisRepo.DeleteGuestCart(owner.GuestID)
+}
+
+// CheckoutCart revalidates the cart of a user and places it as an order
+// through CreateOrder. The cart is emptied once the order exists, a cart that
+// cannot be emptied is only logged since the order is placed already.
+func (s *Service) CheckoutCart(user_id int, checkout *models.CartCheckout) (*models.Order, error) {
+	owner := CartOwner{UserID: user_id}
+	items, err := s.loadCartItems(owner)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, errors.New("cart is empty")
+	}
+
+	cart := s.cartView(owner, items)
+	if !cart.Valid {
+		var issues []string
+		for _, line := range cart.Lines {
+			for _, issue := range line.Issues {
+				issues = append(issues, fmt.Sprintf("product %d: %s", line.ProductID, issue))
+			}
+		}
+		return nil, fmt.Errorf("cart cannot be checked out: %s", strings.Join(issues, "; "))
+	}
+
+	orderData := &models.OrderInput{
+		Location:        checkout.Location,
+		Coupons:         checkout.Coupons,
+		ShippingAddress: checkout.ShippingAddress,
+		AddressID:       checkout.AddressID,
+	}
+	for _, item := range items {
+		orderData.Products = append(orderData.Products, models.OrderProduct{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	err = order_utils.Validate(orderData, s.PSQLRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.CreateOrder(orderData, user_id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.ClearCart(owner)
+	if err != nil {
+		log.Println(err)
+	}
+	return order, nil
+}
+
+func (s *Service) loadCartItems(owner CartOwner) ([]models.CartItem, error) {
+	if owner.UserID != 0 {
+		return s.PSQLRepo.GetCartItems(owner.UserID)
+	}
+
+	if owner.GuestID == "" {
+		return []models.CartItem{}, nil
+	}
+
+	// an unknown or expired guest cart is simply empty
+	items, err := s.RedisRepo.GetGuestCart(owner.GuestID)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return []models.CartItem{}, nil
+	}
+	return items, nil
+}
+
+func (s *Service) saveCartItems(owner CartOwner, items []models.CartItem) error {
+	if owner.UserID != 0 {
+		return s.PSQLRepo.PutCartItems(owner.UserID, items)
+	}
+	return s.RedisRepo.SetGuestCart(owner.GuestID, items)
+}
+
+func (s *Service) cartView(owner CartOwner, items []models.CartItem) models.Cart {
+	cart := order_utils.RevalidateCart(items, s.PSQLRepo)
+	cart.ID = owner.GuestID
+	cart.UserID = owner.UserID
+	return cart
+}
+
+func cartLineIndex(items []models.CartItem, productID int, variantID *int) int {
+	for i, item := range items {
+		if item.SameLine(productID, variantID) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils"
+	"order_processing_system/order_service/order_utils/models"
+)
+
+// EditOrder replaces the lines of an order still waiting for payment and
+// prices it again. The stock of the changed lines is taken or given back in
+// the same transaction that stores the new version of the order.
+func (s *Service) EditOrder(id string, edit *models.OrderEdit, is_admin bool, user_id int) (*models.OrderRevision, error) {
+	err := edit.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.getOwnOrder(id, is_admin, user_id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.Editable(order.Status) {
+		return nil, fmt.Errorf("order %d can no longer be edited", order.ID)
+	}
+	if edit.Version != order.Version {
+		return nil, models.ErrVersionConflict
+	}
+
+	order.Discounts, err = s.PSQLRepo.GetOrderDiscounts(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := s.PSQLRepo.GetOrderAddress(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousTotal := order.TotalAmount
+	touched := append([]models.OrderProduct{}, order.Products...)
+
+	changes, err := order_utils.EditLines(order, edit.Products, s.PSQLRepo)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, errors.New("the edit does not change the order")
+	}
+	touched = append(touched, order.Products...)
+
+	err = order_utils.PriceEdit(order, address, s.TaxEngine, s.Shipping, s.PSQLRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	var revision *models.OrderRevision
+	err = s.trackStockLevels(touched, func(repo *psql.PostgresRepo) error {
+		revision, err = repo.EditOrder(order, changes, previousTotal, user_id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateOrder(order.ID)
+	s.invalidateStock(touched)
+
+	// NATS order updated
+
+	updateData, err := json.Marshal(models.OrderUpdate{
+		OrderID:       order.ID,
+		UserID:        order.UserID,
+		Version:       revision.Version,
+		Changes:       changes,
+		PreviousTotal: previousTotal,
+		TotalAmount:   order.TotalAmount,
+	})
+	if err != nil {
+		log.Println(err)
+		return revision, nil
+	}
+
+	err = s.NATSClient.Publish("order.updated", updateData)
+	if err != nil {
+		log.Println(err)
+	}
+	return revision, nil
+}
+
+func (s *Service) GetOrderRevisions(id string, is_admin bool, user_id int) ([]models.OrderRevision, error) {
+	order, err := s.getOwnOrder(id, is_admin, user_id)
+	if err != nil {
+		return nil, err
+	}
+	return s.PSQLRepo.GetOrderRevisions(order.ID)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils/models"
+	"time"
+)
+
+// SweepUnpaidOrders periodically cancels the orders that were not paid within
+// the payment window, so that the stock they took goes back on sale, and
+// announces each one on NATS.
+func (s *Service) SweepUnpaidOrders(window time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ids, err := s.PSQLRepo.GetUnpaidOrders(time.Now().Add(-window))
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		for _, id := range ids {
+			err = s.expireOrder(id, fmt.Sprintf("not paid within %s", window))
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+// expireOrder cancels an unpaid order, its stock is returned in the same
+// transaction.
+func (s *Service) expireOrder(id int, reason string) error {
+	order, err := s.PSQLRepo.GetOrder(id)
+	if err != nil {
+		return err
+	}
+
+	var expired bool
+	err = s.trackStockLevels(order.Products, func(repo *psql.PostgresRepo) error {
+		expired, err = repo.ExpireOrder(id, reason)
+		return err
+	})
+	if err != nil || !expired {
+		return err
+	}
+
+	s.invalidateStock(order.Products)
+	s.invalidateOrder(order.ID)
+
+	// NATS order expired
+
+	expiredData, err := json.Marshal(models.ExpiredOrder{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Reason:    reason,
+		OrderDate: order.OrderDate,
+		Products:  order.Products,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.NATSClient.Publish("order.expired", expiredData)
+}
@@ -18,32 +18,96 @@ import (
 )
 
 type Service struct {
-	RedisRepo  *redis.RedisRepo
-	PSQLRepo   *psql.PostgresRepo
-	NATSClient *natsclient.OrderNATS
+	RedisRepo      *redis.RedisRepo
+	PSQLRepo       *psql.PostgresRepo
+	NATSClient     *natsclient.OrderNATS
+	Allocator      order_utils.AllocationStrategy
+	TaxEngine      order_utils.TaxEngine
+	Shipping       order_utils.ShippingCalculator
+	Payments       order_utils.PaymentProvider
+	WebhookSecret  string
+	ReservationTTL time.Duration
 }
 
-func NewService(psqlRepo *psql.PostgresRepo, redisRepo *redis.RedisRepo, natsClient *natsclient.OrderNATS) *Service {
+func NewService(psqlRepo *psql.PostgresRepo, redisRepo *redis.RedisRepo, natsClient *natsclient.OrderNATS, allocator order_utils.AllocationStrategy, taxEngine order_utils.TaxEngine, shipping order_utils.ShippingCalculator, payments order_utils.PaymentProvider, webhookSecret string, reservationTTL time.Duration) *Service {
 	return &Service{
-		RedisRepo:  redisRepo,
-		PSQLRepo:   psqlRepo,
-		NATSClient: natsClient,
+		RedisRepo:      redisRepo,
+		PSQLRepo:       psqlRepo,
+		NATSClient:     natsClient,
+		Allocator:      allocator,
+		TaxEngine:      taxEngine,
+		Shipping:       shipping,
+		Payments:       payments,
+		WebhookSecret:  webhookSecret,
+		ReservationTTL: reservationTTL,
 	}
 }
 
 func (s *Service) CreateOrder(orderData *models.OrderInput, user_id int) (*models.Order, error) {
+	order, err := s.placeOrder(orderData, user_id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateStock(order.Products)
+	return order, nil
+}
+
+func (s *Service) placeOrder(orderData *models.OrderInput, user_id int, reservationID *int) (*models.Order, error) {
+	address, err := order_utils.ResolveShippingAddress(orderData, user_id, s.PSQLRepo)
+	if err != nil {
+		return nil, err
+	}
+
 	var order models.Order
 	order.UserID = user_id
 	order.Status = "created"
 	order.Products = orderData.Products
 	order.OrderDate = time.Now()
+	order.ReservationID = reservationID
+
+	// a reservation already holds the stock of all its lines
+	if reservationID == nil {
+		err = order_utils.PlanBackorders(&order, s.PSQLRepo)
+		if err != nil {
+			return nil, err
+		}
+	}
 	amount, err := order_utils.CalculateTotalAmount(&order, s.PSQLRepo)
 	if err != nil {
 		return nil, err
 	}
 	order.TotalAmount = amount
 
-	return s.PSQLRepo.PostOrder(&order)
+	err = order_utils.ApplyPromotions(&order, orderData.Coupons, s.PSQLRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = order_utils.TaxOrder(&order, address.TaxRegion(), s.TaxEngine, s.PSQLRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = order_utils.ShipOrder(&order, address, s.Shipping, s.PSQLRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = order_utils.AllocateOrder(&order, orderData.Location, s.Allocator, s.PSQLRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	var placed *models.Order
+	err = s.trackStockLevels(order.Products, func(repo *psql.PostgresRepo) error {
+		placed, err = repo.PostOrder(&order)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return placed, nil
 }
 
 func (s *Service) GetOrderById(id string, is_admin bool, user_id int) (*models.OrderDetail, error) {
@@ -79,13 +143,51 @@ func (s *Service) GetOrderById(id string, is_admin bool, user_id int) (*models.O
 		return nil, err
 	}
 
+	discounts, err := s.PSQLRepo.GetOrderDiscounts(o_id)
+	if err != nil {
+		return nil, err
+	}
+
+	taxLines, err := s.PSQLRepo.GetOrderTaxLines(o_id)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := s.PSQLRepo.GetOrderAddress(o_id)
+	if err != nil {
+		return nil, err
+	}
+
+	shipments, err := s.PSQLRepo.GetOrderShipments(o_id)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.PSQLRepo.GetOrderStatusHistory(o_id)
+	if err != nil {
+		return nil, err
+	}
+
 	orderDeatil := &models.OrderDetail{
-		ID:          order.ID,
-		UserID:      order.UserID,
-		OrderDate:   order.OrderDate,
-		Status:      order.Status,
-		TotalAmount: order.TotalAmount,
-		Products:    []utils.Product{},
+		ID:             order.ID,
+		UserID:         order.UserID,
+		OrderDate:      order.OrderDate,
+		Status:         order.Status,
+		Subtotal:       order.Subtotal,
+		DiscountAmount: order.DiscountAmount,
+		TaxAmount:      order.TaxAmount,
+		TaxRegion:      order.TaxRegion,
+		ShippingCost:   order.ShippingCost,
+		TotalAmount:    order.TotalAmount,
+		RefundedAmount: order.RefundedAmount,
+		Version:        order.Version,
+		Products:       []utils.Product{},
+		Discounts:      discounts,
+		TaxLines:       taxLines,
+		Shipments:      shipments,
+		StatusHistory:  history,
+
+		ShippingAddress: address,
 	}
 
 	for _, product := range productsIds {
@@ -153,36 +255,68 @@ func (s *Service) GetOrdersByUserId(id string, is_admin bool, user_id int) ([]mo
 	return orders, nil
 }
 
-func (s *Service) UpdateOrderStatus(id string, status string) error {
+// ListOrders returns one page of the orders matching the query, for admins.
+func (s *Service) ListOrders(query models.OrderQuery) (models.OrderPage, error) {
+	orders, total, err := s.PSQLRepo.GetOrderList(query)
+	if err != nil {
+		log.Println(err)
+		return models.OrderPage{}, err
+	}
+
+	page := models.OrderPage{
+		Orders: orders,
+		Total:  total,
+	}
+	if len(orders) > query.Limit {
+		page.Orders = orders[:query.Limit]
+		page.NextCursor = query.CursorAfter(page.Orders[query.Limit-1])
+	}
+	return page, nil
+}
+
+func (s *Service) UpdateOrderStatus(id string, status string, actorID int) error {
 	o_id, err := strconv.Atoi(id)
 	if err != nil {
 		log.Println(err)
 		return err
 	}
 
-	if status != "processing" && status != "delivered" && status != "cancelled" {
-		return errors.New("invalid status")
+	order, err := s.PSQLRepo.GetOrder(o_id)
+	if err != nil {
+		log.Println(err)
+		return err
 	}
 
-	if status == "cancelled" {
-		order, err := s.PSQLRepo.GetOrder(o_id)
+	return s.changeOrderStatus(order, status, actorID)
+}
 
-		if order.Status == status {
-			return errors.New("order already cancelled")
-		}
+// changeOrderStatus moves an order to the status an admin chose, if the
+// transition is allowed. A cancelled order gives its stock back in the same
+// transaction.
+func (s *Service) changeOrderStatus(order *models.Order, status string, actorID int) error {
+	err := models.CheckTransition(order.Status, status)
+	if err != nil {
+		return err
+	}
 
+	if status == models.OrderCancelled {
+		err = s.trackStockLevels(order.Products, func(repo *psql.PostgresRepo) error {
+			return repo.CancelOrder(order.ID, order.Status, actorID)
+		})
 		if err != nil {
 			log.Println(err)
 			return err
 		}
-		for _, product := range order.Products {
-			p_id := product.ProductID
-			quantity := product.Quantity
-			s.PSQLRepo.IncreaseProductStock(p_id, quantity)
+		s.invalidateStock(order.Products)
+	} else {
+		err = s.PSQLRepo.PutOrderStatus(order.ID, order.Status, status, actorID)
+		if err != nil {
+			return err
 		}
 	}
 
-	return s.PSQLRepo.PutOrderStatus(o_id, status)
+	s.invalidateOrder(order.ID)
+	return nil
 }
 
 func (s *Service) ListenProductUpdates() error {
@@ -197,13 +331,40 @@ func (s *Service) ListenProductUpdates() error {
 	})
 
 	s.NATSClient.Subscribe("product.updated", func(msg *nats.Msg) {
-		var product utils.Product
+		var product utils.ProductEvent
 		err := json.Unmarshal(msg.Data, &product)
 		if err != nil {
 			log.Println(err)
 			return
 		}
-		log.Printf("Product with id %d updated, data: %v", product.ID, product)
+		log.Printf("Product with id %d updated, data: %v", product.ID, product.Product)
+		if len(product.AddedCategoryIDs) > 0 || len(product.RemovedCategoryIDs) > 0 {
+			log.Printf("Product with id %d categories changed, added: %v, removed: %v", product.ID, product.AddedCategoryIDs, product.RemovedCategoryIDs)
+		}
+	})
+
+	s.NATSClient.Subscribe("product.imported", func(msg *nats.Msg) {
+		var event utils.ProductImportEvent
+		err := json.Unmarshal(msg.Data, &event)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		log.Printf("Products imported, created: %d, updated: %d", len(event.Created), len(event.Updated))
+	})
+
+	s.NATSClient.Subscribe(utils.SubjectIncreased, func(msg *nats.Msg) {
+		var level utils.StockLevel
+		err := json.Unmarshal(msg.Data, &level)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		err = s.FillBackorders(level.ProductID)
+		if err != nil {
+			log.Println(err)
+		}
 	})
 
 	s.NATSClient.Subscribe("product.deleted", func(msg *nats.Msg) {
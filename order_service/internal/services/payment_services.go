@@ -0,0 +1,269 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/order_service/order_utils"
+	"order_processing_system/order_service/order_utils/models"
+	"strconv"
+	"time"
+)
+
+// errRefundNotMade wraps the errors of refunds the provider did not make.
+var errRefundNotMade = errors.New("the payment provider did not refund")
+
+// PayOrder charges the total of an order with the payment provider. The
+// payment is captured right away unless the input asks for an authorization
+// only, and a captured payment moves the order to paid. Asynchronous providers
+// leave the payment pending until their webhook reports the outcome.
+func (s *Service) PayOrder(id string, input *models.PaymentInput, is_admin bool, user_id int) (*models.Payment, error) {
+	order, err := s.getOwnOrder(id, is_admin, user_id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderCreated {
+		return nil, fmt.Errorf("%s orders cannot be paid", order.Status)
+	}
+
+	payment := &models.Payment{
+		OrderID:  order.ID,
+		Provider: s.Payments.Name(),
+		Amount:   order.TotalAmount,
+	}
+	err = s.PSQLRepo.PostPayment(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.Payments.Authorize(order_utils.PaymentRequest{
+		OrderID:       order.ID,
+		Amount:        payment.Amount,
+		PaymentMethod: input.PaymentMethod,
+		Capture:       input.Capture == nil || *input.Capture,
+	})
+	if err != nil {
+		result = order_utils.PaymentResult{Status: models.PaymentFailed, Message: err.Error()}
+	}
+
+	err = s.applyPaymentResult(payment, result)
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (s *Service) GetOrderPayments(id string, is_admin bool, user_id int) ([]models.Payment, error) {
+	order, err := s.getOwnOrder(id, is_admin, user_id)
+	if err != nil {
+		return nil, err
+	}
+	return s.PSQLRepo.GetOrderPayments(order.ID)
+}
+
+func (s *Service) CapturePayment(id string, paymentID string) (*models.Payment, error) {
+	payment, err := s.getPayment(id, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != models.PaymentAuthorized {
+		return nil, fmt.Errorf("%s payments cannot be captured", payment.Status)
+	}
+
+	result, err := s.Payments.Capture(*payment.Reference, payment.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, s.applyPaymentResult(payment, result)
+}
+
+func (s *Service) VoidPayment(id string, paymentID string) (*models.Payment, error) {
+	payment, err := s.getPayment(id, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != models.PaymentAuthorized {
+		return nil, fmt.Errorf("%s payments cannot be voided", payment.Status)
+	}
+
+	result, err := s.Payments.Void(*payment.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, s.applyPaymentResult(payment, result)
+}
+
+// RefundPayment gives back part of a captured payment, or all of what is left
+// of it when no amount is given.
+func (s *Service) RefundPayment(id string, paymentID string, input *models.RefundInput) (*models.Payment, error) {
+	payment, err := s.getPayment(id, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != models.PaymentCaptured {
+		return nil, fmt.Errorf("%s payments cannot be refunded", payment.Status)
+	}
+
+	amount := payment.Refundable()
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+
+	_, err = s.refund(payment, amount)
+	return payment, err
+}
+
+// refund gives back an amount of a captured payment and reports whether the
+// provider completed it. The order keeps track of what its payments refunded.
+func (s *Service) refund(payment *models.Payment, amount float64) (bool, error) {
+	if amount <= 0 || amount > payment.Refundable() {
+		return false, fmt.Errorf("refund amount must be between 0 and %.2f", payment.Refundable())
+	}
+
+	result, err := s.Payments.Refund(*payment.Reference, amount)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", errRefundNotMade, err)
+	}
+	if result.Status == models.PaymentPending {
+		return false, nil
+	}
+
+	from := payment.Status
+	payment.RefundedAmount += amount
+	payment.Status = result.Status
+	_, err = s.PSQLRepo.PutPayment(payment, from)
+	if err != nil {
+		return false, err
+	}
+
+	s.invalidateOrder(payment.OrderID)
+
+	// NATS payment refunded
+
+	refundData, err := json.Marshal(map[string]any{
+		"payment": payment,
+		"amount":  amount,
+	})
+	if err != nil {
+		log.Println(err)
+		return true, nil
+	}
+
+	err = s.NATSClient.Publish("payment.refunded", refundData)
+	if err != nil {
+		log.Println(err)
+	}
+	return true, nil
+}
+
+func (s *Service) getPayment(id string, paymentID string) (*models.Payment, error) {
+	order_id, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	payment_id, err := strconv.Atoi(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := s.PSQLRepo.GetPayment(order_id, payment_id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Provider != s.Payments.Name() {
+		return nil, fmt.Errorf("payment %d was made with the %s provider", payment.ID, payment.Provider)
+	}
+	return &payment, nil
+}
+
+// applyPaymentResult saves the provider's answer on the payment. When it
+// captures the payment the order becomes paid.
+func (s *Service) applyPaymentResult(payment *models.Payment, result order_utils.PaymentResult) error {
+	// a pending answer leaves the payment as it is until the webhook
+	if result.Status == models.PaymentPending && payment.Status != models.PaymentPending {
+		return nil
+	}
+
+	from := payment.Status
+	payment.Status = result.Status
+	if result.Reference != "" {
+		payment.Reference = &result.Reference
+	}
+	if result.Status == models.PaymentFailed {
+		payment.FailureReason = &result.Message
+	}
+	if result.Status == models.PaymentCaptured {
+		payment.CapturedAmount = payment.Amount
+	}
+
+	paid, err := s.PSQLRepo.PutPayment(payment, from)
+	if err != nil {
+		return err
+	}
+	if paid {
+		s.announcePaid(payment)
+	}
+	return nil
+}
+
+func (s *Service) announcePaid(payment *models.Payment) {
+	s.invalidateOrder(payment.OrderID)
+
+	// NATS order paid
+
+	paymentData, err := json.Marshal(payment)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = s.NATSClient.Publish("order.paid", paymentData)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// HandlePaymentWebhook applies an event reported by the payment provider.
+// Deliveries are verified against the webhook secret, repeated ones are
+// ignored and late ones never move a payment back.
+func (s *Service) HandlePaymentWebhook(body []byte, signature string) error {
+	if s.WebhookSecret == "" {
+		return errors.New("payment webhooks are not configured")
+	}
+
+	err := order_utils.VerifyWebhook(s.WebhookSecret, body, signature, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var event models.PaymentEvent
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		return err
+	}
+
+	err = event.Validate()
+	if err != nil {
+		return err
+	}
+
+	payment, paid, err := s.PSQLRepo.ApplyPaymentEvent(s.Payments.Name(), event, body)
+	if err != nil {
+		return err
+	}
+	if payment == nil {
+		log.Printf("payment event %s already received", event.ID)
+		return nil
+	}
+
+	if paid {
+		s.announcePaid(payment)
+	} else {
+		s.invalidateOrder(payment.OrderID)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"fmt"
+	"order_processing_system/order_service/order_utils/models"
+	"strconv"
+)
+
+func (s *Service) GetPromotions() ([]models.Promotion, error) {
+	return s.PSQLRepo.GetPromotions()
+}
+
+func (s *Service) GetPromotion(id string) (models.Promotion, error) {
+	promotion_id, err := strconv.Atoi(id)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	promotion, err := s.PSQLRepo.GetPromotionByID(promotion_id)
+	if err != nil {
+		return models.Promotion{}, fmt.Errorf("promotion %d not found", promotion_id)
+	}
+	return promotion, nil
+}
+
+func (s *Service) CreatePromotion(promotion *models.Promotion) error {
+	err := s.validatePromotion(promotion)
+	if err != nil {
+		return err
+	}
+	return s.PSQLRepo.PostPromotion(promotion)
+}
+
+func (s *Service) UpdatePromotion(id string, update models.PromotionUpdate) (models.Promotion, error) {
+	promotion_id, err := strconv.Atoi(id)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	update.ID = promotion_id
+	err = s.validatePromotion(&update.Promotion)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+
+	return s.PSQLRepo.PutPromotion(update)
+}
+
+func (s *Service) DeactivatePromotion(id string) error {
+	promotion_id, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.PSQLRepo.DeactivatePromotion(promotion_id)
+}
+
+func (s *Service) validatePromotion(promotion *models.Promotion) error {
+	err := promotion.Validate()
+	if err != nil {
+		return err
+	}
+
+	if promotion.ProductID != nil {
+		_, err = s.PSQLRepo.GetProductByID(*promotion.ProductID)
+		if err != nil {
+			return fmt.Errorf("product %d not found", *promotion.ProductID)
+		}
+	}
+	return nil
+}
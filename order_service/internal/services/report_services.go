@@ -0,0 +1,27 @@
+package services
+
+import (
+	"log"
+	"order_processing_system/order_service/order_utils"
+	"order_processing_system/order_service/order_utils/models"
+)
+
+// ExportOrders streams the orders matching the filters of the query to the
+// exporter, oldest first.
+func (s *Service) ExportOrders(query models.OrderQuery, exporter order_utils.OrderExporter) error {
+	err := s.PSQLRepo.ExportOrders(query, exporter.Write)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	return exporter.Flush()
+}
+
+func (s *Service) GetSalesReport(query models.SalesQuery) (*models.SalesReport, error) {
+	report, err := s.PSQLRepo.GetSalesReport(query)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/order_service/order_utils"
+	"order_processing_system/order_service/order_utils/models"
+	"strconv"
+	"time"
+)
+
+func (s *Service) ReserveStock(input *models.ReservationInput, user_id int) (*models.Reservation, error) {
+	err := order_utils.Validate(&models.OrderInput{Products: input.Products}, s.PSQLRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation := &models.Reservation{
+		UserID:    user_id,
+		ExpiresAt: time.Now().Add(s.ReservationTTL),
+		Products:  input.Products,
+	}
+
+	err = s.PSQLRepo.PostReservation(reservation)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateStock(reservation.Products)
+	return reservation, nil
+}
+
+func (s *Service) GetReservation(id string, is_admin bool, user_id int) (*models.Reservation, error) {
+	r_id, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.PSQLRepo.GetReservation(r_id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !is_admin && reservation.UserID != user_id {
+		return nil, errors.New("forbidden access to another user's reservation")
+	}
+	return reservation, nil
+}
+
+func (s *Service) ReleaseReservation(id string, is_admin bool, user_id int) error {
+	reservation, err := s.GetReservation(id, is_admin, user_id)
+	if err != nil {
+		return err
+	}
+
+	err = s.PSQLRepo.ReleaseReservation(reservation.ID)
+	if err != nil {
+		return err
+	}
+
+	s.invalidateStock(reservation.Products)
+	return nil
+}
+
+// ConfirmReservation places the order for a reservation. The reserved stock
+// is only decremented now, in the same transaction that creates the order.
+func (s *Service) ConfirmReservation(id string, input *models.ReservationConfirm, user_id int) (*models.Order, error) {
+	reservation, err := s.GetReservation(id, false, user_id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reservation.Confirmable(time.Now()) {
+		return nil, fmt.Errorf("reservation %d has expired or is no longer active", reservation.ID)
+	}
+
+	orderData := &models.OrderInput{
+		Products:        reservation.Products,
+		Location:        input.Location,
+		ShippingAddress: input.ShippingAddress,
+		AddressID:       input.AddressID,
+	}
+	order, err := s.placeOrder(orderData, user_id, &reservation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateStock(reservation.Products)
+	return order, nil
+}
+
+// SweepReservations periodically expires reservations past their TTL so their
+// stock becomes available again, and announces each one on NATS.
+func (s *Service) SweepReservations(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		expired, err := s.PSQLRepo.ExpireReservations()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		for _, reservation := range expired {
+			s.invalidateStock(reservation.Products)
+
+			// NATS reservation expired
+
+			reservationData, err := json.Marshal(reservation)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			err = s.NATSClient.Publish("reservation.expired", reservationData)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+// invalidateStock drops the cached stock levels of the product service, which
+// include the reserved and available quantities, and its cached product
+// lists, which show the stock.
+func (s *Service) invalidateStock(lines []models.OrderProduct) {
+	for _, line := range lines {
+		s.RedisRepo.Delete(fmt.Sprintf("product_stock_%d", line.ProductID))
+	}
+	s.RedisRepo.InvalidateCatalog()
+}
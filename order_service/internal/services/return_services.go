@@ -0,0 +1,251 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils"
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/product_service/utils"
+	"strconv"
+)
+
+// RequestReturn asks to send back items of an order, each with its reason.
+func (s *Service) RequestReturn(id string, input *models.ReturnInput, is_admin bool, user_id int) (*models.Return, error) {
+	order, err := s.getOwnOrder(id, is_admin, user_id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = input.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.Return{
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		Items:   input.Items,
+	}
+	err = s.PSQLRepo.PostReturn(ret)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishReturn("order.return_requested", ret)
+	return ret, nil
+}
+
+func (s *Service) GetOrderReturns(id string, is_admin bool, user_id int) ([]models.Return, error) {
+	order, err := s.getOwnOrder(id, is_admin, user_id)
+	if err != nil {
+		return nil, err
+	}
+	return s.PSQLRepo.GetOrderReturns(order.ID)
+}
+
+func (s *Service) ApproveReturn(id string, returnID string, decision *models.ReturnDecision) (*models.Return, error) {
+	return s.decideReturn(id, returnID, models.ReturnApproved, decision)
+}
+
+func (s *Service) RejectReturn(id string, returnID string, decision *models.ReturnDecision) (*models.Return, error) {
+	return s.decideReturn(id, returnID, models.ReturnRejected, decision)
+}
+
+// ReceiveReturn records the goods of an approved return as received and puts
+// them back in stock in the same transaction, unless the receipt says
+// otherwise.
+func (s *Service) ReceiveReturn(id string, returnID string, receipt *models.ReturnReceipt, actorID int) (*models.Return, error) {
+	ret, err := s.getReturn(id, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != models.ReturnApproved {
+		return nil, fmt.Errorf("%s returns cannot be received", ret.Status)
+	}
+
+	ret.Status = models.ReturnReceived
+	ret.Restocked = receipt.Restock == nil || *receipt.Restock
+
+	lines := ret.Lines()
+	movement := utils.NewMovement(utils.ReasonReturn, strconv.Itoa(ret.OrderID), actorID)
+	err = s.trackStockLevels(lines, func(repo *psql.PostgresRepo) error {
+		return repo.ReceiveReturn(ret, movement)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ret.Restocked {
+		s.invalidateStock(lines)
+	}
+
+	s.publishReturn("order.return_received", ret)
+	return ret, nil
+}
+
+// RefundReturn refunds a received return through the order's payment. The
+// amount defaults to the value of the returned items. The return is
+// refund_pending while the provider is called, and stays so until the
+// provider completes the refund.
+func (s *Service) RefundReturn(id string, returnID string, input *models.RefundInput) (*models.Return, error) {
+	ret, err := s.getReturn(id, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != models.ReturnReceived {
+		return nil, fmt.Errorf("%s returns cannot be refunded", ret.Status)
+	}
+
+	order, err := s.PSQLRepo.GetOrder(ret.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.PSQLRepo.GetOrderPayments(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payment *models.Payment
+	for i := range payments {
+		if payments[i].Status == models.PaymentCaptured {
+			payment = &payments[i]
+		}
+	}
+	if payment == nil {
+		return nil, errors.New("the order has no captured payment to refund")
+	}
+	if payment.Provider != s.Payments.Name() {
+		return nil, fmt.Errorf("payment %d was made with the %s provider", payment.ID, payment.Provider)
+	}
+
+	amount := 0.0
+	if input.Amount != nil {
+		amount = *input.Amount
+	} else {
+		amount, err = order_utils.ReturnValue(order, *ret)
+		if err != nil {
+			return nil, err
+		}
+		amount = min(amount, payment.Refundable())
+	}
+
+	if amount <= 0 || amount > payment.Refundable() {
+		return nil, fmt.Errorf("refund amount must be between 0 and %.2f", payment.Refundable())
+	}
+
+	// the return leaves received before the provider is called, so that
+	// concurrent refunds of it cannot both pay out
+	ret.Status = models.ReturnRefundPending
+	ret.RefundAmount = amount
+	err = s.PSQLRepo.PutReturn(ret, models.ReturnReceived)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := s.refund(payment, amount)
+	if errors.Is(err, errRefundNotMade) {
+		ret.Status = models.ReturnReceived
+		ret.RefundAmount = 0
+		putErr := s.PSQLRepo.PutReturn(ret, models.ReturnRefundPending)
+		if putErr != nil {
+			log.Println(putErr)
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !completed {
+		// the refund webhook settles the return
+		return ret, nil
+	}
+
+	// a completed refund settles the pending returns it covers, this one
+	// included unless earlier ones are still pending
+	ret, err = s.getReturn(id, returnID)
+	if err != nil || ret.Status != models.ReturnRefundPending {
+		return ret, err
+	}
+
+	ret.Status = models.ReturnRefunded
+	err = s.PSQLRepo.PutReturn(ret, models.ReturnRefundPending)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Service) decideReturn(id string, returnID string, status string, decision *models.ReturnDecision) (*models.Return, error) {
+	ret, err := s.getReturn(id, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != models.ReturnRequested {
+		return nil, fmt.Errorf("the return is already %s", ret.Status)
+	}
+
+	ret.Status = status
+	if decision.Note != "" {
+		ret.Note = &decision.Note
+	}
+	err = s.PSQLRepo.PutReturn(ret, models.ReturnRequested)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishReturn("order.return_"+status, ret)
+	return ret, nil
+}
+
+func (s *Service) getReturn(id string, returnID string) (*models.Return, error) {
+	order_id, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return_id, err := strconv.Atoi(returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := s.PSQLRepo.GetReturn(order_id, return_id)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// getOwnOrder loads an order of the user, or any order for admins.
+func (s *Service) getOwnOrder(id string, is_admin bool, user_id int) (*models.Order, error) {
+	order_id, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.PSQLRepo.GetOrder(order_id)
+	if err != nil {
+		return nil, err
+	}
+	if !is_admin && order.UserID != user_id {
+		return nil, errors.New("forbidden access to another user's order")
+	}
+	return order, nil
+}
+
+func (s *Service) publishReturn(subject string, ret *models.Return) {
+	// NATS return status
+
+	returnData, err := json.Marshal(ret)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = s.NATSClient.Publish(subject, returnData)
+	if err != nil {
+		log.Println(err)
+	}
+}
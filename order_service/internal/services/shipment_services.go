@@ -0,0 +1,97 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"order_processing_system/order_service/order_utils/models"
+	"strconv"
+	"strings"
+)
+
+// ShipOrder records a shipment for an order. The order moves to shipped once
+// everything it contains has been shipped, and to partially_shipped before.
+func (s *Service) ShipOrder(id string, input *models.ShipmentInput) (*models.Shipment, error) {
+	order_id, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	carrier := strings.TrimSpace(input.Carrier)
+	trackingNumber := strings.TrimSpace(input.TrackingNumber)
+	if carrier == "" || trackingNumber == "" {
+		return nil, errors.New("carrier and tracking number are required")
+	}
+	for _, item := range input.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("product %d: quantity must be positive", item.ProductID)
+		}
+	}
+
+	shipment := &models.Shipment{
+		OrderID:        order_id,
+		Carrier:        carrier,
+		TrackingNumber: trackingNumber,
+		Items:          input.Items,
+	}
+
+	orderStatus, err := s.PSQLRepo.PostShipment(shipment)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateOrder(order_id)
+
+	// NATS order shipped
+
+	shipmentData, err := json.Marshal(map[string]any{
+		"order_status": orderStatus,
+		"shipment":     shipment,
+	})
+	if err != nil {
+		log.Println(err)
+		return shipment, nil
+	}
+
+	err = s.NATSClient.Publish("order.shipped", shipmentData)
+	if err != nil {
+		log.Println(err)
+	}
+	return shipment, nil
+}
+
+// UpdateShipmentStatus moves a shipment forward, from shipped to in_transit
+// to delivered.
+func (s *Service) UpdateShipmentStatus(id string, shipmentID string, status string) error {
+	order_id, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+
+	shipment_id, err := strconv.Atoi(shipmentID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.PSQLRepo.PutShipmentStatus(order_id, shipment_id, status)
+	if err != nil {
+		return err
+	}
+
+	s.invalidateOrder(order_id)
+	return nil
+}
+
+// invalidateOrder drops the cached order and the cached order list of its
+// user.
+func (s *Service) invalidateOrder(orderID int) {
+	s.RedisRepo.Delete(fmt.Sprintf("order_%d", orderID))
+
+	order, err := s.PSQLRepo.GetOrder(orderID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	s.RedisRepo.Delete(fmt.Sprintf("user_%d_orders", order.UserID))
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/product_service/utils"
+)
+
+// trackStockLevels runs a stock change over the products of the lines and
+// publishes the low-stock, out-of-stock and restock events it caused.
+func (s *Service) trackStockLevels(lines []models.OrderProduct, change func(repo *psql.PostgresRepo) error) error {
+	productIDs := make([]int, len(lines))
+	for i, line := range lines {
+		productIDs[i] = line.ProductID
+	}
+
+	changes, err := s.PSQLRepo.WithStockLevels(productIDs, change)
+
+	// NATS stock level events
+
+	utils.PublishStockLevels(s.NATSClient.Publish, changes)
+	return err
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"order_processing_system/order_service/order_utils/models"
+	"strconv"
+)
+
+func (s *Service) GetTaxRates() ([]models.TaxRate, error) {
+	return s.PSQLRepo.GetTaxRateList()
+}
+
+func (s *Service) CreateTaxRate(rate *models.TaxRate) error {
+	err := rate.Validate()
+	if err != nil {
+		return err
+	}
+	return s.PSQLRepo.PostTaxRate(rate)
+}
+
+// UpdateTaxRate changes a rate for future orders, placed orders keep the tax
+// lines they were charged.
+func (s *Service) UpdateTaxRate(id string, rate models.TaxRate) (models.TaxRate, error) {
+	rate_id, err := strconv.Atoi(id)
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+
+	rate.ID = rate_id
+	err = rate.Validate()
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+
+	return s.PSQLRepo.PutTaxRate(rate)
+}
+
+func (s *Service) DeleteTaxRate(id string) error {
+	rate_id, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.PSQLRepo.DeleteTaxRate(rate_id)
+}
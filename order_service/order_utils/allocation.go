@@ -0,0 +1,212 @@
+package order_utils
+
+import (
+	"fmt"
+	"math"
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/product_service/utils"
+	"sort"
+)
+
+type Demand struct {
+	ProductID int
+	Quantity  int
+}
+
+// AllocationStrategy decides which warehouses fulfil the product lines of an
+// order. stock holds the per-warehouse levels of every demanded product.
+type AllocationStrategy interface {
+	Allocate(demand []Demand, stock map[int][]utils.WarehouseStock, location *models.Location) ([]models.Allocation, error)
+}
+
+type NearestStrategy struct{}
+
+type MostStockStrategy struct{}
+
+type SingleShipmentStrategy struct{}
+
+func NewAllocationStrategy(name string) (AllocationStrategy, error) {
+	switch name {
+	case "nearest":
+		return NearestStrategy{}, nil
+	case "most_stock":
+		return MostStockStrategy{}, nil
+	case "single_shipment", "":
+		return SingleShipmentStrategy{}, nil
+	}
+	return nil, fmt.Errorf("unknown allocation strategy %q", name)
+}
+
+func AllocateOrder(order *models.Order, location *models.Location, strategy AllocationStrategy, p *psql.PostgresRepo) error {
+	var demand []Demand
+	index := map[int]int{}
+	for _, line := range order.Products {
+		if line.VariantID != nil || line.Fulfilled() == 0 {
+			continue
+		}
+		if i, ok := index[line.ProductID]; ok {
+			demand[i].Quantity += line.Fulfilled()
+			continue
+		}
+		index[line.ProductID] = len(demand)
+		demand = append(demand, Demand{ProductID: line.ProductID, Quantity: line.Fulfilled()})
+	}
+
+	stock := map[int][]utils.WarehouseStock{}
+	for _, d := range demand {
+		levels, err := p.GetWarehouseStock(d.ProductID)
+		if err != nil {
+			return err
+		}
+		stock[d.ProductID] = levels
+	}
+
+	allocations, err := strategy.Allocate(demand, stock, location)
+	if err != nil {
+		return err
+	}
+	order.Allocations = allocations
+	return nil
+}
+
+func (NearestStrategy) Allocate(demand []Demand, stock map[int][]utils.WarehouseStock, location *models.Location) ([]models.Allocation, error) {
+	var allocations []models.Allocation
+	for _, d := range demand {
+		lines, err := fill(d, byDistance(stock[d.ProductID], location))
+		if err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, lines...)
+	}
+	return allocations, nil
+}
+
+func (MostStockStrategy) Allocate(demand []Demand, stock map[int][]utils.WarehouseStock, location *models.Location) ([]models.Allocation, error) {
+	var allocations []models.Allocation
+	for _, d := range demand {
+		levels := append([]utils.WarehouseStock{}, stock[d.ProductID]...)
+		sort.SliceStable(levels, func(i, j int) bool {
+			return levels[i].Quantity > levels[j].Quantity
+		})
+
+		lines, err := fill(d, levels)
+		if err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, lines...)
+	}
+	return allocations, nil
+}
+
+// Allocate ships everything from one warehouse when any single warehouse
+// holds enough of every product, choosing the nearest such warehouse.
+// Otherwise it splits the order while reusing already chosen warehouses
+// first, to keep the number of shipments low.
+func (SingleShipmentStrategy) Allocate(demand []Demand, stock map[int][]utils.WarehouseStock, location *models.Location) ([]models.Allocation, error) {
+	if len(demand) == 0 {
+		return nil, nil
+	}
+
+	for _, candidate := range byDistance(stock[demand[0].ProductID], location) {
+		if canFulfil(candidate.WarehouseID, demand, stock) {
+			var allocations []models.Allocation
+			for _, d := range demand {
+				allocations = append(allocations, models.Allocation{
+					ProductID:   d.ProductID,
+					WarehouseID: candidate.WarehouseID,
+					Quantity:    d.Quantity,
+				})
+			}
+			return allocations, nil
+		}
+	}
+
+	used := map[int]bool{}
+	var allocations []models.Allocation
+	for _, d := range demand {
+		levels := byDistance(stock[d.ProductID], location)
+		sort.SliceStable(levels, func(i, j int) bool {
+			return used[levels[i].WarehouseID] && !used[levels[j].WarehouseID]
+		})
+
+		lines, err := fill(d, levels)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			used[line.WarehouseID] = true
+		}
+		allocations = append(allocations, lines...)
+	}
+	return allocations, nil
+}
+
+func canFulfil(warehouseID int, demand []Demand, stock map[int][]utils.WarehouseStock) bool {
+	for _, d := range demand {
+		enough := false
+		for _, level := range stock[d.ProductID] {
+			if level.WarehouseID == warehouseID && level.Quantity >= d.Quantity {
+				enough = true
+				break
+			}
+		}
+		if !enough {
+			return false
+		}
+	}
+	return true
+}
+
+func fill(d Demand, levels []utils.WarehouseStock) ([]models.Allocation, error) {
+	var allocations []models.Allocation
+	remaining := d.Quantity
+	for _, level := range levels {
+		if remaining == 0 {
+			break
+		}
+		if level.Quantity <= 0 {
+			continue
+		}
+		quantity := min(level.Quantity, remaining)
+		allocations = append(allocations, models.Allocation{
+			ProductID:   d.ProductID,
+			WarehouseID: level.WarehouseID,
+			Quantity:    quantity,
+		})
+		remaining -= quantity
+	}
+
+	if remaining > 0 {
+		return nil, fmt.Errorf("not enough stock for product %d, available stock: %d", d.ProductID, d.Quantity-remaining)
+	}
+	return allocations, nil
+}
+
+// byDistance orders warehouses by distance to location, or by warehouse
+// priority when the order has no location.
+func byDistance(levels []utils.WarehouseStock, location *models.Location) []utils.WarehouseStock {
+	sorted := append([]utils.WarehouseStock{}, levels...)
+	if location == nil {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Priority < sorted[j].Priority
+		})
+		return sorted
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return distance(location, sorted[i]) < distance(location, sorted[j])
+	})
+	return sorted
+}
+
+func distance(location *models.Location, level utils.WarehouseStock) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(level.Latitude - location.Latitude)
+	dLon := toRad(level.Longitude - location.Longitude)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(location.Latitude))*math.Cos(toRad(level.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,102 @@
+package order_utils
+
+import (
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/product_service/utils"
+	"reflect"
+	"testing"
+)
+
+func TestAllocate(t *testing.T) {
+	berlin := utils.WarehouseStock{WarehouseID: 1, Latitude: 52.52, Longitude: 13.40, Priority: 2}
+	paris := utils.WarehouseStock{WarehouseID: 2, Latitude: 48.85, Longitude: 2.35, Priority: 1}
+	madrid := utils.WarehouseStock{WarehouseID: 3, Latitude: 40.42, Longitude: -3.70, Priority: 3}
+	level := func(w utils.WarehouseStock, quantity int) utils.WarehouseStock {
+		w.Quantity = quantity
+		return w
+	}
+	potsdam := &models.Location{Latitude: 52.39, Longitude: 13.06}
+
+	tests := []struct {
+		name     string
+		strategy string
+		demand   []Demand
+		stock    map[int][]utils.WarehouseStock
+		location *models.Location
+		want     []models.Allocation
+		wantErr  bool
+	}{
+		{
+			name:     "nearest first",
+			strategy: "nearest",
+			demand:   []Demand{{ProductID: 10, Quantity: 4}},
+			stock:    map[int][]utils.WarehouseStock{10: {level(madrid, 10), level(paris, 5), level(berlin, 2)}},
+			location: potsdam,
+			want:     []models.Allocation{{ProductID: 10, WarehouseID: 1, Quantity: 2}, {ProductID: 10, WarehouseID: 2, Quantity: 2}},
+		},
+		{
+			name:     "priority without a location",
+			strategy: "nearest",
+			demand:   []Demand{{ProductID: 10, Quantity: 4}},
+			stock:    map[int][]utils.WarehouseStock{10: {level(madrid, 10), level(paris, 5), level(berlin, 2)}},
+			want:     []models.Allocation{{ProductID: 10, WarehouseID: 2, Quantity: 4}},
+		},
+		{
+			name:     "most stock first",
+			strategy: "most_stock",
+			demand:   []Demand{{ProductID: 10, Quantity: 12}},
+			stock:    map[int][]utils.WarehouseStock{10: {level(berlin, 2), level(paris, 5), level(madrid, 10)}},
+			location: potsdam,
+			want:     []models.Allocation{{ProductID: 10, WarehouseID: 3, Quantity: 10}, {ProductID: 10, WarehouseID: 2, Quantity: 2}},
+		},
+		{
+			name:     "single shipment from the nearest warehouse holding everything",
+			strategy: "single_shipment",
+			demand:   []Demand{{ProductID: 10, Quantity: 3}, {ProductID: 20, Quantity: 2}},
+			stock: map[int][]utils.WarehouseStock{
+				10: {level(berlin, 5), level(paris, 5), level(madrid, 10)},
+				20: {level(berlin, 0), level(paris, 3), level(madrid, 3)},
+			},
+			location: potsdam,
+			want:     []models.Allocation{{ProductID: 10, WarehouseID: 2, Quantity: 3}, {ProductID: 20, WarehouseID: 2, Quantity: 2}},
+		},
+		{
+			name:     "split shipments reuse chosen warehouses",
+			strategy: "single_shipment",
+			demand:   []Demand{{ProductID: 10, Quantity: 11}, {ProductID: 20, Quantity: 2}},
+			stock: map[int][]utils.WarehouseStock{
+				10: {level(berlin, 2), level(paris, 0), level(madrid, 10)},
+				20: {level(berlin, 0), level(paris, 3), level(madrid, 3)},
+			},
+			location: potsdam,
+			want: []models.Allocation{
+				{ProductID: 10, WarehouseID: 1, Quantity: 2},
+				{ProductID: 10, WarehouseID: 3, Quantity: 9},
+				{ProductID: 20, WarehouseID: 3, Quantity: 2},
+			},
+		},
+		{
+			name:     "not enough stock",
+			strategy: "nearest",
+			demand:   []Demand{{ProductID: 10, Quantity: 20}},
+			stock:    map[int][]utils.WarehouseStock{10: {level(berlin, 2), level(paris, 5), level(madrid, 10)}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := NewAllocationStrategy(tt.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := strategy.Allocate(tt.demand, tt.stock, tt.location)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Allocate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
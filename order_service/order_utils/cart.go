@@ -0,0 +1,49 @@
+package order_utils
+
+import (
+	"fmt"
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils/models"
+)
+
+// RevalidateCart prices the cart items at the current prices and checks them
+// against the available stock. Lines that can no longer be ordered make the
+// cart invalid; a changed price is only pointed out.
+func RevalidateCart(items []models.CartItem, p *psql.PostgresRepo) models.Cart {
+	cart := models.Cart{Lines: []models.CartLine{}, Valid: true}
+
+	for _, item := range items {
+		line := models.CartLine{CartItem: item}
+		orderLine := models.OrderProduct{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity}
+
+		product, price, err := LinePrice(orderLine, p)
+		if err != nil {
+			line.Issues = append(line.Issues, "product is no longer available")
+			cart.Lines = append(cart.Lines, line)
+			cart.Valid = false
+			continue
+		}
+
+		line.Name = product.Name
+		line.UnitPrice = price
+		line.LineTotal = price * float64(item.Quantity)
+		if price != item.AddedPrice {
+			line.Issues = append(line.Issues, fmt.Sprintf("price changed from %.2f to %.2f", item.AddedPrice, price))
+		}
+
+		available, err := GetAvailableLineAmount(orderLine, p)
+		if err != nil {
+			line.Issues = append(line.Issues, err.Error())
+			cart.Valid = false
+		}
+		line.Available = max(available, 0)
+		if err == nil && item.Quantity > available && !product.AcceptsBackorders() {
+			line.Issues = append(line.Issues, fmt.Sprintf("only %d available", line.Available))
+			cart.Valid = false
+		}
+
+		cart.Total += line.LineTotal
+		cart.Lines = append(cart.Lines, line)
+	}
+	return cart
+}
@@ -0,0 +1,180 @@
+package order_utils
+
+import (
+	"fmt"
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/user_service/user_utils"
+	"sort"
+)
+
+// EditLines replaces the lines of an order with the edited ones and returns
+// what changed per product and variant. Increases are taken from the available
+// stock, or backordered when the product accepts backorders. Decreases shorten
+// the backordered part first and give the rest back to stock.
+func EditLines(o *models.Order, products []models.OrderProduct, p *psql.PostgresRepo) ([]models.LineChange, error) {
+	type key struct{ product, variant int }
+	variant := func(id *int) int {
+		if id == nil {
+			return 0
+		}
+		return *id
+	}
+	keyOf := func(line models.OrderProduct) key {
+		return key{line.ProductID, variant(line.VariantID)}
+	}
+
+	previous := map[key]models.OrderProduct{}
+	for _, line := range o.Products {
+		merged := previous[keyOf(line)]
+		merged.ProductID = line.ProductID
+		merged.VariantID = line.VariantID
+		merged.Quantity += line.Quantity
+		merged.Backordered += line.Backordered
+		previous[keyOf(line)] = merged
+	}
+
+	var lines []models.OrderProduct
+	index := map[key]int{}
+	for _, line := range products {
+		if i, ok := index[keyOf(line)]; ok {
+			lines[i].Quantity += line.Quantity
+			continue
+		}
+		index[keyOf(line)] = len(lines)
+		lines = append(lines, models.OrderProduct{ProductID: line.ProductID, VariantID: line.VariantID, Quantity: line.Quantity})
+	}
+
+	var changes []models.LineChange
+	for i, line := range lines {
+		before := previous[keyOf(line)]
+		lines[i].Backordered = before.Backordered
+
+		change := models.LineChange{ProductID: line.ProductID, VariantID: line.VariantID, From: before.Quantity, To: line.Quantity}
+		switch delta := line.Quantity - before.Quantity; {
+		case delta > 0:
+			available, err := GetAvailableLineAmount(line, p)
+			if err != nil {
+				return nil, err
+			}
+			take := min(delta, max(available, 0))
+			if take < delta {
+				product, err := p.GetProductByID(line.ProductID)
+				if err != nil {
+					return nil, err
+				}
+				if !product.AcceptsBackorders() {
+					return nil, fmt.Errorf("not enough stock for product %d, available stock: %d", line.ProductID, max(available, 0))
+				}
+				lines[i].Backordered += delta - take
+			}
+			change.Stock = take
+		case delta < 0:
+			shortened := min(-delta, before.Backordered)
+			lines[i].Backordered -= shortened
+			change.Stock = delta + shortened
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	for k, before := range previous {
+		if _, ok := index[k]; ok {
+			continue
+		}
+		changes = append(changes, models.LineChange{ProductID: before.ProductID, VariantID: before.VariantID, From: before.Quantity, Stock: -before.Fulfilled()})
+	}
+
+	for i, line := range lines {
+		lines[i].Status = models.LineAllocated
+		if line.Backordered > 0 {
+			lines[i].Status = models.LineBackordered
+		}
+	}
+
+	// stock rows are locked in product and variant order
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].ProductID != changes[j].ProductID {
+			return changes[i].ProductID < changes[j].ProductID
+		}
+		return variant(changes[i].VariantID) < variant(changes[j].VariantID)
+	})
+
+	o.Products = lines
+	return changes, nil
+}
+
+// PriceEdit prices the edited lines of an order at the current prices. The
+// discounts of the order are kept as long as their promotions still apply to
+// the lines, the tax region and shipping address are those of the order. An
+// order without a stored address keeps its shipping cost.
+func PriceEdit(o *models.Order, address *user_utils.PostalAddress, engine TaxEngine, calculator ShippingCalculator, p *psql.PostgresRepo) error {
+	amount, err := CalculateTotalAmount(o, p)
+	if err != nil {
+		return err
+	}
+
+	discounts := o.Discounts
+	o.TotalAmount = amount
+	o.DiscountAmount = 0
+	o.TaxAmount = 0
+	o.Discounts = nil
+	o.TaxLines = nil
+
+	err = reapplyPromotions(o, discounts, p)
+	if err != nil {
+		return err
+	}
+
+	err = TaxOrder(o, o.TaxRegion, engine, p)
+	if err != nil {
+		return err
+	}
+
+	if address == nil {
+		o.TotalAmount = roundAmount(o.TotalAmount + o.ShippingCost)
+		return nil
+	}
+	return ShipOrder(o, *address, calculator, p)
+}
+
+// reapplyPromotions discounts the edited lines with the promotions the order
+// was placed with. Their validity and usage were checked then, a promotion
+// that no longer applies to the lines is dropped.
+func reapplyPromotions(o *models.Order, discounts []models.OrderDiscount, p *psql.PostgresRepo) error {
+	if len(discounts) == 0 {
+		return nil
+	}
+
+	prices, err := linePrices(o.Products, p)
+	if err != nil {
+		return err
+	}
+
+	subtotal := o.TotalAmount
+	discounted := 0.0
+
+	for _, discount := range discounts {
+		promotion, err := p.GetPromotionByID(discount.PromotionID)
+		if err != nil {
+			return err
+		}
+		if subtotal < promotion.MinBasket {
+			continue
+		}
+
+		amount := roundAmount(min(PromotionDiscount(promotion, o.Products, prices), subtotal-discounted))
+		if amount <= 0 {
+			continue
+		}
+		discounted += amount
+
+		discount.Amount = amount
+		o.Discounts = append(o.Discounts, discount)
+	}
+
+	o.DiscountAmount = roundAmount(discounted)
+	o.TotalAmount = roundAmount(subtotal - discounted)
+	return nil
+}
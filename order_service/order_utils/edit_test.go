@@ -0,0 +1,92 @@
+package order_utils
+
+import (
+	"order_processing_system/order_service/order_utils/models"
+	"reflect"
+	"testing"
+)
+
+// The cases only shorten or drop lines, which EditLines settles without
+// reading stock from the repository.
+func TestEditLinesDecreases(t *testing.T) {
+	variant := 7
+
+	tests := []struct {
+		name     string
+		previous []models.OrderProduct
+		edited   []models.OrderProduct
+		changes  []models.LineChange
+		lines    []models.OrderProduct
+	}{
+		{
+			name:     "shortened line gives stock back",
+			previous: []models.OrderProduct{{ProductID: 1, Quantity: 5}},
+			edited:   []models.OrderProduct{{ProductID: 1, Quantity: 3}},
+			changes:  []models.LineChange{{ProductID: 1, From: 5, To: 3, Stock: -2}},
+			lines:    []models.OrderProduct{{ProductID: 1, Quantity: 3, Status: models.LineAllocated}},
+		},
+		{
+			name:     "backordered units go first",
+			previous: []models.OrderProduct{{ProductID: 1, Quantity: 5, Backordered: 3}},
+			edited:   []models.OrderProduct{{ProductID: 1, Quantity: 3}},
+			changes:  []models.LineChange{{ProductID: 1, From: 5, To: 3, Stock: 0}},
+			lines:    []models.OrderProduct{{ProductID: 1, Quantity: 3, Backordered: 1, Status: models.LineBackordered}},
+		},
+		{
+			name:     "stock is given back past the backorder",
+			previous: []models.OrderProduct{{ProductID: 1, Quantity: 5, Backordered: 2}},
+			edited:   []models.OrderProduct{{ProductID: 1, Quantity: 1}},
+			changes:  []models.LineChange{{ProductID: 1, From: 5, To: 1, Stock: -2}},
+			lines:    []models.OrderProduct{{ProductID: 1, Quantity: 1, Status: models.LineAllocated}},
+		},
+		{
+			name: "removed line gives its fulfilled units back",
+			previous: []models.OrderProduct{
+				{ProductID: 1, Quantity: 2},
+				{ProductID: 2, Quantity: 5, Backordered: 2},
+			},
+			edited:  []models.OrderProduct{{ProductID: 1, Quantity: 2}},
+			changes: []models.LineChange{{ProductID: 2, From: 5, Stock: -3}},
+			lines:   []models.OrderProduct{{ProductID: 1, Quantity: 2, Status: models.LineAllocated}},
+		},
+		{
+			name:     "repeated lines are merged",
+			previous: []models.OrderProduct{{ProductID: 1, Quantity: 5}},
+			edited:   []models.OrderProduct{{ProductID: 1, Quantity: 2}, {ProductID: 1, Quantity: 1}},
+			changes:  []models.LineChange{{ProductID: 1, From: 5, To: 3, Stock: -2}},
+			lines:    []models.OrderProduct{{ProductID: 1, Quantity: 3, Status: models.LineAllocated}},
+		},
+		{
+			name: "variants are lines of their own",
+			previous: []models.OrderProduct{
+				{ProductID: 1, VariantID: &variant, Quantity: 4},
+				{ProductID: 1, Quantity: 1},
+			},
+			edited: []models.OrderProduct{
+				{ProductID: 1, Quantity: 1},
+				{ProductID: 1, VariantID: &variant, Quantity: 2},
+			},
+			changes: []models.LineChange{{ProductID: 1, VariantID: &variant, From: 4, To: 2, Stock: -2}},
+			lines: []models.OrderProduct{
+				{ProductID: 1, Quantity: 1, Status: models.LineAllocated},
+				{ProductID: 1, VariantID: &variant, Quantity: 2, Status: models.LineAllocated},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &models.Order{Products: tt.previous}
+			changes, err := EditLines(o, tt.edited, nil)
+			if err != nil {
+				t.Fatalf("EditLines() error = %v", err)
+			}
+			if !reflect.DeepEqual(changes, tt.changes) {
+				t.Errorf("EditLines() changes = %+v, want %+v", changes, tt.changes)
+			}
+			if !reflect.DeepEqual(o.Products, tt.lines) {
+				t.Errorf("EditLines() lines = %+v, want %+v", o.Products, tt.lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package order_utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"order_processing_system/export"
+	"order_processing_system/order_service/order_utils/models"
+	"strconv"
+	"time"
+)
+
+// OrderExporter writes exported orders in one file format as they are read.
+type OrderExporter interface {
+	ContentType() string
+	Extension() string
+	Write(order models.OrderExport) error
+	Flush() error
+}
+
+// CSVExporter writes one row per order line, the order columns are repeated
+// on every line of the order. An order without lines gets one row with empty
+// line columns.
+type CSVExporter struct {
+	w *export.CSVWriter
+}
+
+// NDJSONExporter writes one JSON object per order, with its lines.
+type NDJSONExporter struct {
+	enc *json.Encoder
+}
+
+var csvHeader = []string{
+	"order_id", "user_id", "order_date", "status", "subtotal", "discount_amount", "tax_amount", "tax_region",
+	"shipping_cost", "total_amount", "refunded_amount", "product_id", "product_name", "variant_id", "quantity",
+	"line_status", "backordered_quantity", "unit_price",
+}
+
+func NewOrderExporter(format string, w io.Writer) (OrderExporter, error) {
+	switch format {
+	case "csv", "":
+		return &CSVExporter{w: export.NewCSVWriter(w, csvHeader)}, nil
+	case "ndjson":
+		return &NDJSONExporter{enc: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q, allowed: csv, ndjson", format)
+}
+
+func (e *CSVExporter) ContentType() string {
+	return "text/csv"
+}
+
+func (e *CSVExporter) Extension() string {
+	return "csv"
+}
+
+func (e *CSVExporter) Write(order models.OrderExport) error {
+	columns := []string{
+		strconv.Itoa(order.ID),
+		strconv.Itoa(order.UserID),
+		order.OrderDate.UTC().Format(time.RFC3339),
+		order.Status,
+		formatAmount(order.Subtotal),
+		formatAmount(order.DiscountAmount),
+		formatAmount(order.TaxAmount),
+		order.TaxRegion,
+		formatAmount(order.ShippingCost),
+		formatAmount(order.TotalAmount),
+		formatAmount(order.RefundedAmount),
+	}
+	if len(order.Lines) == 0 {
+		return e.w.Write(append(columns, make([]string, len(csvHeader)-len(columns))...))
+	}
+
+	for _, line := range order.Lines {
+		variantID := ""
+		if line.VariantID != nil {
+			variantID = strconv.Itoa(*line.VariantID)
+		}
+
+		err := e.w.Write(append(columns,
+			strconv.Itoa(line.ProductID),
+			line.ProductName,
+			variantID,
+			strconv.Itoa(line.Quantity),
+			line.Status,
+			strconv.Itoa(line.Backordered),
+			formatAmount(line.UnitPrice),
+		))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *CSVExporter) Flush() error {
+	return e.w.Flush()
+}
+
+func (e *NDJSONExporter) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (e *NDJSONExporter) Extension() string {
+	return "ndjson"
+}
+
+func (e *NDJSONExporter) Write(order models.OrderExport) error {
+	return e.enc.Encode(order)
+}
+
+func (e *NDJSONExporter) Flush() error {
+	return nil
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
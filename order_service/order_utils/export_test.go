@@ -0,0 +1,68 @@
+package order_utils
+
+import (
+	"bytes"
+	"order_processing_system/order_service/order_utils/models"
+	"testing"
+	"time"
+)
+
+func TestCSVExporter(t *testing.T) {
+	variant := 3
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	header := "order_id,user_id,order_date,status,subtotal,discount_amount,tax_amount,tax_region,shipping_cost,total_amount,refunded_amount,product_id,product_name,variant_id,quantity,line_status,backordered_quantity,unit_price\n"
+
+	tests := []struct {
+		name   string
+		orders []models.OrderExport
+		want   string
+	}{
+		{
+			name: "no orders",
+			want: header,
+		},
+		{
+			name: "one row per line",
+			orders: []models.OrderExport{{
+				ID: 1, UserID: 2, OrderDate: date, Status: models.OrderPaid, Subtotal: 30, TotalAmount: 30,
+				Lines: []models.ExportLine{
+					{ProductID: 5, ProductName: "Mug, blue", Quantity: 1, Status: models.LineAllocated, UnitPrice: 10},
+					{ProductID: 6, ProductName: "Shirt", VariantID: &variant, Quantity: 2, Status: models.LineBackordered, Backordered: 2, UnitPrice: 10},
+				},
+			}},
+			want: header +
+				"1,2,2024-03-01T12:00:00Z,paid,30.00,0.00,0.00,,0.00,30.00,0.00,5,\"Mug, blue\",,1,allocated,0,10.00\n" +
+				"1,2,2024-03-01T12:00:00Z,paid,30.00,0.00,0.00,,0.00,30.00,0.00,6,Shirt,3,2,backordered,2,10.00\n",
+		},
+		{
+			name: "order without lines",
+			orders: []models.OrderExport{{
+				ID: 1, UserID: 2, OrderDate: date, Status: models.OrderCancelled, Lines: []models.ExportLine{},
+			}},
+			want: header + "1,2,2024-03-01T12:00:00Z,cancelled,0.00,0.00,0.00,,0.00,0.00,0.00,,,,,,,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			exporter, err := NewOrderExporter("csv", &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, order := range tt.orders {
+				err = exporter.Write(order)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = exporter.Flush()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("export =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package order_utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"order_processing_system/order_service/order_utils/models"
+	"time"
+)
+
+const (
+	fakeGatewayDelay    = time.Second
+	fakeGatewayAttempts = 5
+)
+
+// FakeGateway is an asynchronous gateway for local testing. It decides the
+// outcome of every call like MockProvider, answers pending and reports the
+// outcome with signed webhooks. Like real gateways it delivers them at least
+// once and in no particular order, and retries failed deliveries.
+type FakeGateway struct {
+	mock   *MockProvider
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewFakeGateway(url string, secret string) *FakeGateway {
+	return &FakeGateway{
+		mock:   NewMockProvider(),
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake_gateway"
+}
+
+func (g *FakeGateway) Authorize(request PaymentRequest) (PaymentResult, error) {
+	result, err := g.mock.Authorize(request)
+	if err != nil {
+		return PaymentResult{}, err
+	}
+
+	switch result.Status {
+	case models.PaymentFailed:
+		g.deliver(models.PaymentEvent{Type: models.PaymentEventFailed, Reference: result.Reference, Message: result.Message})
+	case models.PaymentCaptured:
+		g.deliver(
+			models.PaymentEvent{Type: models.PaymentEventAuthorized, Reference: result.Reference},
+			models.PaymentEvent{Type: models.PaymentEventCaptured, Reference: result.Reference, CapturedAmount: request.Amount},
+		)
+	default:
+		g.deliver(models.PaymentEvent{Type: models.PaymentEventAuthorized, Reference: result.Reference})
+	}
+	return PaymentResult{Reference: result.Reference, Status: models.PaymentPending}, nil
+}
+
+func (g *FakeGateway) Capture(reference string, amount float64) (PaymentResult, error) {
+	_, err := g.mock.Capture(reference, amount)
+	if err != nil {
+		return PaymentResult{}, err
+	}
+
+	g.deliver(models.PaymentEvent{Type: models.PaymentEventCaptured, Reference: reference, CapturedAmount: amount})
+	return PaymentResult{Reference: reference, Status: models.PaymentPending}, nil
+}
+
+func (g *FakeGateway) Void(reference string) (PaymentResult, error) {
+	_, err := g.mock.Void(reference)
+	if err != nil {
+		return PaymentResult{}, err
+	}
+
+	g.deliver(models.PaymentEvent{Type: models.PaymentEventVoided, Reference: reference})
+	return PaymentResult{Reference: reference, Status: models.PaymentPending}, nil
+}
+
+func (g *FakeGateway) Refund(reference string, amount float64) (PaymentResult, error) {
+	_, err := g.mock.Refund(reference, amount)
+	if err != nil {
+		return PaymentResult{}, err
+	}
+
+	captured, refunded := g.mock.totals(reference)
+	g.deliver(models.PaymentEvent{Type: models.PaymentEventRefunded, Reference: reference, CapturedAmount: captured, RefundedAmount: refunded})
+	return PaymentResult{Reference: reference, Status: models.PaymentPending}, nil
+}
+
+// deliver sends the events after a delay, shuffled and with one of them sent
+// twice.
+func (g *FakeGateway) deliver(events ...models.PaymentEvent) {
+	now := time.Now()
+	for i := range events {
+		id, err := randomHex(12)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		events[i].ID = "evt_" + id
+		events[i].OccurredAt = now.Add(time.Duration(i) * time.Millisecond)
+	}
+
+	go func() {
+		time.Sleep(fakeGatewayDelay)
+
+		mathrand.Shuffle(len(events), func(i, j int) {
+			events[i], events[j] = events[j], events[i]
+		})
+		events = append(events, events[mathrand.Intn(len(events))])
+
+		for _, event := range events {
+			err := g.send(event)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}()
+}
+
+func (g *FakeGateway) send(event models.PaymentEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	backoff := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err = g.post(body)
+		if err == nil || attempt == fakeGatewayAttempts {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (g *FakeGateway) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(g.secret, body, time.Now()))
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s answered %s", g.url, resp.Status)
+	}
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"order_processing_system/cart"
+	"order_processing_system/user_service/user_utils"
+)
+
+// CartItem is what a cart stores per line, shared with the user service that
+// merges guest carts.
+type CartItem = cart.Item
+
+// CartLine is a cart item revalidated against the current price and stock.
+type CartLine struct {
+	CartItem
+	Name      string   `json:"name"`
+	UnitPrice float64  `json:"unit_price"`
+	LineTotal float64  `json:"line_total"`
+	Available int      `json:"available"`
+	Issues    []string `json:"issues,omitempty"`
+}
+
+type Cart struct {
+	ID     string     `json:"id,omitempty"`
+	UserID int        `json:"user_id,omitempty"`
+	Lines  []CartLine `json:"lines"`
+	Total  float64    `json:"total"`
+	Valid  bool       `json:"valid"`
+}
+
+type CartItemInput struct {
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id,omitempty"`
+	Quantity  int  `json:"quantity"`
+}
+
+type CartCheckout struct {
+	Location *Location `json:"location,omitempty"`
+	Coupons  []string  `json:"coupons,omitempty"`
+
+	ShippingAddress *user_utils.PostalAddress `json:"shipping_address,omitempty"`
+	AddressID       *int                      `json:"address_id,omitempty"`
+}
+
+type CartItemUpdate struct {
+	VariantID *int `json:"variant_id,omitempty"`
+	Quantity  int  `json:"quantity"`
+}
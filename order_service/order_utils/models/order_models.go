@@ -1,37 +1,110 @@
 package models
 
 import (
+	"errors"
 	"order_processing_system/product_service/utils"
+	"order_processing_system/user_service/user_utils"
 	"time"
 )
 
+var ErrOrderNotFound = errors.New("order not found")
+
 type Order struct {
-	ID          int            `db:"id" json:"id"`
-	UserID      int            `db:"user_id" json:"user_id"`
-	OrderDate   time.Time      `db:"order_date" json:"order_date"`
-	Status      string         `db:"status" json:"status"`
-	TotalAmount float64        `db:"total_amount" json:"total_amount"`
-	Products    []OrderProduct `json:"products"`
+	ID             int             `db:"id" json:"id"`
+	UserID         int             `db:"user_id" json:"user_id"`
+	OrderDate      time.Time       `db:"order_date" json:"order_date"`
+	Status         string          `db:"status" json:"status"`
+	Subtotal       float64         `db:"subtotal" json:"subtotal"`
+	DiscountAmount float64         `db:"discount_amount" json:"discount_amount"`
+	TaxAmount      float64         `db:"tax_amount" json:"tax_amount"`
+	TaxRegion      string          `db:"tax_region" json:"tax_region,omitempty"`
+	ShippingCost   float64         `db:"shipping_cost" json:"shipping_cost"`
+	TotalAmount    float64         `db:"total_amount" json:"total_amount"`
+	RefundedAmount float64         `db:"refunded_amount" json:"refunded_amount"`
+	Version        int             `db:"version" json:"version"`
+	Products       []OrderProduct  `json:"products"`
+	Allocations    []Allocation    `json:"allocations,omitempty"`
+	Discounts      []OrderDiscount `json:"discounts,omitempty"`
+	TaxLines       []TaxLine       `json:"tax_lines,omitempty"`
+	// ShippingAddress is the snapshot stored with the order.
+	ShippingAddress *user_utils.PostalAddress `db:"-" json:"shipping_address,omitempty"`
+	// ReservationID is set when the order confirms a stock reservation.
+	ReservationID *int `db:"-" json:"-"`
 }
 
 type OrderDetail struct {
-	ID          int             `db:"id" json:"id"`
-	UserID      int             `db:"user_id" json:"user_id"`
-	OrderDate   time.Time       `db:"order_date" json:"order_date"`
-	Status      string          `db:"status" json:"status"`
-	TotalAmount float64         `db:"total_amount" json:"total_amount"`
-	Products    []utils.Product `json:"products"`
+	ID             int             `db:"id" json:"id"`
+	UserID         int             `db:"user_id" json:"user_id"`
+	OrderDate      time.Time       `db:"order_date" json:"order_date"`
+	Status         string          `db:"status" json:"status"`
+	Subtotal       float64         `db:"subtotal" json:"subtotal"`
+	DiscountAmount float64         `db:"discount_amount" json:"discount_amount"`
+	TaxAmount      float64         `db:"tax_amount" json:"tax_amount"`
+	TaxRegion      string          `db:"tax_region" json:"tax_region,omitempty"`
+	ShippingCost   float64         `db:"shipping_cost" json:"shipping_cost"`
+	TotalAmount    float64         `db:"total_amount" json:"total_amount"`
+	RefundedAmount float64         `db:"refunded_amount" json:"refunded_amount"`
+	Version        int             `db:"version" json:"version"`
+	Products       []utils.Product `json:"products"`
+	Discounts      []OrderDiscount `json:"discounts"`
+	TaxLines       []TaxLine       `json:"tax_lines"`
+	Shipments      []Shipment      `json:"shipments"`
+	StatusHistory  []StatusChange  `json:"status_history"`
+
+	ShippingAddress *user_utils.PostalAddress `json:"shipping_address,omitempty"`
 }
 
 type OrderInput struct {
 	Products []OrderProduct `json:"products"`
+	Location *Location      `json:"location,omitempty"`
+	Coupons  []string       `json:"coupons,omitempty"`
+	// The order ships to ShippingAddress, else to the address book entry
+	// AddressID, else to the user's default address.
+	ShippingAddress *user_utils.PostalAddress `json:"shipping_address,omitempty"`
+	AddressID       *int                      `json:"address_id,omitempty"`
+}
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
 
+const (
+	LineAllocated   = "allocated"
+	LineBackordered = "backordered"
+)
+
 type OrderProduct struct {
-	ProductID int `db:"product_id" json:"product_id"`
-	Quantity  int `db:"quantity" json:"quantity"`
+	ProductID   int    `db:"product_id" json:"product_id"`
+	VariantID   *int   `db:"variant_id" json:"variant_id,omitempty"`
+	Quantity    int    `db:"quantity" json:"quantity"`
+	Status      string `db:"status" json:"status,omitempty"`
+	Backordered int    `db:"backordered_quantity" json:"backordered_quantity,omitempty"`
+	// UnitPrice is the price the line was ordered at.
+	UnitPrice float64 `db:"unit_price" json:"unit_price,omitempty"`
+}
+
+// Fulfilled is the part of the line that has been taken from stock.
+func (l OrderProduct) Fulfilled() int {
+	return l.Quantity - l.Backordered
 }
 
 type StatusUpdate struct {
 	Status string `json:"status"`
 }
+
+type Allocation struct {
+	ProductID   int `db:"product_id" json:"product_id"`
+	WarehouseID int `db:"warehouse_id" json:"warehouse_id"`
+	Quantity    int `db:"quantity" json:"quantity"`
+}
+
+// BackorderFill is stock that was allocated to a waiting backordered line.
+type BackorderFill struct {
+	OrderID   int  `json:"order_id"`
+	UserID    int  `json:"user_id"`
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id,omitempty"`
+	Quantity  int  `json:"quantity"`
+	Remaining int  `json:"remaining"`
+}
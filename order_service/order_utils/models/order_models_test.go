@@ -0,0 +1,23 @@
+package models
+
+import "testing"
+
+func TestOrderProductFulfilled(t *testing.T) {
+	tests := []struct {
+		name string
+		line OrderProduct
+		want int
+	}{
+		{"allocated line", OrderProduct{Quantity: 3, Status: LineAllocated}, 3},
+		{"partly backordered line", OrderProduct{Quantity: 5, Backordered: 2, Status: LineBackordered}, 3},
+		{"fully backordered line", OrderProduct{Quantity: 4, Backordered: 4, Status: LineBackordered}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.line.Fulfilled(); got != tt.want {
+				t.Errorf("Fulfilled() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Order statuses set by payments.
+const (
+	OrderCreated = "created"
+	OrderPaid    = "paid"
+)
+
+const (
+	PaymentPending    = "pending"
+	PaymentAuthorized = "authorized"
+	PaymentCaptured   = "captured"
+	PaymentVoided     = "voided"
+	PaymentRefunded   = "refunded"
+	PaymentFailed     = "failed"
+)
+
+// Webhook event types of payment providers.
+const (
+	PaymentEventAuthorized = "payment.authorized"
+	PaymentEventCaptured   = "payment.captured"
+	PaymentEventFailed     = "payment.failed"
+	PaymentEventVoided     = "payment.voided"
+	PaymentEventRefunded   = "payment.refunded"
+)
+
+var paymentEventStatuses = map[string]string{
+	PaymentEventAuthorized: PaymentAuthorized,
+	PaymentEventCaptured:   PaymentCaptured,
+	PaymentEventFailed:     PaymentFailed,
+	PaymentEventVoided:     PaymentVoided,
+	PaymentEventRefunded:   PaymentRefunded,
+}
+
+// paymentSteps orders the statuses so that late deliveries of older events
+// cannot move a payment back.
+var paymentSteps = map[string]int{
+	PaymentPending:    0,
+	PaymentAuthorized: 1,
+	PaymentCaptured:   2,
+	PaymentVoided:     2,
+	PaymentFailed:     2,
+	PaymentRefunded:   3,
+}
+
+// Payment is a charge of an order with a payment provider. Reference is the
+// provider's id of the charge.
+type Payment struct {
+	ID             int       `db:"id" json:"id"`
+	OrderID        int       `db:"order_id" json:"order_id"`
+	Provider       string    `db:"provider" json:"provider"`
+	Reference      *string   `db:"reference" json:"reference,omitempty"`
+	Status         string    `db:"status" json:"status"`
+	Amount         float64   `db:"amount" json:"amount"`
+	CapturedAmount float64   `db:"captured_amount" json:"captured_amount"`
+	RefundedAmount float64   `db:"refunded_amount" json:"refunded_amount"`
+	FailureReason  *string   `db:"failure_reason" json:"failure_reason,omitempty"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Refundable is the captured amount that has not been refunded yet.
+func (p Payment) Refundable() float64 {
+	return math.Round((p.CapturedAmount-p.RefundedAmount)*100) / 100
+}
+
+type PaymentInput struct {
+	// PaymentMethod is the provider's token for the card or account charged.
+	PaymentMethod string `json:"payment_method"`
+	// Capture takes the money right away, which is the default. Without it
+	// the payment is only authorized and captured later.
+	Capture *bool `json:"capture,omitempty"`
+}
+
+// RefundInput refunds part of a captured payment, or all of what is left
+// without an amount.
+type RefundInput struct {
+	Amount *float64 `json:"amount,omitempty"`
+}
+
+// PaymentEvent is a webhook delivery of a payment provider. Refund events
+// carry the total refunded so far, so that they can arrive in any order.
+type PaymentEvent struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Reference      string    `json:"reference"`
+	CapturedAmount float64   `json:"captured_amount,omitempty"`
+	RefundedAmount float64   `json:"refunded_amount,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+func (e PaymentEvent) Validate() error {
+	if e.ID == "" || e.Reference == "" || e.OccurredAt.IsZero() {
+		return errors.New("event id, reference and occurred_at are required")
+	}
+	if _, ok := paymentEventStatuses[e.Type]; !ok {
+		return fmt.Errorf("unknown event type %q", e.Type)
+	}
+	return nil
+}
+
+// ApplyEvent moves the payment to the state reported by the event and tells
+// whether it changed. Events older than the payment's state are ignored; a
+// refund implies the capture it refunds, even when the capture event has not
+// arrived yet.
+func (p *Payment) ApplyEvent(e PaymentEvent) bool {
+	if e.Type == PaymentEventRefunded {
+		if p.Status == PaymentVoided || p.Status == PaymentFailed {
+			return false
+		}
+
+		changed := false
+		if p.Status == PaymentPending || p.Status == PaymentAuthorized {
+			p.Status = PaymentCaptured
+			p.CapturedAmount = p.Amount
+			if e.CapturedAmount > 0 {
+				p.CapturedAmount = e.CapturedAmount
+			}
+			changed = true
+		}
+		if e.RefundedAmount > p.RefundedAmount {
+			p.RefundedAmount = min(e.RefundedAmount, p.CapturedAmount)
+			changed = true
+		}
+		if p.Status == PaymentCaptured && p.Refundable() <= 0 {
+			p.Status = PaymentRefunded
+		}
+		return changed
+	}
+
+	status := paymentEventStatuses[e.Type]
+	if paymentSteps[status] <= paymentSteps[p.Status] {
+		return false
+	}
+
+	p.Status = status
+	switch status {
+	case PaymentCaptured:
+		p.CapturedAmount = p.Amount
+		if e.CapturedAmount > 0 {
+			p.CapturedAmount = e.CapturedAmount
+		}
+	case PaymentFailed:
+		p.FailureReason = &e.Message
+	}
+	return true
+}
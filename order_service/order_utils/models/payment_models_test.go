@@ -0,0 +1,142 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPaymentApplyEvent(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	event := func(typ string, captured, refunded float64) PaymentEvent {
+		return PaymentEvent{ID: "evt", Type: typ, Reference: "ch_1", CapturedAmount: captured, RefundedAmount: refunded, Message: "declined", OccurredAt: at}
+	}
+
+	tests := []struct {
+		name     string
+		payment  Payment
+		events   []PaymentEvent
+		changed  []bool
+		status   string
+		captured float64
+		refunded float64
+	}{
+		{
+			name:    "in order",
+			payment: Payment{Status: PaymentPending, Amount: 50},
+			events: []PaymentEvent{
+				event(PaymentEventAuthorized, 0, 0),
+				event(PaymentEventCaptured, 0, 0),
+				event(PaymentEventRefunded, 0, 50),
+			},
+			changed:  []bool{true, true, true},
+			status:   PaymentRefunded,
+			captured: 50,
+			refunded: 50,
+		},
+		{
+			name:    "authorization after the capture",
+			payment: Payment{Status: PaymentPending, Amount: 50},
+			events: []PaymentEvent{
+				event(PaymentEventCaptured, 45, 0),
+				event(PaymentEventAuthorized, 0, 0),
+			},
+			changed:  []bool{true, false},
+			status:   PaymentCaptured,
+			captured: 45,
+		},
+		{
+			name:    "duplicate capture",
+			payment: Payment{Status: PaymentAuthorized, Amount: 50},
+			events: []PaymentEvent{
+				event(PaymentEventCaptured, 0, 0),
+				event(PaymentEventCaptured, 0, 0),
+			},
+			changed:  []bool{true, false},
+			status:   PaymentCaptured,
+			captured: 50,
+		},
+		{
+			name:    "refund before the capture",
+			payment: Payment{Status: PaymentAuthorized, Amount: 50},
+			events: []PaymentEvent{
+				event(PaymentEventRefunded, 0, 20),
+				event(PaymentEventCaptured, 0, 0),
+			},
+			changed:  []bool{true, false},
+			status:   PaymentCaptured,
+			captured: 50,
+			refunded: 20,
+		},
+		{
+			name:    "partial refunds out of order and duplicated",
+			payment: Payment{Status: PaymentCaptured, Amount: 50, CapturedAmount: 50},
+			events: []PaymentEvent{
+				event(PaymentEventRefunded, 0, 30),
+				event(PaymentEventRefunded, 0, 10),
+				event(PaymentEventRefunded, 0, 30),
+			},
+			changed:  []bool{true, false, false},
+			status:   PaymentCaptured,
+			captured: 50,
+			refunded: 30,
+		},
+		{
+			name:    "refund above the capture",
+			payment: Payment{Status: PaymentCaptured, Amount: 50, CapturedAmount: 40},
+			events: []PaymentEvent{
+				event(PaymentEventRefunded, 0, 60),
+				event(PaymentEventRefunded, 0, 40),
+			},
+			changed:  []bool{true, false},
+			status:   PaymentRefunded,
+			captured: 40,
+			refunded: 40,
+		},
+		{
+			name:    "voided payments ignore later events",
+			payment: Payment{Status: PaymentAuthorized, Amount: 50},
+			events: []PaymentEvent{
+				event(PaymentEventVoided, 0, 0),
+				event(PaymentEventCaptured, 0, 0),
+				event(PaymentEventRefunded, 0, 50),
+			},
+			changed: []bool{true, false, false},
+			status:  PaymentVoided,
+		},
+		{
+			name:    "failure after the capture",
+			payment: Payment{Status: PaymentCaptured, Amount: 50, CapturedAmount: 50},
+			events: []PaymentEvent{
+				event(PaymentEventFailed, 0, 0),
+			},
+			changed:  []bool{false},
+			status:   PaymentCaptured,
+			captured: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := tt.payment
+			for i, e := range tt.events {
+				if changed := payment.ApplyEvent(e); changed != tt.changed[i] {
+					t.Errorf("ApplyEvent(%s) #%d = %v, want %v", e.Type, i, changed, tt.changed[i])
+				}
+			}
+			if payment.Status != tt.status || payment.CapturedAmount != tt.captured || payment.RefundedAmount != tt.refunded {
+				t.Errorf("payment = %s captured %v refunded %v, want %s captured %v refunded %v",
+					payment.Status, payment.CapturedAmount, payment.RefundedAmount, tt.status, tt.captured, tt.refunded)
+			}
+		})
+	}
+}
+
+func TestPaymentApplyFailedEvent(t *testing.T) {
+	payment := Payment{Status: PaymentAuthorized, Amount: 50}
+	if !payment.ApplyEvent(PaymentEvent{Type: PaymentEventFailed, Message: "card declined"}) {
+		t.Fatal("ApplyEvent(failed) = false, want true")
+	}
+	if payment.Status != PaymentFailed || payment.FailureReason == nil || *payment.FailureReason != "card declined" {
+		t.Errorf("payment = %s, reason %v, want failed with the event message", payment.Status, payment.FailureReason)
+	}
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	PromotionPercentage = "percentage"
+	PromotionFixed      = "fixed"
+	PromotionBuyXGetY   = "buy_x_get_y"
+)
+
+// Promotion is a coupon. Value is a percentage or an amount depending on the
+// type, buy_x_get_y promotions instead give GetQuantity units free for every
+// BuyQuantity units bought. A ProductID limits the promotion to that product.
+type Promotion struct {
+	ID           int        `db:"id" json:"id"`
+	Code         string     `db:"code" json:"code"`
+	Description  string     `db:"description" json:"description"`
+	Type         string     `db:"type" json:"type"`
+	Value        float64    `db:"value" json:"value"`
+	ProductID    *int       `db:"product_id" json:"product_id,omitempty"`
+	BuyQuantity  int        `db:"buy_quantity" json:"buy_quantity,omitempty"`
+	GetQuantity  int        `db:"get_quantity" json:"get_quantity,omitempty"`
+	MinBasket    float64    `db:"min_basket" json:"min_basket"`
+	UsageLimit   *int       `db:"usage_limit" json:"usage_limit,omitempty"`
+	PerUserLimit *int       `db:"per_user_limit" json:"per_user_limit,omitempty"`
+	StartsAt     *time.Time `db:"starts_at" json:"starts_at,omitempty"`
+	EndsAt       *time.Time `db:"ends_at" json:"ends_at,omitempty"`
+	Active       bool       `db:"active" json:"active"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
+
+// PromotionUpdate replaces a promotion. Active is only changed when it is
+// sent, so that an update does not reactivate a deactivated promotion.
+type PromotionUpdate struct {
+	Promotion
+	Active *bool `json:"active"`
+}
+
+func (p *Promotion) Validate() error {
+	p.Code = strings.ToUpper(strings.TrimSpace(p.Code))
+	if p.Code == "" {
+		return errors.New("code is required")
+	}
+
+	switch p.Type {
+	case PromotionPercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return errors.New("percentage must be between 0 and 100")
+		}
+	case PromotionFixed:
+		if p.Value <= 0 {
+			return errors.New("value must be greater than 0")
+		}
+	case PromotionBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return errors.New("buy_quantity and get_quantity must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("type must be one of %s, %s, %s", PromotionPercentage, PromotionFixed, PromotionBuyXGetY)
+	}
+
+	if p.MinBasket < 0 {
+		return errors.New("min_basket must not be negative")
+	}
+	if p.UsageLimit != nil && *p.UsageLimit <= 0 {
+		return errors.New("usage_limit must be greater than 0")
+	}
+	if p.PerUserLimit != nil && *p.PerUserLimit <= 0 {
+		return errors.New("per_user_limit must be greater than 0")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// Usable reports why the promotion cannot be used at the given time, if so.
+func (p Promotion) Usable(now time.Time) error {
+	if !p.Active {
+		return fmt.Errorf("coupon %s is no longer active", p.Code)
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return fmt.Errorf("coupon %s is not valid yet", p.Code)
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return fmt.Errorf("coupon %s has expired", p.Code)
+	}
+	return nil
+}
+
+// CheckUsage compares how often the promotion has been used, overall and by
+// one user, with its limits.
+func (p Promotion) CheckUsage(used int, usedByUser int) error {
+	if p.UsageLimit != nil && used >= *p.UsageLimit {
+		return fmt.Errorf("coupon %s has reached its usage limit", p.Code)
+	}
+	if p.PerUserLimit != nil && usedByUser >= *p.PerUserLimit {
+		return fmt.Errorf("coupon %s has already been used the maximum number of times", p.Code)
+	}
+	return nil
+}
+
+// OrderDiscount is a promotion applied to an order, with the amount it took
+// off at the time.
+type OrderDiscount struct {
+	PromotionID int     `db:"promotion_id" json:"promotion_id"`
+	Code        string  `db:"code" json:"code"`
+	Description string  `db:"description" json:"description"`
+	Amount      float64 `db:"amount" json:"amount"`
+}
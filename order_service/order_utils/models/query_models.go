@@ -0,0 +1,295 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultOrderLimit = 20
+	MaxOrderLimit     = 100
+)
+
+var orderSortColumns = map[string]string{
+	"id":         "id",
+	"order_date": "order_date",
+	"total":      "total_amount",
+}
+
+var orderStatuses = map[string]bool{
+	OrderCreated:           true,
+	OrderPaid:              true,
+	OrderProcessing:        true,
+	OrderPartiallyShipped:  true,
+	OrderShipped:           true,
+	OrderDelivered:         true,
+	OrderCancelled:         true,
+	OrderPartiallyRefunded: true,
+	OrderRefunded:          true,
+}
+
+// OrderQuery filters the orders listed for admins. Pages follow each other
+// through an opaque cursor holding the sort value and id of the last order of
+// the previous page, so orders placed meanwhile do not shift the pages.
+type OrderQuery struct {
+	Limit     int
+	Sort      string
+	Cursor    string
+	Statuses  []string
+	UserID    int
+	ProductID int
+	// Orders are placed at From or later and before To.
+	From     *time.Time
+	To       *time.Time
+	MinTotal *float64
+	MaxTotal *float64
+}
+
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	Total      int     `json:"total"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type orderCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func ParseOrderQuery(values url.Values) (OrderQuery, error) {
+	query := OrderQuery{
+		Limit:  DefaultOrderLimit,
+		Sort:   values.Get("sort"),
+		Cursor: values.Get("cursor"),
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > MaxOrderLimit {
+			return OrderQuery{}, fmt.Errorf("limit must be between 1 and %d", MaxOrderLimit)
+		}
+		query.Limit = l
+	}
+
+	if status := values.Get("status"); status != "" {
+		for _, s := range strings.Split(status, ",") {
+			s = strings.TrimSpace(s)
+			if !orderStatuses[s] {
+				return OrderQuery{}, fmt.Errorf("unknown order status %q", s)
+			}
+			query.Statuses = append(query.Statuses, s)
+		}
+	}
+
+	if userID := values.Get("user_id"); userID != "" {
+		id, err := strconv.Atoi(userID)
+		if err != nil || id < 1 {
+			return OrderQuery{}, fmt.Errorf("user_id must be a positive integer")
+		}
+		query.UserID = id
+	}
+
+	if productID := values.Get("product_id"); productID != "" {
+		id, err := strconv.Atoi(productID)
+		if err != nil || id < 1 {
+			return OrderQuery{}, fmt.Errorf("product_id must be a positive integer")
+		}
+		query.ProductID = id
+	}
+
+	from, to, err := parseDateRange(values)
+	if err != nil {
+		return OrderQuery{}, err
+	}
+	query.From, query.To = from, to
+
+	if minTotal := values.Get("min_total"); minTotal != "" {
+		total, err := strconv.ParseFloat(minTotal, 64)
+		if err != nil || total < 0 {
+			return OrderQuery{}, fmt.Errorf("min_total must be a non-negative number")
+		}
+		query.MinTotal = &total
+	}
+
+	if maxTotal := values.Get("max_total"); maxTotal != "" {
+		total, err := strconv.ParseFloat(maxTotal, 64)
+		if err != nil || total < 0 {
+			return OrderQuery{}, fmt.Errorf("max_total must be a non-negative number")
+		}
+		query.MaxTotal = &total
+	}
+
+	return query, query.Validate()
+}
+
+// parseDateRange reads the "from" and "to" parameters. A date given as "to"
+// includes the whole day.
+func parseDateRange(values url.Values) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+
+	if value := values.Get("from"); value != "" {
+		t, _, err := parseQueryTime(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("from must be a date or an RFC 3339 time")
+		}
+		from = &t
+	}
+
+	if value := values.Get("to"); value != "" {
+		t, date, err := parseQueryTime(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("to must be a date or an RFC 3339 time")
+		}
+		if date {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = &t
+	}
+
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+func parseQueryTime(value string) (time.Time, bool, error) {
+	t, err := time.Parse(time.DateOnly, value)
+	if err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+func (q *OrderQuery) Validate() error {
+	if _, _, err := q.SortColumn(); err != nil {
+		return err
+	}
+	if _, _, err := q.After(); err != nil {
+		return err
+	}
+	if q.MinTotal != nil && q.MaxTotal != nil && *q.MinTotal > *q.MaxTotal {
+		return fmt.Errorf("min_total must not exceed max_total")
+	}
+	return nil
+}
+
+// SortColumn maps the "sort" parameter (e.g. "total" or "-total") to a
+// whitelisted column and direction. Newest orders come first by default.
+func (q *OrderQuery) SortColumn() (string, string, error) {
+	if q.Sort == "" {
+		return "order_date", "DESC", nil
+	}
+
+	field, direction := q.Sort, "ASC"
+	if strings.HasPrefix(field, "-") {
+		field, direction = field[1:], "DESC"
+	}
+
+	column, ok := orderSortColumns[field]
+	if !ok {
+		return "", "", fmt.Errorf("cannot sort by %q, allowed: id, order_date, total", field)
+	}
+	return column, direction, nil
+}
+
+// After decodes the cursor into the sort value and id the page starts after.
+// The value is typed by the sort column: an int, a time.Time or a float64.
+// Without a cursor the id is 0.
+func (q *OrderQuery) After() (any, int, error) {
+	if q.Cursor == "" {
+		return nil, 0, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid cursor")
+	}
+
+	var cursor orderCursor
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.ID <= 0 {
+		return nil, 0, fmt.Errorf("invalid cursor")
+	}
+	if cursor.Sort != q.Sort {
+		return nil, 0, fmt.Errorf("the cursor belongs to another sort order")
+	}
+
+	column, _, err := q.SortColumn()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var value any
+	switch column {
+	case "order_date":
+		value, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	case "total_amount":
+		var total float64
+		total, err = strconv.ParseFloat(cursor.Value, 64)
+		if err == nil && (math.IsNaN(total) || math.IsInf(total, 0)) {
+			err = fmt.Errorf("total is not a number")
+		}
+		value = total
+	default:
+		value, err = strconv.Atoi(cursor.Value)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid cursor")
+	}
+	return value, cursor.ID, nil
+}
+
+// CursorAfter is the cursor of the page that follows the order given.
+func (q *OrderQuery) CursorAfter(o Order) string {
+	value := strconv.Itoa(o.ID)
+	switch column, _, _ := q.SortColumn(); column {
+	case "order_date":
+		value = o.OrderDate.UTC().Format(time.RFC3339Nano)
+	case "total_amount":
+		value = strconv.FormatFloat(o.TotalAmount, 'f', -1, 64)
+	}
+
+	data, _ := json.Marshal(orderCursor{Sort: q.Sort, Value: value, ID: o.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (q *OrderQuery) Values() url.Values {
+	values := url.Values{}
+	values.Set("limit", strconv.Itoa(q.Limit))
+	if q.Sort != "" {
+		values.Set("sort", q.Sort)
+	}
+	if q.Cursor != "" {
+		values.Set("cursor", q.Cursor)
+	}
+	if len(q.Statuses) > 0 {
+		values.Set("status", strings.Join(q.Statuses, ","))
+	}
+	if q.UserID != 0 {
+		values.Set("user_id", strconv.Itoa(q.UserID))
+	}
+	if q.ProductID != 0 {
+		values.Set("product_id", strconv.Itoa(q.ProductID))
+	}
+	if q.From != nil {
+		values.Set("from", q.From.Format(time.RFC3339))
+	}
+	if q.To != nil {
+		values.Set("to", q.To.Format(time.RFC3339))
+	}
+	if q.MinTotal != nil {
+		values.Set("min_total", strconv.FormatFloat(*q.MinTotal, 'f', -1, 64))
+	}
+	if q.MaxTotal != nil {
+		values.Set("max_total", strconv.FormatFloat(*q.MaxTotal, 'f', -1, 64))
+	}
+	return values
+}
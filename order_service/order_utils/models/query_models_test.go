@@ -0,0 +1,134 @@
+package models
+
+import (
+	"encoding/base64"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseOrderQuery(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	minTotal, maxTotal := 10.0, 99.5
+
+	tests := []struct {
+		name    string
+		query   string
+		want    OrderQuery
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  OrderQuery{Limit: DefaultOrderLimit},
+		},
+		{
+			name:  "all filters",
+			query: "limit=50&sort=-total&status=paid,%20shipped&user_id=3&product_id=4&from=2024-03-01&to=2024-03-31&min_total=10&max_total=99.5",
+			want: OrderQuery{
+				Limit:     50,
+				Sort:      "-total",
+				Statuses:  []string{OrderPaid, OrderShipped},
+				UserID:    3,
+				ProductID: 4,
+				From:      &from,
+				To:        &to,
+				MinTotal:  &minTotal,
+				MaxTotal:  &maxTotal,
+			},
+		},
+		{name: "limit too high", query: "limit=101", wantErr: true},
+		{name: "limit not a number", query: "limit=ten", wantErr: true},
+		{name: "unknown status", query: "status=paid,lost", wantErr: true},
+		{name: "unknown sort", query: "sort=email", wantErr: true},
+		{name: "negative user", query: "user_id=-1", wantErr: true},
+		{name: "bad date", query: "from=yesterday", wantErr: true},
+		{name: "empty date range", query: "from=2024-03-02T00:00:00Z&to=2024-03-01", wantErr: true},
+		{name: "min above max", query: "min_total=20&max_total=10", wantErr: true},
+		{name: "invalid cursor", query: "cursor=not-a-cursor", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseOrderQuery(values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOrderQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseOrderQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderQueryCursor(t *testing.T) {
+	order := Order{
+		ID:          42,
+		OrderDate:   time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC),
+		TotalAmount: 19.99,
+	}
+
+	tests := []struct {
+		sort  string
+		value any
+	}{
+		{"", order.OrderDate},
+		{"-order_date", order.OrderDate},
+		{"total", 19.99},
+		{"id", 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			q := OrderQuery{Sort: tt.sort}
+			q.Cursor = q.CursorAfter(order)
+
+			value, id, err := q.After()
+			if err != nil {
+				t.Fatalf("After() error = %v", err)
+			}
+			if id != order.ID || !reflect.DeepEqual(value, tt.value) {
+				t.Errorf("After() = %v, %d, want %v, %d", value, id, tt.value, order.ID)
+			}
+		})
+	}
+}
+
+func TestOrderQueryInvalidCursor(t *testing.T) {
+	cursor := func(data string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(data))
+	}
+
+	tests := []struct {
+		name   string
+		sort   string
+		cursor string
+	}{
+		{"not base64", "", "%%%"},
+		{"not json", "", cursor("42")},
+		{"missing id", "id", cursor(`{"s":"id","v":"42"}`)},
+		{"another sort order", "total", cursor(`{"s":"-total","v":"19.99","id":42}`)},
+		{"date sort with an id", "order_date", cursor(`{"s":"order_date","v":"42","id":42}`)},
+		{"total sort with a date", "total", cursor(`{"s":"total","v":"2024-03-01T12:30:00Z","id":42}`)},
+		{"total sort with NaN", "total", cursor(`{"s":"total","v":"NaN","id":42}`)},
+		{"id sort with a total", "id", cursor(`{"s":"id","v":"19.99","id":42}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := OrderQuery{Sort: tt.sort, Cursor: tt.cursor}
+			if _, _, err := q.After(); err == nil {
+				t.Error("After() error = nil, want an error")
+			}
+			if err := q.Validate(); err == nil {
+				t.Error("Validate() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"net/url"
+	"time"
+)
+
+// OrderExport is an order with its lines as exported for accounting.
+type OrderExport struct {
+	ID             int          `json:"id"`
+	UserID         int          `json:"user_id"`
+	OrderDate      time.Time    `json:"order_date"`
+	Status         string       `json:"status"`
+	Subtotal       float64      `json:"subtotal"`
+	DiscountAmount float64      `json:"discount_amount"`
+	TaxAmount      float64      `json:"tax_amount"`
+	TaxRegion      string       `json:"tax_region"`
+	ShippingCost   float64      `json:"shipping_cost"`
+	TotalAmount    float64      `json:"total_amount"`
+	RefundedAmount float64      `json:"refunded_amount"`
+	Lines          []ExportLine `json:"lines"`
+}
+
+type ExportLine struct {
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	VariantID   *int    `json:"variant_id,omitempty"`
+	Quantity    int     `json:"quantity"`
+	Status      string  `json:"status"`
+	Backordered int     `json:"backordered_quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+}
+
+// SalesQuery limits a sales report to the orders placed at From or later and
+// before To.
+type SalesQuery struct {
+	From *time.Time
+	To   *time.Time
+}
+
+func ParseSalesQuery(values url.Values) (SalesQuery, error) {
+	from, to, err := parseDateRange(values)
+	if err != nil {
+		return SalesQuery{}, err
+	}
+	return SalesQuery{From: from, To: to}, nil
+}
+
+// SalesFigures aggregate a set of orders. Revenue, units and the average
+// order value only count sold orders, the paid ones that were not refunded in
+// full, and revenue is net of their refunds. Orders waiting for payment only
+// count towards the number of orders.
+type SalesFigures struct {
+	Orders            int     `db:"orders" json:"orders"`
+	CancelledOrders   int     `db:"cancelled_orders" json:"cancelled_orders"`
+	CancellationRate  float64 `db:"cancellation_rate" json:"cancellation_rate"`
+	SoldOrders        int     `db:"sold_orders" json:"sold_orders"`
+	Revenue           float64 `db:"revenue" json:"revenue"`
+	Refunded          float64 `db:"refunded" json:"refunded"`
+	UnitsSold         int     `db:"units_sold" json:"units_sold"`
+	AverageOrderValue float64 `db:"average_order_value" json:"average_order_value"`
+}
+
+type DailySales struct {
+	Day string `db:"day" json:"day"`
+	SalesFigures
+}
+
+// ProductSales is what a product sold in sold orders at the prices of the
+// order lines, before order discounts, taxes, shipping and refunds.
+type ProductSales struct {
+	ProductID int     `db:"product_id" json:"product_id"`
+	Name      string  `db:"name" json:"name"`
+	Orders    int     `db:"orders" json:"orders"`
+	UnitsSold int     `db:"units_sold" json:"units_sold"`
+	Revenue   float64 `db:"revenue" json:"revenue"`
+}
+
+type SalesReport struct {
+	From     *time.Time     `json:"from,omitempty"`
+	To       *time.Time     `json:"to,omitempty"`
+	Summary  SalesFigures   `json:"summary"`
+	Days     []DailySales   `json:"days"`
+	Products []ProductSales `json:"products"`
+}
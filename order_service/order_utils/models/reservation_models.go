@@ -0,0 +1,42 @@
+package models
+
+import (
+	"order_processing_system/user_service/user_utils"
+	"time"
+)
+
+const (
+	ReservationActive    = "active"
+	ReservationConfirmed = "confirmed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+// Reservation holds stock for a checkout until it is confirmed into an order,
+// released by the customer or expired by the sweeper.
+type Reservation struct {
+	ID        int            `db:"id" json:"id"`
+	UserID    int            `db:"user_id" json:"user_id"`
+	Status    string         `db:"status" json:"status"`
+	OrderID   *int           `db:"order_id" json:"order_id,omitempty"`
+	ExpiresAt time.Time      `db:"expires_at" json:"expires_at"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	Products  []OrderProduct `db:"-" json:"products"`
+}
+
+// Confirmable reports whether the reservation still holds its stock at now.
+// The sweeper expires reservations late, their TTL decides.
+func (r Reservation) Confirmable(now time.Time) bool {
+	return r.Status == ReservationActive && r.ExpiresAt.After(now)
+}
+
+type ReservationInput struct {
+	Products []OrderProduct `json:"products"`
+}
+
+type ReservationConfirm struct {
+	Location *Location `json:"location,omitempty"`
+
+	ShippingAddress *user_utils.PostalAddress `json:"shipping_address,omitempty"`
+	AddressID       *int                      `json:"address_id,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReservationConfirmable(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		reservation Reservation
+		want        bool
+	}{
+		{"active", Reservation{Status: ReservationActive, ExpiresAt: now.Add(time.Minute)}, true},
+		{"past its TTL before the sweep", Reservation{Status: ReservationActive, ExpiresAt: now.Add(-time.Second)}, false},
+		{"expiring now", Reservation{Status: ReservationActive, ExpiresAt: now}, false},
+		{"confirmed", Reservation{Status: ReservationConfirmed, ExpiresAt: now.Add(time.Minute)}, false},
+		{"released", Reservation{Status: ReservationReleased, ExpiresAt: now.Add(time.Minute)}, false},
+		{"expired", Reservation{Status: ReservationExpired, ExpiresAt: now.Add(-time.Minute)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reservation.Confirmable(now); got != tt.want {
+				t.Errorf("Confirmable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Order statuses set by refunds.
+const (
+	OrderPartiallyRefunded = "partially_refunded"
+	OrderRefunded          = "refunded"
+)
+
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnReceived  = "received"
+	// ReturnRefundPending waits for the payment provider to complete the
+	// refund, the refund webhook moves it to refunded.
+	ReturnRefundPending = "refund_pending"
+	ReturnRefunded      = "refunded"
+)
+
+var returnReasons = map[string]bool{
+	"damaged":          true,
+	"defective":        true,
+	"wrong_item":       true,
+	"not_as_described": true,
+	"no_longer_needed": true,
+	"other":            true,
+}
+
+// Return is a return merchandise authorization: the customer asks to send
+// back items of an order, an admin approves it, receives the goods and
+// refunds them.
+type Return struct {
+	ID           int          `db:"id" json:"id"`
+	OrderID      int          `db:"order_id" json:"order_id"`
+	UserID       int          `db:"user_id" json:"user_id"`
+	Status       string       `db:"status" json:"status"`
+	Note         *string      `db:"note" json:"note,omitempty"`
+	Restocked    bool         `db:"restocked" json:"restocked"`
+	RefundAmount float64      `db:"refund_amount" json:"refund_amount"`
+	CreatedAt    time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time    `db:"updated_at" json:"updated_at"`
+	Items        []ReturnItem `json:"items"`
+}
+
+type ReturnItem struct {
+	ProductID int    `db:"product_id" json:"product_id"`
+	VariantID *int   `db:"variant_id" json:"variant_id,omitempty"`
+	Quantity  int    `db:"quantity" json:"quantity"`
+	Reason    string `db:"reason" json:"reason"`
+	Comment   string `db:"comment" json:"comment,omitempty"`
+}
+
+type ReturnInput struct {
+	Items []ReturnItem `json:"items"`
+}
+
+// ReturnDecision approves or rejects a return, with a note for the customer.
+type ReturnDecision struct {
+	Note string `json:"note"`
+}
+
+// ReturnReceipt records the goods of a return as received. They go back to
+// stock unless Restock is false, e.g. for damaged goods.
+type ReturnReceipt struct {
+	Restock *bool `json:"restock,omitempty"`
+}
+
+func (r ReturnInput) Validate() error {
+	if len(r.Items) == 0 {
+		return errors.New("a return needs at least one item")
+	}
+	for _, item := range r.Items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("product %d: quantity must be positive", item.ProductID)
+		}
+		if !returnReasons[item.Reason] {
+			return fmt.Errorf("product %d: unknown return reason %q", item.ProductID, item.Reason)
+		}
+	}
+	return nil
+}
+
+// RefundStatus is the status of an order once the amount given is refunded.
+// A full refund closes the order. A partial one keeps the fulfilment status
+// so the goods still outstanding can be shipped and delivered, and only shows
+// in the status of delivered orders.
+func RefundStatus(status string, refunded float64, total float64) string {
+	switch {
+	case refunded <= 0:
+		return status
+	case refunded >= total:
+		return OrderRefunded
+	case status == OrderDelivered:
+		return OrderPartiallyRefunded
+	}
+	return status
+}
+
+// Quantities lists the returned quantity of each product and variant.
+func (r Return) Quantities() []ShipmentItem {
+	items := make([]ShipmentItem, 0, len(r.Items))
+	for _, item := range r.Items {
+		items = append(items, ShipmentItem{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity})
+	}
+	return MergeShipmentItems(items)
+}
+
+// Lines turns the returned items into order lines, as the stock functions
+// take them.
+func (r Return) Lines() []OrderProduct {
+	lines := make([]OrderProduct, 0, len(r.Items))
+	for _, item := range r.Quantities() {
+		lines = append(lines, OrderProduct{ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity})
+	}
+	return lines
+}
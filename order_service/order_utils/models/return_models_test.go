@@ -0,0 +1,88 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRefundStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		refunded float64
+		want     string
+	}{
+		{"no refund", OrderPaid, 0, OrderPaid},
+		{"partial refund of a paid order", OrderPaid, 10, OrderPaid},
+		{"partial refund of a partially shipped order", OrderPartiallyShipped, 10, OrderPartiallyShipped},
+		{"partial refund of a shipped order", OrderShipped, 10, OrderShipped},
+		{"partial refund of a delivered order", OrderDelivered, 10, OrderPartiallyRefunded},
+		{"further refund of a delivered order", OrderPartiallyRefunded, 20, OrderPartiallyRefunded},
+		{"full refund", OrderPaid, 50, OrderRefunded},
+		{"full refund of a delivered order", OrderPartiallyRefunded, 50, OrderRefunded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RefundStatus(tt.status, tt.refunded, 50); got != tt.want {
+				t.Errorf("RefundStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// A partial refund must not keep the rest of an order from being shipped and
+// delivered.
+func TestRefundThenShip(t *testing.T) {
+	for _, status := range []string{OrderPaid, OrderProcessing, OrderPartiallyShipped} {
+		t.Run(status, func(t *testing.T) {
+			refunded := RefundStatus(status, 10, 50)
+			if err := CheckTransition(refunded, OrderShipped); err != nil {
+				t.Fatalf("shipping after a partial refund: %v", err)
+			}
+			if err := CheckTransition(OrderShipped, OrderDelivered); err != nil {
+				t.Fatalf("delivering after a partial refund: %v", err)
+			}
+		})
+	}
+}
+
+func TestReturnInputValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   ReturnInput
+		wantErr bool
+	}{
+		{name: "valid", input: ReturnInput{Items: []ReturnItem{{ProductID: 1, Quantity: 1, Reason: "damaged"}}}},
+		{name: "no items", input: ReturnInput{}, wantErr: true},
+		{name: "zero quantity", input: ReturnInput{Items: []ReturnItem{{ProductID: 1, Reason: "damaged"}}}, wantErr: true},
+		{name: "unknown reason", input: ReturnInput{Items: []ReturnItem{{ProductID: 1, Quantity: 1, Reason: "changed_mind"}}}, wantErr: true},
+		{name: "missing reason", input: ReturnInput{Items: []ReturnItem{{ProductID: 1, Quantity: 1}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReturnLines(t *testing.T) {
+	red := 7
+	ret := Return{Items: []ReturnItem{
+		{ProductID: 1, Quantity: 1, Reason: "damaged"},
+		{ProductID: 2, VariantID: &red, Quantity: 1, Reason: "wrong_item"},
+		{ProductID: 1, Quantity: 2, Reason: "defective"},
+	}}
+	want := []OrderProduct{
+		{ProductID: 1, Quantity: 3},
+		{ProductID: 2, VariantID: &red, Quantity: 1},
+	}
+
+	if got := ret.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %+v, want %+v", got, want)
+	}
+}
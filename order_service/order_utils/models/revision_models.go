@@ -0,0 +1,86 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrVersionConflict = errors.New("the order was changed meanwhile, reload it and try again")
+
+// OrderEdit replaces the lines of an order. Version is the version of the
+// order the edit was made on.
+type OrderEdit struct {
+	Version  int            `json:"version"`
+	Products []OrderProduct `json:"products"`
+}
+
+func (e *OrderEdit) Validate() error {
+	if e.Version <= 0 {
+		return fmt.Errorf("version is required")
+	}
+	if len(e.Products) == 0 {
+		return fmt.Errorf("an order needs at least one product, cancel it instead")
+	}
+	for _, line := range e.Products {
+		if line.Quantity <= 0 {
+			return fmt.Errorf("quantity must be greater than 0")
+		}
+	}
+	return nil
+}
+
+// Editable tells whether the lines of an order in the status given can still
+// change. Once paid the order is priced for good.
+func Editable(status string) bool {
+	return status == OrderCreated
+}
+
+// LineChange is the change of the quantity of a product, or variant, made by
+// an edit. Stock is what the edit takes from stock, or gives back when it is
+// negative; backordered units never were in stock.
+type LineChange struct {
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id,omitempty"`
+	From      int  `json:"from"`
+	To        int  `json:"to"`
+	Stock     int  `json:"-"`
+}
+
+type LineChanges []LineChange
+
+func (c LineChanges) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *LineChanges) Scan(src any) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("unexpected type %T for line changes", src)
+	}
+	return json.Unmarshal(data, c)
+}
+
+// OrderRevision records an edit of an order, which made it the version given.
+type OrderRevision struct {
+	ID            int         `db:"id" json:"id"`
+	OrderID       int         `db:"order_id" json:"order_id"`
+	Version       int         `db:"version" json:"version"`
+	ActorID       *int        `db:"actor_id" json:"actor_id,omitempty"`
+	Changes       LineChanges `db:"changes" json:"changes"`
+	PreviousTotal float64     `db:"previous_total" json:"previous_total"`
+	TotalAmount   float64     `db:"total_amount" json:"total_amount"`
+	CreatedAt     time.Time   `db:"created_at" json:"created_at"`
+}
+
+// OrderUpdate announces an edit of an order.
+type OrderUpdate struct {
+	OrderID       int          `json:"order_id"`
+	UserID        int          `json:"user_id"`
+	Version       int          `json:"version"`
+	Changes       []LineChange `json:"changes"`
+	PreviousTotal float64      `json:"previous_total"`
+	TotalAmount   float64      `json:"total_amount"`
+}
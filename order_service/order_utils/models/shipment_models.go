@@ -0,0 +1,131 @@
+package models
+
+import "time"
+
+// Order statuses set by shipments.
+const (
+	OrderPartiallyShipped = "partially_shipped"
+	OrderShipped          = "shipped"
+	OrderDelivered        = "delivered"
+)
+
+// Shipment statuses, in the order a parcel goes through them.
+const (
+	ShipmentShipped   = "shipped"
+	ShipmentInTransit = "in_transit"
+	ShipmentDelivered = "delivered"
+)
+
+var shipmentSteps = map[string]int{
+	ShipmentShipped:   0,
+	ShipmentInTransit: 1,
+	ShipmentDelivered: 2,
+}
+
+type Shipment struct {
+	ID             int            `db:"id" json:"id"`
+	OrderID        int            `db:"order_id" json:"order_id"`
+	Carrier        string         `db:"carrier" json:"carrier"`
+	TrackingNumber string         `db:"tracking_number" json:"tracking_number"`
+	Status         string         `db:"status" json:"status"`
+	ShippedAt      time.Time      `db:"shipped_at" json:"shipped_at"`
+	DeliveredAt    *time.Time     `db:"delivered_at" json:"delivered_at,omitempty"`
+	Items          []ShipmentItem `json:"items"`
+}
+
+type ShipmentItem struct {
+	ProductID int  `db:"product_id" json:"product_id"`
+	VariantID *int `db:"variant_id" json:"variant_id,omitempty"`
+	Quantity  int  `db:"quantity" json:"quantity"`
+}
+
+// ShipmentInput creates a shipment. Without items it ships everything that
+// is in stock and not shipped yet.
+type ShipmentInput struct {
+	Carrier        string         `json:"carrier"`
+	TrackingNumber string         `json:"tracking_number"`
+	Items          []ShipmentItem `json:"items"`
+}
+
+type ShipmentStatusUpdate struct {
+	Status string `json:"status"`
+}
+
+// ValidShipmentStep reports whether a shipment may move from one status to
+// the other. Shipments only move forward.
+func ValidShipmentStep(from string, to string) bool {
+	next, ok := shipmentSteps[to]
+	return ok && next > shipmentSteps[from]
+}
+
+type shipmentKey struct{ product, variant int }
+
+func keyOf(productID int, variantID *int) shipmentKey {
+	if variantID == nil {
+		return shipmentKey{productID, 0}
+	}
+	return shipmentKey{productID, *variantID}
+}
+
+// ShipmentBalance compares the lines of an order with what has been shipped.
+// shippable is the stock taken for the order that has not been shipped yet,
+// outstanding the quantity of the order that has not been shipped, including
+// backordered quantities.
+func ShipmentBalance(lines []OrderProduct, shipped []ShipmentItem) (shippable []ShipmentItem, outstanding int) {
+	index := map[shipmentKey]int{}
+	for _, line := range lines {
+		k := keyOf(line.ProductID, line.VariantID)
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(shippable)
+			shippable = append(shippable, ShipmentItem{ProductID: line.ProductID, VariantID: line.VariantID})
+			i = len(shippable) - 1
+		}
+		shippable[i].Quantity += line.Fulfilled()
+		outstanding += line.Quantity
+	}
+
+	for _, item := range shipped {
+		if i, ok := index[keyOf(item.ProductID, item.VariantID)]; ok {
+			shippable[i].Quantity -= item.Quantity
+		}
+		outstanding -= item.Quantity
+	}
+
+	available := shippable[:0]
+	for _, item := range shippable {
+		if item.Quantity > 0 {
+			available = append(available, item)
+		}
+	}
+	return available, outstanding
+}
+
+// MergeShipmentItems sums the quantities of repeated products and variants.
+func MergeShipmentItems(items []ShipmentItem) []ShipmentItem {
+	index := map[shipmentKey]int{}
+	merged := []ShipmentItem{}
+	for _, item := range items {
+		k := keyOf(item.ProductID, item.VariantID)
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(merged)
+			merged = append(merged, ShipmentItem{ProductID: item.ProductID, VariantID: item.VariantID})
+			i = len(merged) - 1
+		}
+		merged[i].Quantity += item.Quantity
+	}
+	return merged
+}
+
+// ShippableQuantity is the quantity of a product and variant that can still
+// be shipped, given the shippable items of ShipmentBalance.
+func ShippableQuantity(shippable []ShipmentItem, productID int, variantID *int) int {
+	k := keyOf(productID, variantID)
+	for _, item := range shippable {
+		if keyOf(item.ProductID, item.VariantID) == k {
+			return item.Quantity
+		}
+	}
+	return 0
+}
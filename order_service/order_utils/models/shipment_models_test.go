@@ -0,0 +1,115 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidShipmentStep(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{ShipmentShipped, ShipmentInTransit, true},
+		{ShipmentShipped, ShipmentDelivered, true},
+		{ShipmentInTransit, ShipmentDelivered, true},
+		{ShipmentInTransit, ShipmentShipped, false},
+		{ShipmentDelivered, ShipmentInTransit, false},
+		{ShipmentShipped, ShipmentShipped, false},
+		{ShipmentShipped, "lost", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			if got := ValidShipmentStep(tt.from, tt.to); got != tt.want {
+				t.Errorf("ValidShipmentStep() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShipmentBalance(t *testing.T) {
+	red := 7
+
+	tests := []struct {
+		name            string
+		lines           []OrderProduct
+		shipped         []ShipmentItem
+		wantShippable   []ShipmentItem
+		wantOutstanding int
+	}{
+		{
+			name:            "nothing shipped",
+			lines:           []OrderProduct{{ProductID: 1, Quantity: 2}, {ProductID: 2, VariantID: &red, Quantity: 1}},
+			wantShippable:   []ShipmentItem{{ProductID: 1, Quantity: 2}, {ProductID: 2, VariantID: &red, Quantity: 1}},
+			wantOutstanding: 3,
+		},
+		{
+			name:            "repeated lines are summed",
+			lines:           []OrderProduct{{ProductID: 1, Quantity: 2}, {ProductID: 1, Quantity: 3}},
+			shipped:         []ShipmentItem{{ProductID: 1, Quantity: 4}},
+			wantShippable:   []ShipmentItem{{ProductID: 1, Quantity: 1}},
+			wantOutstanding: 1,
+		},
+		{
+			name:            "variant and product are separate",
+			lines:           []OrderProduct{{ProductID: 2, Quantity: 1}, {ProductID: 2, VariantID: &red, Quantity: 1}},
+			shipped:         []ShipmentItem{{ProductID: 2, VariantID: &red, Quantity: 1}},
+			wantShippable:   []ShipmentItem{{ProductID: 2, Quantity: 1}},
+			wantOutstanding: 1,
+		},
+		{
+			name:            "backordered quantities are outstanding but not shippable",
+			lines:           []OrderProduct{{ProductID: 1, Quantity: 5, Backordered: 3, Status: LineBackordered}},
+			shipped:         []ShipmentItem{{ProductID: 1, Quantity: 2}},
+			wantShippable:   []ShipmentItem{},
+			wantOutstanding: 3,
+		},
+		{
+			name:            "everything shipped",
+			lines:           []OrderProduct{{ProductID: 1, Quantity: 2}},
+			shipped:         []ShipmentItem{{ProductID: 1, Quantity: 1}, {ProductID: 1, Quantity: 1}},
+			wantShippable:   []ShipmentItem{},
+			wantOutstanding: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shippable, outstanding := ShipmentBalance(tt.lines, tt.shipped)
+			if !reflect.DeepEqual(shippable, tt.wantShippable) {
+				t.Errorf("shippable = %+v, want %+v", shippable, tt.wantShippable)
+			}
+			if outstanding != tt.wantOutstanding {
+				t.Errorf("outstanding = %d, want %d", outstanding, tt.wantOutstanding)
+			}
+		})
+	}
+}
+
+func TestMergeShipmentItems(t *testing.T) {
+	red, blue := 7, 8
+	items := []ShipmentItem{
+		{ProductID: 1, Quantity: 1},
+		{ProductID: 2, VariantID: &red, Quantity: 2},
+		{ProductID: 1, Quantity: 3},
+		{ProductID: 2, VariantID: &blue, Quantity: 1},
+		{ProductID: 2, VariantID: &red, Quantity: 1},
+	}
+	want := []ShipmentItem{
+		{ProductID: 1, Quantity: 4},
+		{ProductID: 2, VariantID: &red, Quantity: 3},
+		{ProductID: 2, VariantID: &blue, Quantity: 1},
+	}
+
+	got := MergeShipmentItems(items)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeShipmentItems() = %+v, want %+v", got, want)
+	}
+	if quantity := ShippableQuantity(got, 2, &red); quantity != 3 {
+		t.Errorf("ShippableQuantity() of variant %d = %d, want 3", red, quantity)
+	}
+	if quantity := ShippableQuantity(got, 2, nil); quantity != 0 {
+		t.Errorf("ShippableQuantity() of the product without variant = %d, want 0", quantity)
+	}
+}
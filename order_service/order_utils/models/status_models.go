@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	OrderProcessing = "processing"
+	OrderCancelled  = "cancelled"
+)
+
+// MaxBulkStatusOrders caps the orders of a bulk status update.
+const MaxBulkStatusOrders = 1000
+
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// orderTransitions are the status changes admins make by hand. Payments,
+// shipments and refunds move orders through the other ones.
+var orderTransitions = map[string][]string{
+	OrderCreated:          {OrderProcessing, OrderCancelled},
+	OrderPaid:             {OrderProcessing, OrderShipped, OrderCancelled},
+	OrderProcessing:       {OrderShipped, OrderDelivered, OrderCancelled},
+	OrderPartiallyShipped: {OrderShipped, OrderDelivered},
+	OrderShipped:          {OrderDelivered},
+}
+
+// CheckTransition tells whether an admin may move an order from one status
+// to the other.
+func CheckTransition(from string, to string) error {
+	for _, status := range orderTransitions[from] {
+		if status == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w from %s to %s", ErrIllegalTransition, from, to)
+}
+
+// Expirable reports whether an order can be cancelled for not being paid in
+// time: it still waits for payment and none of its payments is in progress.
+func Expirable(status string, paymentsInProgress int) bool {
+	return status == OrderCreated && paymentsInProgress == 0
+}
+
+// StatusChange is an entry of the status history of an order. Reason and
+// actor are empty for changes made without them, e.g. before the history
+// was kept.
+type StatusChange struct {
+	Status    string    `db:"status" json:"status"`
+	Reason    *string   `db:"reason" json:"reason,omitempty"`
+	ActorID   *int      `db:"actor_id" json:"actor_id,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// ExpiredOrder is announced when an order is cancelled for not being paid in
+// time.
+type ExpiredOrder struct {
+	OrderID   int            `json:"order_id"`
+	UserID    int            `json:"user_id"`
+	Reason    string         `json:"reason"`
+	OrderDate time.Time      `json:"order_date"`
+	Products  []OrderProduct `json:"products"`
+}
+
+// BulkStatusUpdate moves many orders to the same status.
+type BulkStatusUpdate struct {
+	OrderIDs []int  `json:"order_ids"`
+	Status   string `json:"status"`
+}
+
+func (u *BulkStatusUpdate) Validate() error {
+	if len(u.OrderIDs) == 0 {
+		return errors.New("order_ids is required")
+	}
+	if len(u.OrderIDs) > MaxBulkStatusOrders {
+		return fmt.Errorf("at most %d orders can be updated at once", MaxBulkStatusOrders)
+	}
+	if !orderStatuses[u.Status] {
+		return fmt.Errorf("unknown order status %q", u.Status)
+	}
+	return nil
+}
+
+// Outcomes of the orders of a bulk status update.
+const (
+	BulkSucceeded         = "succeeded"
+	BulkIllegalTransition = "illegal_transition"
+	BulkNotFound          = "not_found"
+	BulkFailed            = "failed"
+)
+
+type BulkStatusResult struct {
+	OrderID int    `json:"order_id"`
+	Result  string `json:"result"`
+	From    string `json:"from,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type BulkStatusReport struct {
+	Status    string             `json:"status"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []BulkStatusResult `json:"results"`
+}
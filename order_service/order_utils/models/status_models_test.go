@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpirable(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		payments int
+		want     bool
+	}{
+		{"unpaid order", OrderCreated, 0, true},
+		{"payment in progress", OrderCreated, 1, false},
+		{"paid order", OrderPaid, 0, false},
+		{"cancelled order", OrderCancelled, 0, false},
+		{"shipped order", OrderShipped, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Expirable(tt.status, tt.payments); got != tt.want {
+				t.Errorf("Expirable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  bool
+	}{
+		{OrderCreated, OrderProcessing, false},
+		{OrderCreated, OrderCancelled, false},
+		{OrderPaid, OrderShipped, false},
+		{OrderProcessing, OrderDelivered, false},
+		{OrderPartiallyShipped, OrderShipped, false},
+		{OrderShipped, OrderDelivered, false},
+		{OrderCreated, OrderPaid, true},
+		{OrderCreated, OrderShipped, true},
+		{OrderShipped, OrderCancelled, true},
+		{OrderPartiallyShipped, OrderCancelled, true},
+		{OrderDelivered, OrderShipped, true},
+		{OrderCancelled, OrderProcessing, true},
+		{OrderRefunded, OrderDelivered, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			err := CheckTransition(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckTransition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrIllegalTransition) {
+				t.Errorf("CheckTransition() error = %v, want ErrIllegalTransition", err)
+			}
+		})
+	}
+}
+
+func TestBulkStatusUpdateValidate(t *testing.T) {
+	tooMany := make([]int, MaxBulkStatusOrders+1)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+	}
+
+	tests := []struct {
+		name    string
+		update  BulkStatusUpdate
+		wantErr bool
+	}{
+		{name: "valid", update: BulkStatusUpdate{OrderIDs: []int{1, 2, 2}, Status: OrderShipped}},
+		{name: "at the limit", update: BulkStatusUpdate{OrderIDs: tooMany[:MaxBulkStatusOrders], Status: OrderCancelled}},
+		{name: "no orders", update: BulkStatusUpdate{Status: OrderShipped}, wantErr: true},
+		{name: "too many orders", update: BulkStatusUpdate{OrderIDs: tooMany, Status: OrderShipped}, wantErr: true},
+		{name: "unknown status", update: BulkStatusUpdate{OrderIDs: []int{1}, Status: "lost"}, wantErr: true},
+		{name: "missing status", update: BulkStatusUpdate{OrderIDs: []int{1}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.update.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// TaxRate is the rate of a tax class in a region. Regions are country codes,
+// optionally followed by a subdivision ("US", "US-CA"); a subdivision without
+// its own rate uses the country's. Inclusive rates are already part of the
+// product prices.
+type TaxRate struct {
+	ID        int     `db:"id" json:"id"`
+	Region    string  `db:"region" json:"region"`
+	TaxClass  string  `db:"tax_class" json:"tax_class"`
+	Name      string  `db:"name" json:"name"`
+	Rate      float64 `db:"rate" json:"rate"`
+	Inclusive bool    `db:"inclusive" json:"inclusive"`
+}
+
+func (t *TaxRate) Validate() error {
+	t.Region = NormalizeRegion(t.Region)
+	t.TaxClass = strings.ToLower(strings.TrimSpace(t.TaxClass))
+	if t.Region == "" {
+		return errors.New("region is required")
+	}
+	if t.TaxClass == "" {
+		return errors.New("tax_class is required")
+	}
+	if t.Rate < 0 || t.Rate >= 100 {
+		return errors.New("rate must be between 0 and 100")
+	}
+	return nil
+}
+
+func NormalizeRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
+
+// TaxLine is the tax charged on one order line, with a copy of the rate it was
+// computed with so that invoices do not change with the rates.
+type TaxLine struct {
+	ProductID     int     `db:"product_id" json:"product_id"`
+	VariantID     *int    `db:"variant_id" json:"variant_id,omitempty"`
+	Region        string  `db:"region" json:"region"`
+	TaxClass      string  `db:"tax_class" json:"tax_class"`
+	Name          string  `db:"name" json:"name"`
+	Rate          float64 `db:"rate" json:"rate"`
+	Inclusive     bool    `db:"inclusive" json:"inclusive"`
+	TaxableAmount float64 `db:"taxable_amount" json:"taxable_amount"`
+	TaxAmount     float64 `db:"tax_amount" json:"tax_amount"`
+}
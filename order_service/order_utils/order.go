@@ -15,33 +15,143 @@ func GetAvailableProductAmount(productID int, p *psql.PostgresRepo) (utils.Produ
 	return amount, nil
 }
 
+func GetLineVariant(line models.OrderProduct, p *psql.PostgresRepo) (utils.ProductVariant, error) {
+	variant, err := p.GetVariantByID(*line.VariantID)
+	if err != nil {
+		return utils.ProductVariant{}, fmt.Errorf("variant %d not found", *line.VariantID)
+	}
+	if variant.ProductID != line.ProductID {
+		return utils.ProductVariant{}, fmt.Errorf("variant %d does not belong to product %d", variant.ID, line.ProductID)
+	}
+	return variant, nil
+}
+
+func GetAvailableLineAmount(line models.OrderProduct, p *psql.PostgresRepo) (int, error) {
+	if line.VariantID != nil {
+		variant, err := GetLineVariant(line, p)
+		if err != nil {
+			return 0, err
+		}
+		reserved, err := p.GetReservedQuantity(line.ProductID, line.VariantID)
+		if err != nil {
+			return 0, err
+		}
+		return variant.StockQuantity - reserved, nil
+	}
+
+	variants, err := p.GetProductVariants(line.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	if len(variants) > 0 {
+		return 0, fmt.Errorf("product %d has variants, variant_id is required", line.ProductID)
+	}
+
+	amount, err := GetAvailableProductAmount(line.ProductID, p)
+	if err != nil {
+		return 0, err
+	}
+	return amount.Available, nil
+}
+
 func Validate(o *models.OrderInput, p *psql.PostgresRepo) error {
 	for _, product := range o.Products {
-		available, err := GetAvailableProductAmount(product.ProductID, p)
+		available, err := GetAvailableLineAmount(product, p)
 		if err != nil {
 			return err
 		}
-		if available.StockQuantity <= 0 {
-			return fmt.Errorf("product is out of stock")
-		}
 		if product.Quantity <= 0 {
 			return fmt.Errorf("quantity must be greater than 0")
 		}
-		if product.Quantity > available.StockQuantity {
-			return fmt.Errorf("not enough stock for product, available stock: %d", available.StockQuantity)
+		if product.Quantity <= available {
+			continue
+		}
+
+		item, err := p.GetProductByID(product.ProductID)
+		if err != nil {
+			return err
+		}
+		if item.AcceptsBackorders() {
+			continue
+		}
+
+		if available <= 0 {
+			return fmt.Errorf("product is out of stock")
+		}
+		return fmt.Errorf("not enough stock for product, available stock: %d", available)
+	}
+	return nil
+}
+
+// PlanBackorders splits the lines of backorderable products into the part
+// taken from the available stock and the part that waits for incoming stock.
+func PlanBackorders(o *models.Order, p *psql.PostgresRepo) error {
+	type key struct{ product, variant int }
+	remaining := map[key]int{}
+
+	for i, line := range o.Products {
+		o.Products[i].Status = models.LineAllocated
+		o.Products[i].Backordered = 0
+
+		k := key{product: line.ProductID}
+		if line.VariantID != nil {
+			k.variant = *line.VariantID
+		}
+
+		available, ok := remaining[k]
+		if !ok {
+			amount, err := GetAvailableLineAmount(line, p)
+			if err != nil {
+				return err
+			}
+			available = amount
+		}
+
+		if line.Quantity > available {
+			product, err := p.GetProductByID(line.ProductID)
+			if err != nil {
+				return err
+			}
+			if product.AcceptsBackorders() {
+				o.Products[i].Backordered = line.Quantity - max(available, 0)
+				o.Products[i].Status = models.LineBackordered
+			}
 		}
+
+		remaining[k] = available - o.Products[i].Fulfilled()
 	}
 	return nil
 }
 
+// LinePrice returns the product of a line and its current unit price, which
+// is the variant price for variant lines.
+func LinePrice(line models.OrderProduct, p *psql.PostgresRepo) (utils.Product, float64, error) {
+	product, err := p.GetProductByID(line.ProductID)
+	if err != nil {
+		return utils.Product{}, 0, err
+	}
+
+	price := product.Price
+	if line.VariantID != nil {
+		variant, err := GetLineVariant(line, p)
+		if err != nil {
+			return utils.Product{}, 0, err
+		}
+		price = variant.EffectivePrice(product)
+	}
+	return product, price, nil
+}
+
 func CalculateTotalAmount(o *models.Order, p *psql.PostgresRepo) (float64, error) {
 	totalAmount := 0.0
-	for i, product := range o.Products {
-		product, err := p.GetProductByID(product.ProductID)
+	for i, line := range o.Products {
+		_, price, err := LinePrice(line, p)
 		if err != nil {
 			return 0, err
 		}
-		totalAmount += product.Price * float64(o.Products[i].Quantity)
+
+		o.Products[i].UnitPrice = price
+		totalAmount += price * float64(o.Products[i].Quantity)
 	}
 	return totalAmount, nil
 }
@@ -0,0 +1,192 @@
+package order_utils
+
+import (
+	"errors"
+	"fmt"
+	"order_processing_system/order_service/order_utils/models"
+	"sync"
+)
+
+type PaymentRequest struct {
+	OrderID       int
+	Amount        float64
+	PaymentMethod string
+	// Capture takes the money along with the authorization.
+	Capture bool
+}
+
+// PaymentResult is the provider's answer to an operation. A declined payment
+// is a result with the failed status, errors are reserved for calls that did
+// not reach the provider or that it rejected as invalid. Asynchronous
+// providers answer pending and report the outcome by webhook.
+type PaymentResult struct {
+	Reference string
+	Status    string
+	Message   string
+}
+
+// PaymentProvider charges orders with a payment gateway.
+type PaymentProvider interface {
+	Name() string
+	Authorize(request PaymentRequest) (PaymentResult, error)
+	Capture(reference string, amount float64) (PaymentResult, error)
+	Void(reference string) (PaymentResult, error)
+	Refund(reference string, amount float64) (PaymentResult, error)
+}
+
+// Payment methods the mock provider declines.
+const (
+	MockCardDeclined          = "mock_card_declined"
+	MockCardInsufficientFunds = "mock_card_insufficient_funds"
+)
+
+type mockCharge struct {
+	authorized float64
+	captured   float64
+	refunded   float64
+	status     string
+}
+
+// MockProvider is an in-memory gateway for local development. It accepts
+// every payment method except the MockCard ones.
+type MockProvider struct {
+	mu      sync.Mutex
+	charges map[string]*mockCharge
+}
+
+// PaymentConfig selects the payment provider. The webhook settings are used
+// by the fake gateway, which reports outcomes to WebhookURL.
+type PaymentConfig struct {
+	Provider      string
+	WebhookURL    string
+	WebhookSecret string
+}
+
+func NewPaymentProvider(config PaymentConfig) (PaymentProvider, error) {
+	switch config.Provider {
+	case "mock", "":
+		return NewMockProvider(), nil
+	case "fake_gateway":
+		if config.WebhookURL == "" || config.WebhookSecret == "" {
+			return nil, errors.New("the fake gateway needs a webhook url and secret")
+		}
+		return NewFakeGateway(config.WebhookURL, config.WebhookSecret), nil
+	}
+	return nil, fmt.Errorf("unknown payment provider %q", config.Provider)
+}
+
+func NewMockProvider() *MockProvider {
+	return &MockProvider{charges: map[string]*mockCharge{}}
+}
+
+func (m *MockProvider) Name() string {
+	return "mock"
+}
+
+func (m *MockProvider) Authorize(request PaymentRequest) (PaymentResult, error) {
+	if request.PaymentMethod == "" {
+		return PaymentResult{}, errors.New("payment method is required")
+	}
+
+	reference, err := mockReference()
+	if err != nil {
+		return PaymentResult{}, err
+	}
+
+	switch request.PaymentMethod {
+	case MockCardDeclined:
+		return PaymentResult{Reference: reference, Status: models.PaymentFailed, Message: "card declined"}, nil
+	case MockCardInsufficientFunds:
+		return PaymentResult{Reference: reference, Status: models.PaymentFailed, Message: "insufficient funds"}, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	charge := &mockCharge{authorized: request.Amount, status: models.PaymentAuthorized}
+	if request.Capture {
+		charge.captured = request.Amount
+		charge.status = models.PaymentCaptured
+	}
+	m.charges[reference] = charge
+	return PaymentResult{Reference: reference, Status: charge.status}, nil
+}
+
+func (m *MockProvider) Capture(reference string, amount float64) (PaymentResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	charge, err := m.charge(reference, models.PaymentAuthorized)
+	if err != nil {
+		return PaymentResult{}, err
+	}
+	if amount > charge.authorized {
+		return PaymentResult{}, fmt.Errorf("capture of %.2f exceeds the authorized %.2f", amount, charge.authorized)
+	}
+
+	charge.captured = amount
+	charge.status = models.PaymentCaptured
+	return PaymentResult{Reference: reference, Status: charge.status}, nil
+}
+
+func (m *MockProvider) Void(reference string) (PaymentResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	charge, err := m.charge(reference, models.PaymentAuthorized)
+	if err != nil {
+		return PaymentResult{}, err
+	}
+
+	charge.status = models.PaymentVoided
+	return PaymentResult{Reference: reference, Status: charge.status}, nil
+}
+
+func (m *MockProvider) Refund(reference string, amount float64) (PaymentResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	charge, err := m.charge(reference, models.PaymentCaptured)
+	if err != nil {
+		return PaymentResult{}, err
+	}
+	if left := roundAmount(charge.captured - charge.refunded); amount > left {
+		return PaymentResult{}, fmt.Errorf("refund of %.2f exceeds the refundable %.2f", amount, left)
+	}
+
+	charge.refunded = roundAmount(charge.refunded + amount)
+	if charge.refunded >= charge.captured {
+		charge.status = models.PaymentRefunded
+	}
+	return PaymentResult{Reference: reference, Status: charge.status}, nil
+}
+
+func (m *MockProvider) totals(reference string) (float64, float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	charge, ok := m.charges[reference]
+	if !ok {
+		return 0, 0
+	}
+	return charge.captured, charge.refunded
+}
+
+func (m *MockProvider) charge(reference string, status string) (*mockCharge, error) {
+	charge, ok := m.charges[reference]
+	if !ok {
+		return nil, fmt.Errorf("unknown payment %s", reference)
+	}
+	if charge.status != status {
+		return nil, fmt.Errorf("payment %s is %s", reference, charge.status)
+	}
+	return charge, nil
+}
+
+func mockReference() (string, error) {
+	id, err := randomHex(12)
+	if err != nil {
+		return "", err
+	}
+	return "mock_" + id, nil
+}
@@ -0,0 +1,126 @@
+package order_utils
+
+import (
+	"fmt"
+	"math"
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils/models"
+	"strings"
+	"time"
+)
+
+// ApplyPromotions applies the coupon codes of an order to its total, which
+// must already be calculated. Every code can be used once per order and the
+// discounts together never exceed the total. The usage limits are checked
+// here and once more when the order is stored.
+func ApplyPromotions(o *models.Order, codes []string, p *psql.PostgresRepo) error {
+	if len(codes) == 0 {
+		return nil
+	}
+
+	prices, err := linePrices(o.Products, p)
+	if err != nil {
+		return err
+	}
+
+	subtotal := o.TotalAmount
+	discounted := 0.0
+	applied := map[string]bool{}
+	now := time.Now()
+
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if applied[code] {
+			return fmt.Errorf("coupon %s is used more than once", code)
+		}
+		applied[code] = true
+
+		promotion, err := p.GetPromotionByCode(code)
+		if err != nil {
+			return fmt.Errorf("coupon %s not found", code)
+		}
+
+		err = promotion.Usable(now)
+		if err != nil {
+			return err
+		}
+
+		used, usedByUser, err := p.GetPromotionUsage(promotion.ID, o.UserID)
+		if err != nil {
+			return err
+		}
+		err = promotion.CheckUsage(used, usedByUser)
+		if err != nil {
+			return err
+		}
+
+		if subtotal < promotion.MinBasket {
+			return fmt.Errorf("coupon %s requires a basket of at least %.2f", code, promotion.MinBasket)
+		}
+
+		amount := roundAmount(min(PromotionDiscount(promotion, o.Products, prices), subtotal-discounted))
+		if amount <= 0 {
+			return fmt.Errorf("coupon %s does not apply to this order", code)
+		}
+		discounted += amount
+
+		o.Discounts = append(o.Discounts, models.OrderDiscount{
+			PromotionID: promotion.ID,
+			Code:        promotion.Code,
+			Description: promotion.Description,
+			Amount:      amount,
+		})
+	}
+
+	o.DiscountAmount = roundAmount(discounted)
+	o.TotalAmount = roundAmount(subtotal - discounted)
+	return nil
+}
+
+// PromotionDiscount is what a promotion takes off the lines it applies to,
+// given the unit price of every line. Buy X get Y gives the cheapest units
+// away.
+func PromotionDiscount(promotion models.Promotion, lines []models.OrderProduct, prices []float64) float64 {
+	base := 0.0
+	units := 0
+	cheapest := math.Inf(1)
+
+	for i, line := range lines {
+		if promotion.ProductID != nil && line.ProductID != *promotion.ProductID {
+			continue
+		}
+		base += prices[i] * float64(line.Quantity)
+		units += line.Quantity
+		cheapest = min(cheapest, prices[i])
+	}
+	if units == 0 {
+		return 0
+	}
+
+	switch promotion.Type {
+	case models.PromotionPercentage:
+		return base * promotion.Value / 100
+	case models.PromotionFixed:
+		return min(promotion.Value, base)
+	case models.PromotionBuyXGetY:
+		free := units / (promotion.BuyQuantity + promotion.GetQuantity) * promotion.GetQuantity
+		return float64(free) * cheapest
+	}
+	return 0
+}
+
+func linePrices(lines []models.OrderProduct, p *psql.PostgresRepo) ([]float64, error) {
+	prices := make([]float64, len(lines))
+	for i, line := range lines {
+		_, price, err := LinePrice(line, p)
+		if err != nil {
+			return nil, err
+		}
+		prices[i] = price
+	}
+	return prices, nil
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,39 @@
+package order_utils
+
+import (
+	"order_processing_system/order_service/order_utils/models"
+	"testing"
+)
+
+func TestPromotionDiscount(t *testing.T) {
+	product := 2
+	lines := []models.OrderProduct{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 3},
+	}
+	prices := []float64{10, 4}
+
+	tests := []struct {
+		name      string
+		promotion models.Promotion
+		want      float64
+	}{
+		{"percentage of the order", models.Promotion{Type: models.PromotionPercentage, Value: 10}, 3.2},
+		{"percentage of a product", models.Promotion{Type: models.PromotionPercentage, Value: 50, ProductID: &product}, 6},
+		{"fixed", models.Promotion{Type: models.PromotionFixed, Value: 5}, 5},
+		{"fixed above the lines", models.Promotion{Type: models.PromotionFixed, Value: 50, ProductID: &product}, 12},
+		{"buy one get one gives the cheapest units", models.Promotion{Type: models.PromotionBuyXGetY, BuyQuantity: 1, GetQuantity: 1}, 8},
+		{"buy two get one of a product", models.Promotion{Type: models.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, ProductID: &product}, 4},
+		{"buy x get y without enough units", models.Promotion{Type: models.PromotionBuyXGetY, BuyQuantity: 5, GetQuantity: 1}, 0},
+		{"product not ordered", models.Promotion{Type: models.PromotionFixed, Value: 5, ProductID: new(int)}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := roundAmount(PromotionDiscount(tt.promotion, lines, prices))
+			if got != tt.want {
+				t.Errorf("PromotionDiscount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package order_utils
+
+import (
+	"fmt"
+	"order_processing_system/order_service/order_utils/models"
+)
+
+// ReturnValue is what the returned items of an order are worth. The items
+// are valued at the prices they were ordered at and carry their share of the
+// order's discounts and exclusive taxes, but not of its shipping.
+func ReturnValue(order *models.Order, ret models.Return) (float64, error) {
+	value := 0.0
+	for _, line := range ret.Lines() {
+		ordered, ok := orderLine(order, line)
+		if !ok {
+			return 0, fmt.Errorf("product %d is not part of order %d", line.ProductID, order.ID)
+		}
+		value += ordered.UnitPrice * float64(line.Quantity)
+	}
+
+	if order.Subtotal > 0 {
+		value *= (order.TotalAmount - order.ShippingCost) / order.Subtotal
+	}
+	return roundAmount(value), nil
+}
+
+// orderLine finds the line of the order with the product and variant of line.
+func orderLine(order *models.Order, line models.OrderProduct) (models.OrderProduct, bool) {
+	for _, ordered := range order.Products {
+		if ordered.ProductID != line.ProductID {
+			continue
+		}
+		if (ordered.VariantID == nil) == (line.VariantID == nil) && (ordered.VariantID == nil || *ordered.VariantID == *line.VariantID) {
+			return ordered, true
+		}
+	}
+	return models.OrderProduct{}, false
+}
@@ -0,0 +1,54 @@
+package order_utils
+
+import (
+	"order_processing_system/order_service/order_utils/models"
+	"testing"
+)
+
+func TestReturnValue(t *testing.T) {
+	red := 7
+	order := &models.Order{
+		ID: 1,
+		Products: []models.OrderProduct{
+			{ProductID: 1, Quantity: 2, UnitPrice: 20},
+			{ProductID: 2, VariantID: &red, Quantity: 1, UnitPrice: 60},
+		},
+		Subtotal:    100,
+		TotalAmount: 100,
+	}
+	discounted := *order
+	discounted.DiscountAmount = 10
+	discounted.TaxAmount = 9
+	discounted.ShippingCost = 5
+	discounted.TotalAmount = 104
+
+	item := func(productID int, variantID *int, quantity int) models.ReturnItem {
+		return models.ReturnItem{ProductID: productID, VariantID: variantID, Quantity: quantity, Reason: "damaged"}
+	}
+
+	tests := []struct {
+		name    string
+		order   *models.Order
+		items   []models.ReturnItem
+		want    float64
+		wantErr bool
+	}{
+		{name: "one unit", order: order, items: []models.ReturnItem{item(1, nil, 1)}, want: 20},
+		{name: "whole order", order: order, items: []models.ReturnItem{item(1, nil, 2), item(2, &red, 1)}, want: 100},
+		{name: "discount and tax without shipping", order: &discounted, items: []models.ReturnItem{item(2, &red, 1)}, want: 59.4},
+		{name: "product not in the order", order: order, items: []models.ReturnItem{item(3, nil, 1)}, wantErr: true},
+		{name: "product without the variant", order: order, items: []models.ReturnItem{item(2, nil, 1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReturnValue(tt.order, models.Return{Items: tt.items})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReturnValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ReturnValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package order_utils
+
+import (
+	"errors"
+	"fmt"
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils/models"
+	"order_processing_system/user_service/user_utils"
+)
+
+// ShippingBasket is what a shipping rate is quoted for: the weight of the
+// order in kilograms and the value of its goods after discounts.
+type ShippingBasket struct {
+	Weight  float64
+	Amount  float64
+	Address user_utils.PostalAddress
+}
+
+// ShippingCalculator quotes the shipping cost of an order.
+type ShippingCalculator interface {
+	Quote(basket ShippingBasket) float64
+}
+
+type FlatRate struct {
+	Amount float64
+}
+
+type WeightRate struct {
+	Base  float64
+	PerKg float64
+}
+
+// FreeOverThreshold ships baskets worth at least Threshold for free and quotes
+// the others with Rate.
+type FreeOverThreshold struct {
+	Threshold float64
+	Rate      ShippingCalculator
+}
+
+type ShippingConfig struct {
+	Method   string
+	Flat     float64
+	Base     float64
+	PerKg    float64
+	FreeOver float64
+}
+
+func NewShippingCalculator(config ShippingConfig) (ShippingCalculator, error) {
+	var rate ShippingCalculator
+	switch config.Method {
+	case "flat", "":
+		rate = FlatRate{Amount: config.Flat}
+	case "weight":
+		rate = WeightRate{Base: config.Base, PerKg: config.PerKg}
+	default:
+		return nil, fmt.Errorf("unknown shipping method %q", config.Method)
+	}
+
+	if config.FreeOver > 0 {
+		rate = FreeOverThreshold{Threshold: config.FreeOver, Rate: rate}
+	}
+	return rate, nil
+}
+
+func (r FlatRate) Quote(basket ShippingBasket) float64 {
+	return r.Amount
+}
+
+func (r WeightRate) Quote(basket ShippingBasket) float64 {
+	return r.Base + r.PerKg*basket.Weight
+}
+
+func (r FreeOverThreshold) Quote(basket ShippingBasket) float64 {
+	if basket.Amount >= r.Threshold {
+		return 0
+	}
+	return r.Rate.Quote(basket)
+}
+
+// ResolveShippingAddress picks the address an order ships to: the one given
+// with the order, else the address book entry addressID, else the user's
+// default address.
+func ResolveShippingAddress(o *models.OrderInput, user_id int, p *psql.PostgresRepo) (user_utils.PostalAddress, error) {
+	var address user_utils.PostalAddress
+	switch {
+	case o.ShippingAddress != nil:
+		address = *o.ShippingAddress
+	case o.AddressID != nil:
+		entry, err := p.GetUserAddress(user_id, *o.AddressID)
+		if err != nil {
+			return user_utils.PostalAddress{}, fmt.Errorf("address %d not found", *o.AddressID)
+		}
+		address = entry.PostalAddress
+	default:
+		entry, err := p.GetDefaultAddress(user_id)
+		if err != nil {
+			return user_utils.PostalAddress{}, errors.New("shipping address is required")
+		}
+		address = entry.PostalAddress
+	}
+
+	err := address.Validate()
+	if err != nil {
+		return user_utils.PostalAddress{}, fmt.Errorf("shipping address: %w", err)
+	}
+	return address, nil
+}
+
+// ShipOrder stores the shipping address on the order and adds the shipping
+// cost to its total. The goods are valued after discounts.
+func ShipOrder(o *models.Order, address user_utils.PostalAddress, calculator ShippingCalculator, p *psql.PostgresRepo) error {
+	weight := 0.0
+	for _, line := range o.Products {
+		product, err := p.GetProductByID(line.ProductID)
+		if err != nil {
+			return err
+		}
+		weight += product.Weight * float64(line.Quantity)
+	}
+
+	basket := ShippingBasket{
+		Weight:  weight,
+		Amount:  o.Subtotal - o.DiscountAmount,
+		Address: address,
+	}
+
+	o.ShippingAddress = &address
+	o.ShippingCost = roundAmount(max(calculator.Quote(basket), 0))
+	o.TotalAmount = roundAmount(o.TotalAmount + o.ShippingCost)
+	return nil
+}
@@ -0,0 +1,58 @@
+package order_utils
+
+import "testing"
+
+func TestShippingCalculator(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ShippingConfig
+		basket  ShippingBasket
+		want    float64
+		wantErr bool
+	}{
+		{
+			name:   "flat rate by default",
+			config: ShippingConfig{Flat: 4.95},
+			basket: ShippingBasket{Weight: 10, Amount: 20},
+			want:   4.95,
+		},
+		{
+			name:   "weight rate",
+			config: ShippingConfig{Method: "weight", Base: 3, PerKg: 1.5},
+			basket: ShippingBasket{Weight: 4, Amount: 20},
+			want:   9,
+		},
+		{
+			name:   "below the free shipping threshold",
+			config: ShippingConfig{Method: "weight", Base: 3, PerKg: 1.5, FreeOver: 50},
+			basket: ShippingBasket{Weight: 2, Amount: 49.99},
+			want:   6,
+		},
+		{
+			name:   "at the free shipping threshold",
+			config: ShippingConfig{Method: "flat", Flat: 4.95, FreeOver: 50},
+			basket: ShippingBasket{Weight: 2, Amount: 50},
+			want:   0,
+		},
+		{
+			name:    "unknown method",
+			config:  ShippingConfig{Method: "courier"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calculator, err := NewShippingCalculator(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewShippingCalculator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := calculator.Quote(tt.basket); got != tt.want {
+				t.Errorf("Quote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package order_utils
+
+import (
+	"fmt"
+	"order_processing_system/db/psql"
+	"order_processing_system/order_service/order_utils/models"
+	"strings"
+)
+
+// TaxableLine is an order line valued after the order's discounts.
+type TaxableLine struct {
+	ProductID int
+	VariantID *int
+	TaxClass  string
+	Amount    float64
+}
+
+// TaxEngine computes the tax lines of an order for the region it is taxed in.
+// rates holds the rates of the region and of its country.
+type TaxEngine interface {
+	Calculate(region string, lines []TaxableLine, rates []models.TaxRate) []models.TaxLine
+}
+
+// RateTableEngine charges the configured rate of each line's tax class, taking
+// the region's own rate over the country's.
+type RateTableEngine struct{}
+
+// NoTaxEngine charges no tax at all.
+type NoTaxEngine struct{}
+
+func NewTaxEngine(name string) (TaxEngine, error) {
+	switch name {
+	case "rates", "":
+		return RateTableEngine{}, nil
+	case "none":
+		return NoTaxEngine{}, nil
+	}
+	return nil, fmt.Errorf("unknown tax engine %q", name)
+}
+
+// TaxOrder computes the taxes of an order once its discounts are applied.
+// Discounts are spread over the lines by value. Inclusive taxes are already
+// part of the subtotal, exclusive ones are added to the total.
+func TaxOrder(o *models.Order, region string, engine TaxEngine, p *psql.PostgresRepo) error {
+	o.Subtotal = roundAmount(o.TotalAmount + o.DiscountAmount)
+	o.TaxRegion = models.NormalizeRegion(region)
+	if o.TaxRegion == "" || o.Subtotal == 0 {
+		return nil
+	}
+
+	share := 1 - o.DiscountAmount/o.Subtotal
+	lines := make([]TaxableLine, 0, len(o.Products))
+	for _, line := range o.Products {
+		product, price, err := LinePrice(line, p)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, TaxableLine{
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			TaxClass:  product.TaxClass,
+			Amount:    price * float64(line.Quantity) * share,
+		})
+	}
+
+	rates, err := p.GetTaxRates(o.TaxRegion, regionCountry(o.TaxRegion))
+	if err != nil {
+		return err
+	}
+
+	o.TaxLines = engine.Calculate(o.TaxRegion, lines, rates)
+
+	exclusive := 0.0
+	for _, line := range o.TaxLines {
+		o.TaxAmount += line.TaxAmount
+		if !line.Inclusive {
+			exclusive += line.TaxAmount
+		}
+	}
+	o.TaxAmount = roundAmount(o.TaxAmount)
+	o.TotalAmount = roundAmount(o.TotalAmount + exclusive)
+	return nil
+}
+
+func (RateTableEngine) Calculate(region string, lines []TaxableLine, rates []models.TaxRate) []models.TaxLine {
+	taxLines := []models.TaxLine{}
+	for _, line := range lines {
+		rate, ok := matchRate(region, line.TaxClass, rates)
+		if !ok || line.Amount <= 0 {
+			continue
+		}
+
+		taxLine := models.TaxLine{
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			Region:    rate.Region,
+			TaxClass:  rate.TaxClass,
+			Name:      rate.Name,
+			Rate:      rate.Rate,
+			Inclusive: rate.Inclusive,
+		}
+		if rate.Inclusive {
+			taxLine.TaxAmount = roundAmount(line.Amount - line.Amount/(1+rate.Rate/100))
+			taxLine.TaxableAmount = roundAmount(line.Amount - taxLine.TaxAmount)
+		} else {
+			taxLine.TaxAmount = roundAmount(line.Amount * rate.Rate / 100)
+			taxLine.TaxableAmount = roundAmount(line.Amount)
+		}
+		taxLines = append(taxLines, taxLine)
+	}
+	return taxLines
+}
+
+func (NoTaxEngine) Calculate(region string, lines []TaxableLine, rates []models.TaxRate) []models.TaxLine {
+	return []models.TaxLine{}
+}
+
+func matchRate(region string, taxClass string, rates []models.TaxRate) (models.TaxRate, bool) {
+	var fallback *models.TaxRate
+	for i, rate := range rates {
+		if rate.TaxClass != taxClass {
+			continue
+		}
+		if rate.Region == region {
+			return rate, true
+		}
+		if rate.Region == regionCountry(region) {
+			fallback = &rates[i]
+		}
+	}
+	if fallback == nil {
+		return models.TaxRate{}, false
+	}
+	return *fallback, true
+}
+
+// regionCountry returns the country part of a region such as "US-CA".
+func regionCountry(region string) string {
+	country, _, _ := strings.Cut(region, "-")
+	return country
+}
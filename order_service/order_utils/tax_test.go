@@ -0,0 +1,80 @@
+package order_utils
+
+import (
+	"order_processing_system/order_service/order_utils/models"
+	"reflect"
+	"testing"
+)
+
+func TestRateTableEngineCalculate(t *testing.T) {
+	rates := []models.TaxRate{
+		{Region: "US", TaxClass: "standard", Name: "US", Rate: 5},
+		{Region: "US-CA", TaxClass: "standard", Name: "CA", Rate: 7.25},
+		{Region: "US", TaxClass: "food", Name: "US food", Rate: 2},
+		{Region: "DE", TaxClass: "standard", Name: "MwSt", Rate: 19, Inclusive: true},
+	}
+
+	tests := []struct {
+		name   string
+		region string
+		lines  []TaxableLine
+		want   []models.TaxLine
+	}{
+		{
+			name:   "region rate over country rate",
+			region: "US-CA",
+			lines:  []TaxableLine{{ProductID: 1, TaxClass: "standard", Amount: 100}},
+			want: []models.TaxLine{
+				{ProductID: 1, Region: "US-CA", TaxClass: "standard", Name: "CA", Rate: 7.25, TaxAmount: 7.25, TaxableAmount: 100},
+			},
+		},
+		{
+			name:   "country rate without a region rate",
+			region: "US-NY",
+			lines:  []TaxableLine{{ProductID: 1, TaxClass: "standard", Amount: 100}},
+			want: []models.TaxLine{
+				{ProductID: 1, Region: "US", TaxClass: "standard", Name: "US", Rate: 5, TaxAmount: 5, TaxableAmount: 100},
+			},
+		},
+		{
+			name:   "country rate of another class",
+			region: "US-CA",
+			lines:  []TaxableLine{{ProductID: 2, TaxClass: "food", Amount: 10.5}},
+			want: []models.TaxLine{
+				{ProductID: 2, Region: "US", TaxClass: "food", Name: "US food", Rate: 2, TaxAmount: 0.21, TaxableAmount: 10.5},
+			},
+		},
+		{
+			name:   "inclusive rate",
+			region: "DE",
+			lines:  []TaxableLine{{ProductID: 1, TaxClass: "standard", Amount: 119}},
+			want: []models.TaxLine{
+				{ProductID: 1, Region: "DE", TaxClass: "standard", Name: "MwSt", Rate: 19, Inclusive: true, TaxAmount: 19, TaxableAmount: 100},
+			},
+		},
+		{
+			name:   "unmatched class and free lines",
+			region: "US-CA",
+			lines: []TaxableLine{
+				{ProductID: 1, TaxClass: "books", Amount: 20},
+				{ProductID: 2, TaxClass: "standard", Amount: 0},
+			},
+			want: []models.TaxLine{},
+		},
+		{
+			name:   "unknown region",
+			region: "FR",
+			lines:  []TaxableLine{{ProductID: 1, TaxClass: "standard", Amount: 100}},
+			want:   []models.TaxLine{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RateTableEngine{}.Calculate(tt.region, tt.lines, rates)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Calculate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package order_utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader carries the signature of a payment webhook, in the
+// form t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">.
+const WebhookSignatureHeader = "X-Payment-Signature"
+
+// webhookTolerance bounds the age of a signature, against replayed deliveries.
+const webhookTolerance = 5 * time.Minute
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+func SignWebhook(secret string, body []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + webhookMAC(secret, timestamp, body)
+}
+
+// VerifyWebhook checks the signature header of a webhook body. Several v1
+// values may be sent while the secret is rotated, one match is enough.
+func VerifyWebhook(secret string, body []byte, header string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > webhookTolerance || age < -webhookTolerance {
+		return ErrInvalidSignature
+	}
+
+	expected := []byte(webhookMAC(secret, timestamp, body))
+	for _, signature := range signatures {
+		if hmac.Equal(expected, []byte(signature)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func webhookMAC(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
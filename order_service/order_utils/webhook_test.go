@@ -0,0 +1,39 @@
+package order_utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name   string
+		header string
+		err    error
+	}{
+		{"valid", SignWebhook("secret", body, now), nil},
+		{"within tolerance", SignWebhook("secret", body, now.Add(-webhookTolerance)), nil},
+		{"clock ahead within tolerance", SignWebhook("secret", body, now.Add(webhookTolerance)), nil},
+		{"too old", SignWebhook("secret", body, now.Add(-webhookTolerance-time.Second)), ErrInvalidSignature},
+		{"too far ahead", SignWebhook("secret", body, now.Add(webhookTolerance+time.Second)), ErrInvalidSignature},
+		{"wrong secret", SignWebhook("other", body, now), ErrInvalidSignature},
+		{"tampered body", SignWebhook("secret", []byte(`{"id":"evt_2"}`), now), ErrInvalidSignature},
+		{"rotated secret", SignWebhook("other", body, now) + ",v1=" + webhookMAC("secret", "1700000000", body), nil},
+		{"missing timestamp", "v1=" + webhookMAC("secret", "1700000000", body), ErrInvalidSignature},
+		{"missing signature", "t=1700000000", ErrInvalidSignature},
+		{"empty", "", ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhook("secret", body, tt.header, now)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("VerifyWebhook() = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
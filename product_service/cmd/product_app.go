@@ -62,6 +62,12 @@ func Run() error {
 
 	// service
 	productService := services.NewService(psqlRepo, redisRepo, nats)
+	go func() {
+		err := productService.ListenStockEvents()
+		if err != nil {
+			log.Print(err)
+		}
+	}()
 
 	// controller
 	productController := controllers.NewController(errChan, productService)
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"order_processing_system/export"
+	"order_processing_system/product_service/utils"
+)
+
+// maxImportBytes bounds the size of an import file.
+const maxImportBytes = 10 << 20
+
+type CatalogHandler interface {
+	ProductImport(w http.ResponseWriter, r *http.Request)
+	ProductExport(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) ProductImport(w http.ResponseWriter, r *http.Request) {
+	options, err := utils.ParseImportOptions(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the format parameter wins over the content type, JSON is the default
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = utils.FormatJSON
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err == nil && mediaType == "text/csv" {
+			format = utils.FormatCSV
+		}
+	}
+
+	rows, err := utils.ParseProductImport(format, http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := c.s.ImportProducts(rows, options, requestUserID(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(report)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) ProductExport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	stream := export.NewStream(w)
+	exporter, err := utils.NewProductExporter(format, stream)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if format == "" {
+		format = utils.FormatCSV
+	}
+	w.Header().Set("Content-Type", exporter.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"products.%s\"", format))
+
+	err = c.s.ExportProducts(exporter)
+	if err != nil {
+		stream.Fail(err)
+	}
+}
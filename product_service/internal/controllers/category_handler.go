@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"order_processing_system/product_service/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type CategoryHandler interface {
+	CategoryList(w http.ResponseWriter, r *http.Request)
+	CategoryDetail(w http.ResponseWriter, r *http.Request)
+	CategoryProducts(w http.ResponseWriter, r *http.Request)
+	CategoryCreate(w http.ResponseWriter, r *http.Request)
+	CategoryUpdate(w http.ResponseWriter, r *http.Request)
+	CategoryDelete(w http.ResponseWriter, r *http.Request)
+	ProductCategoriesUpdate(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) CategoryList(w http.ResponseWriter, r *http.Request) {
+	categories, err := c.s.GetCategoryTree()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(categories)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) CategoryDetail(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	category, err := c.s.GetCategory(id)
+	if err != nil {
+		http.Error(w, "No such category", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(category)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) CategoryProducts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	query, err := utils.ParseProductQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	productsData, err := c.s.GetCategoryProducts(id, query)
+	if err != nil {
+		http.Error(w, "No such category", http.StatusNotFound)
+		return
+	}
+
+	c.writeProductPage(w, r, query, productsData)
+}
+
+func (c *Controller) CategoryCreate(w http.ResponseWriter, r *http.Request) {
+	var category utils.Category
+	err := json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	category.ID = 0
+
+	err = c.s.CreateCategory(&category)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(category)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) CategoryUpdate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var newCategory utils.Category
+	err := json.NewDecoder(r.Body).Decode(&newCategory)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	currCategory, err := c.s.GetCategory(id)
+	if err != nil {
+		http.Error(w, "No such category", http.StatusNotFound)
+		return
+	}
+
+	newCategory.ID = currCategory.ID
+
+	category, err := c.s.UpdateCategory(newCategory)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(category)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) CategoryDelete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	err := c.s.RemoveCategory(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) ProductCategoriesUpdate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var assignment utils.CategoryAssignment
+	err := json.NewDecoder(r.Body).Decode(&assignment)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	event, err := c.s.AssignProductCategories(id, assignment.CategoryIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(event)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
@@ -2,9 +2,14 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"order_processing_system/db/redis"
 	"order_processing_system/product_service/internal/services"
 	"order_processing_system/product_service/utils"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -16,8 +21,14 @@ type Controller struct {
 
 type Handler interface {
 	ProductList(w http.ResponseWriter, r *http.Request)
+	ProductSearch(w http.ResponseWriter, r *http.Request)
 	ProductDetail(w http.ResponseWriter, r *http.Request)
 	ProductStock(w http.ResponseWriter, r *http.Request)
+	ProductStockMovements(w http.ResponseWriter, r *http.Request)
+	ProductStockAdjust(w http.ResponseWriter, r *http.Request)
+	ProductStockThreshold(w http.ResponseWriter, r *http.Request)
+	ProductStockSubscribe(w http.ResponseWriter, r *http.Request)
+	ProductStockUnsubscribe(w http.ResponseWriter, r *http.Request)
 	ProductCreate(w http.ResponseWriter, r *http.Request)
 	ProductUpdate(w http.ResponseWriter, r *http.Request)
 	ProductDelete(w http.ResponseWriter, r *http.Request)
@@ -31,8 +42,42 @@ func NewController(ch chan error, s *services.Service) *Controller {
 }
 
 func (c *Controller) ProductList(w http.ResponseWriter, r *http.Request) {
+	query, err := utils.ParseProductQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	productsData, err := c.s.GetAllProducts(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	c.writeProductPage(w, r, query, productsData)
+}
+
+func (c *Controller) writeProductPage(w http.ResponseWriter, r *http.Request, query utils.ProductQuery, page utils.ProductPage) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	w.Header().Set("Link", paginationLinks(r.URL.Path, query, page.Total))
+	w.WriteHeader(http.StatusOK)
+
+	err := json.NewEncoder(w).Encode(page.Products)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) ProductSearch(w http.ResponseWriter, r *http.Request) {
+	search, err := utils.ParseProductSearch(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	productsData, err := c.s.GetAllProducts()
+	results, err := c.s.SearchProducts(search)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -41,7 +86,7 @@ func (c *Controller) ProductList(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	err = json.NewEncoder(w).Encode(productsData)
+	err = json.NewEncoder(w).Encode(results)
 	if err != nil {
 		c.ch <- err
 		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
@@ -88,6 +133,124 @@ func (c *Controller) ProductStock(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (c *Controller) ProductStockMovements(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	ledger, err := c.s.GetStockLedger(id, limit)
+	if err != nil {
+		http.Error(w, "No such product", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(ledger)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) ProductStockAdjust(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var adjustment utils.StockAdjustment
+	err := json.NewDecoder(r.Body).Decode(&adjustment)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	stock, err := c.s.AdjustStock(id, adjustment, requestUserID(r))
+	if errors.Is(err, utils.ErrNegativeStock) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(stock)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) ProductStockThreshold(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var input utils.StockThresholdInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	stock, err := c.s.SetLowStockThreshold(id, input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(stock)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) ProductStockSubscribe(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	err := c.s.SubscribeBackInStock(id, requestUserID(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	respMsg := fmt.Sprintf("You will be notified when product %s is back in stock", id)
+	w.Write([]byte(respMsg))
+}
+
+func (c *Controller) ProductStockUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	err := c.s.UnsubscribeBackInStock(id, requestUserID(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	respMsg := fmt.Sprintf("Back in stock notification for product %s cancelled", id)
+	w.Write([]byte(respMsg))
+}
+
 func (c *Controller) ProductCreate(w http.ResponseWriter, r *http.Request) {
 	var product utils.Product
 	err := json.NewDecoder(r.Body).Decode(&product)
@@ -96,7 +259,7 @@ func (c *Controller) ProductCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.s.CreateProduct(&product)
+	err = c.s.CreateProduct(&product, requestUserID(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -159,3 +322,41 @@ func (c *Controller) ProductDelete(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func paginationLinks(path string, query utils.ProductQuery, total int) string {
+	lastPage := (total + query.Limit - 1) / query.Limit
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	link := func(page int, rel string) string {
+		q := query
+		q.Page = page
+		return fmt.Sprintf("<%s?%s>; rel=\"%s\"", path, q.Values().Encode(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if query.Page > 1 {
+		links = append(links, link(min(query.Page-1, lastPage), "prev"))
+	}
+	if query.Page < lastPage {
+		links = append(links, link(query.Page+1, "next"))
+	}
+	links = append(links, link(lastPage, "last"))
+
+	return strings.Join(links, ", ")
+}
+
+func requestUserID(r *http.Request) int {
+	authHeader := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+
+	token := strings.TrimPrefix(authHeader, prefix)
+	token = strings.TrimSpace(token)
+
+	info, err := redis.ParseToken(token)
+	if err != nil {
+		return 0
+	}
+	return info.ID
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"order_processing_system/product_service/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type VariantHandler interface {
+	VariantList(w http.ResponseWriter, r *http.Request)
+	VariantCreate(w http.ResponseWriter, r *http.Request)
+	VariantUpdate(w http.ResponseWriter, r *http.Request)
+	VariantDelete(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) VariantList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	variants, err := c.s.GetVariants(id)
+	if err != nil {
+		http.Error(w, "No such product", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(variants)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) VariantCreate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var variant utils.ProductVariant
+	err := json.NewDecoder(r.Body).Decode(&variant)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	err = c.s.CreateVariant(id, &variant, requestUserID(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(variant)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) VariantUpdate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	variantID := vars["variant_id"]
+
+	var newVariant utils.ProductVariant
+	err := json.NewDecoder(r.Body).Decode(&newVariant)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	variant, err := c.s.UpdateVariant(id, variantID, newVariant)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(variant)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) VariantDelete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	variantID := vars["variant_id"]
+
+	err := c.s.RemoveVariant(id, variantID, requestUserID(r))
+	if errors.Is(err, utils.ErrVariantHasOrders) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"order_processing_system/product_service/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type WarehouseHandler interface {
+	WarehouseList(w http.ResponseWriter, r *http.Request)
+	WarehouseCreate(w http.ResponseWriter, r *http.Request)
+	WarehouseUpdate(w http.ResponseWriter, r *http.Request)
+	WarehouseStockAdjust(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) WarehouseList(w http.ResponseWriter, r *http.Request) {
+	warehouses, err := c.s.GetWarehouses()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(warehouses)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) WarehouseCreate(w http.ResponseWriter, r *http.Request) {
+	var warehouse utils.Warehouse
+	err := json.NewDecoder(r.Body).Decode(&warehouse)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	err = c.s.CreateWarehouse(&warehouse)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(warehouse)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) WarehouseUpdate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var newWarehouse utils.Warehouse
+	err := json.NewDecoder(r.Body).Decode(&newWarehouse)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	warehouse, err := c.s.UpdateWarehouse(id, newWarehouse)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(warehouse)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) WarehouseStockAdjust(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	productID := vars["product_id"]
+
+	var adjustment utils.StockAdjustment
+	err := json.NewDecoder(r.Body).Decode(&adjustment)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	stock, err := c.s.AdjustWarehouseStock(id, productID, adjustment, requestUserID(r))
+	if errors.Is(err, utils.ErrNegativeStock) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(stock)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
@@ -17,3 +17,7 @@ func NewNATS(url string) *ProductNATS {
 func (n *ProductNATS) Publish(subject string, data []byte) error {
 	return n.Conn.Publish(subject, data)
 }
+
+func (n *ProductNATS) Subscribe(subject string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	return n.Conn.Subscribe(subject, cb)
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"order_processing_system/openapi"
 	"order_processing_system/product_service/internal/controllers"
 	"order_processing_system/product_service/internal/middleware"
 
@@ -12,18 +13,66 @@ import (
 func NewServer(c *controllers.Controller) *http.Server {
 	r := mux.NewRouter().StrictSlash(true)
 
+	spec := openapi.MustLoad("product")
+	r.Use(spec.ValidateRequest)
+
+	r.HandleFunc("/openapi.json", spec.Handler).Methods("GET")
+	r.HandleFunc("/docs", openapi.SwaggerUI).Methods("GET")
+
 	productRouter := r.PathPrefix("/api/products").Subrouter()
 
 	productRouter.HandleFunc("", c.ProductList).Methods("GET")
+	productRouter.HandleFunc("/search", c.ProductSearch).Methods("GET")
 	productRouter.HandleFunc("/{id}", c.ProductDetail).Methods("GET")
 	productRouter.HandleFunc("/{id}/stock", c.ProductStock).Methods("GET")
+	productRouter.HandleFunc("/{id}/variants", c.VariantList).Methods("GET")
+
+	subscriptionRouter := r.PathPrefix("/api/products").Subrouter()
+	subscriptionRouter.Use(middleware.IsAuthenticated)
+
+	subscriptionRouter.HandleFunc("/{id}/stock/subscriptions", c.ProductStockSubscribe).Methods("POST")
+	subscriptionRouter.HandleFunc("/{id}/stock/subscriptions", c.ProductStockUnsubscribe).Methods("DELETE")
 
 	adminRouter := r.PathPrefix("/api/products").Subrouter()
 	adminRouter.Use(middleware.IsAdmin)
 
-	adminRouter.HandleFunc("", c.ProductCreate).Methods("POST")        // admin
-	adminRouter.HandleFunc("/{id}", c.ProductUpdate).Methods("PUT")    // admin
-	adminRouter.HandleFunc("/{id}", c.ProductDelete).Methods("DELETE") // admin
+	adminRouter.HandleFunc("", c.ProductCreate).Methods("POST")                              // admin
+	adminRouter.HandleFunc("/{id}", c.ProductUpdate).Methods("PUT")                          // admin
+	adminRouter.HandleFunc("/{id}", c.ProductDelete).Methods("DELETE")                       // admin
+	adminRouter.HandleFunc("/{id}/stock/movements", c.ProductStockMovements).Methods("GET")  // admin
+	adminRouter.HandleFunc("/{id}/stock/adjustments", c.ProductStockAdjust).Methods("POST")  // admin
+	adminRouter.HandleFunc("/{id}/stock/threshold", c.ProductStockThreshold).Methods("PUT")  // admin
+	adminRouter.HandleFunc("/{id}/categories", c.ProductCategoriesUpdate).Methods("PUT")     // admin
+	adminRouter.HandleFunc("/{id}/variants", c.VariantCreate).Methods("POST")                // admin
+	adminRouter.HandleFunc("/{id}/variants/{variant_id}", c.VariantUpdate).Methods("PUT")    // admin
+	adminRouter.HandleFunc("/{id}/variants/{variant_id}", c.VariantDelete).Methods("DELETE") // admin
+
+	catalogRouter := r.PathPrefix("/api/catalog").Subrouter()
+	catalogRouter.Use(middleware.IsAdmin)
+
+	catalogRouter.HandleFunc("/import", c.ProductImport).Methods("POST") // admin
+	catalogRouter.HandleFunc("/export", c.ProductExport).Methods("GET")  // admin
+
+	categoryRouter := r.PathPrefix("/api/categories").Subrouter()
+
+	categoryRouter.HandleFunc("", c.CategoryList).Methods("GET")
+	categoryRouter.HandleFunc("/{id}", c.CategoryDetail).Methods("GET")
+	categoryRouter.HandleFunc("/{id}/products", c.CategoryProducts).Methods("GET")
+
+	categoryAdminRouter := r.PathPrefix("/api/categories").Subrouter()
+	categoryAdminRouter.Use(middleware.IsAdmin)
+
+	categoryAdminRouter.HandleFunc("", c.CategoryCreate).Methods("POST")        // admin
+	categoryAdminRouter.HandleFunc("/{id}", c.CategoryUpdate).Methods("PUT")    // admin
+	categoryAdminRouter.HandleFunc("/{id}", c.CategoryDelete).Methods("DELETE") // admin
+
+	warehouseRouter := r.PathPrefix("/api/warehouses").Subrouter()
+	warehouseRouter.Use(middleware.IsAdmin)
+
+	warehouseRouter.HandleFunc("", c.WarehouseList).Methods("GET")                                             // admin
+	warehouseRouter.HandleFunc("", c.WarehouseCreate).Methods("POST")                                          // admin
+	warehouseRouter.HandleFunc("/{id}", c.WarehouseUpdate).Methods("PUT")                                      // admin
+	warehouseRouter.HandleFunc("/{id}/stock/{product_id}/adjustments", c.WarehouseStockAdjust).Methods("POST") // admin
 
 	fmt.Println("http://localhost:8001/api/products/")
 
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"order_processing_system/product_service/utils"
+	"sort"
+	"strconv"
+)
+
+func (s *Service) GetCategoryTree() ([]utils.Category, error) {
+	categories, err := s.PSQLRepo.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	return utils.BuildCategoryTree(categories), nil
+}
+
+func (s *Service) GetCategory(id string) (utils.Category, error) {
+	category_id, err := strconv.Atoi(id)
+	if err != nil {
+		return utils.Category{}, err
+	}
+	return s.PSQLRepo.GetCategoryByID(category_id)
+}
+
+func (s *Service) CreateCategory(category *utils.Category) error {
+	err := category.Validate()
+	if err != nil {
+		return err
+	}
+
+	if category.ParentID != nil {
+		_, err = s.PSQLRepo.GetCategoryByID(*category.ParentID)
+		if err != nil {
+			return errors.New("parent category not found")
+		}
+	}
+
+	return s.PSQLRepo.PostCategory(category)
+}
+
+func (s *Service) UpdateCategory(category utils.Category) (utils.Category, error) {
+	err := category.Validate()
+	if err != nil {
+		return utils.Category{}, err
+	}
+
+	if category.ParentID != nil {
+		_, err = s.PSQLRepo.GetCategoryByID(*category.ParentID)
+		if err != nil {
+			return utils.Category{}, errors.New("parent category not found")
+		}
+
+		cycle, err := s.PSQLRepo.IsCategoryInSubtree(category.ID, *category.ParentID)
+		if err != nil {
+			return utils.Category{}, err
+		}
+		if cycle {
+			return utils.Category{}, errors.New("category cannot be moved under itself or its subcategory")
+		}
+	}
+
+	updated, err := s.PSQLRepo.PutCategory(category)
+	if err != nil {
+		return utils.Category{}, err
+	}
+
+	// a moved category takes its products to the lists of its new parents
+	s.RedisRepo.InvalidateCatalog()
+	return updated, nil
+}
+
+func (s *Service) RemoveCategory(id string) error {
+	category_id, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	err = s.PSQLRepo.DeleteCategory(category_id)
+	if err != nil {
+		return err
+	}
+
+	s.RedisRepo.InvalidateCatalog()
+	return nil
+}
+
+func (s *Service) GetCategoryProducts(id string, query utils.ProductQuery) (utils.ProductPage, error) {
+	category, err := s.GetCategory(id)
+	if err != nil {
+		return utils.ProductPage{}, err
+	}
+
+	query.CategoryID = category.ID
+	return s.GetAllProducts(query)
+}
+
+func (s *Service) AssignProductCategories(id string, categoryIDs []int) (utils.ProductEvent, error) {
+	product, err := s.GetProduct(id)
+	if err != nil {
+		return utils.ProductEvent{}, err
+	}
+
+	unique := map[int]bool{}
+	newIDs := []int{}
+	for _, categoryID := range categoryIDs {
+		if unique[categoryID] {
+			continue
+		}
+		_, err := s.PSQLRepo.GetCategoryByID(categoryID)
+		if err != nil {
+			return utils.ProductEvent{}, fmt.Errorf("category %d not found", categoryID)
+		}
+		unique[categoryID] = true
+		newIDs = append(newIDs, categoryID)
+	}
+	sort.Ints(newIDs)
+
+	oldIDs, err := s.PSQLRepo.GetProductCategoryIDs(product.ID)
+	if err != nil {
+		return utils.ProductEvent{}, err
+	}
+
+	err = s.PSQLRepo.SetProductCategories(product.ID, newIDs)
+	if err != nil {
+		return utils.ProductEvent{}, err
+	}
+	s.RedisRepo.InvalidateCatalog()
+
+	added, removed := utils.DiffIDs(oldIDs, newIDs)
+	event := utils.ProductEvent{
+		Product:            product,
+		CategoryIDs:        newIDs,
+		AddedCategoryIDs:   added,
+		RemovedCategoryIDs: removed,
+	}
+
+	// NATS product updated
+
+	eventData, err := json.Marshal(event)
+	if err != nil {
+		return utils.ProductEvent{}, err
+	}
+
+	err = s.NATSClient.Publish("product.updated", eventData)
+	if err != nil {
+		return utils.ProductEvent{}, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"order_processing_system/product_service/utils"
+	"strings"
+)
+
+// ImportProducts validates the rows of an import and, unless it is a dry run,
+// creates or updates the products by SKU. An atomic import writes nothing
+// when a row is invalid, otherwise the valid rows are committed in chunks and
+// a chunk that fails does not stop the next ones.
+func (s *Service) ImportProducts(rows []utils.ProductImportRow, options utils.ProductImportOptions, actorID int) (utils.ProductImportReport, error) {
+	report := utils.ProductImportReport{
+		DryRun:  options.DryRun,
+		Atomic:  options.Atomic,
+		Results: make([]utils.ProductImportResult, len(rows)),
+	}
+
+	skus := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.Product.SKU != nil {
+			skus = append(skus, strings.TrimSpace(*row.Product.SKU))
+		}
+	}
+
+	existing, err := s.PSQLRepo.GetProductIDsBySKU(skus)
+	if err != nil {
+		return utils.ProductImportReport{}, err
+	}
+
+	seen := map[string]int{}
+	var valid []int
+	for i := range rows {
+		row := &rows[i]
+		result := &report.Results[i]
+		result.Row = row.Row
+		if row.Product.SKU != nil {
+			result.SKU = strings.TrimSpace(*row.Product.SKU)
+		}
+
+		id, exists := existing[result.SKU]
+		err := row.Check(exists)
+		if first, ok := seen[result.SKU]; ok && err == nil {
+			err = fmt.Errorf("sku %s repeats row %d", result.SKU, first)
+		}
+		if err != nil {
+			result.Result = utils.ImportInvalid
+			result.Error = err.Error()
+			report.Invalid++
+			continue
+		}
+
+		seen[result.SKU] = row.Row
+		result.ProductID = id
+		result.Result = utils.ImportValid
+		valid = append(valid, i)
+	}
+
+	if options.DryRun || len(valid) == 0 || (options.Atomic && report.Invalid > 0) {
+		return report, nil
+	}
+
+	chunkSize := utils.ImportChunkSize
+	if options.Atomic {
+		chunkSize = len(valid)
+	}
+
+	var event utils.ProductImportEvent
+	movement := utils.NewMovement(utils.ReasonRestock, "import", actorID)
+	for start := 0; start < len(valid); start += chunkSize {
+		chunk := valid[start:min(start+chunkSize, len(valid))]
+
+		products := make([]utils.Product, len(chunk))
+		for j, i := range chunk {
+			products[j] = rows[i].Product
+		}
+
+		created, err := s.PSQLRepo.UpsertProducts(products, movement)
+		if err != nil {
+			log.Println(err)
+			for _, i := range chunk {
+				report.Results[i].Result = utils.ImportFailed
+				report.Results[i].Error = err.Error()
+				report.Failed++
+			}
+			continue
+		}
+
+		for j, i := range chunk {
+			result := &report.Results[i]
+			result.ProductID = products[j].ID
+			if created[j] {
+				result.Result = utils.ImportCreated
+				report.Created++
+				event.Created = append(event.Created, products[j].ID)
+			} else {
+				result.Result = utils.ImportUpdated
+				report.Updated++
+				event.Updated = append(event.Updated, products[j].ID)
+				s.RedisRepo.Delete(fmt.Sprintf("product_%d", products[j].ID))
+			}
+		}
+	}
+
+	if report.Created+report.Updated == 0 {
+		return report, nil
+	}
+	s.RedisRepo.InvalidateCatalog()
+
+	// NATS products imported, the products are written already so a failed
+	// publish is only logged
+
+	eventData, err := json.Marshal(event)
+	if err != nil {
+		log.Println(err)
+		return report, nil
+	}
+
+	err = s.NATSClient.Publish("product.imported", eventData)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return report, nil
+}
+
+// ExportProducts streams the catalog to the exporter, in the columns imports
+// read back.
+func (s *Service) ExportProducts(exporter utils.ProductExporter) error {
+	err := s.PSQLRepo.ExportProducts(exporter.Write)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	return exporter.Flush()
+}
@@ -24,26 +24,52 @@ func NewService(psqlRepo *psql.PostgresRepo, redisRepo *redis.RedisRepo, natsCli
 	}
 }
 
-func (s *Service) GetAllProducts() ([]utils.Product, error) {
-	cacheKey := "products_all"
+func (s *Service) GetAllProducts(query utils.ProductQuery) (utils.ProductPage, error) {
+	cacheKey := s.RedisRepo.CatalogKey(query.CacheKey())
 	cached, err := s.RedisRepo.GetData(cacheKey)
 	if err == nil {
-		var products []utils.Product
-		if err := json.Unmarshal([]byte(cached), &products); err == nil {
-			return products, nil
+		var page utils.ProductPage
+		if err := json.Unmarshal([]byte(cached), &page); err == nil {
+			return page, nil
 		}
 	}
 
-	products, err := s.PSQLRepo.GetProductsList()
+	products, total, err := s.PSQLRepo.GetProductsList(query)
 	if err != nil {
-		return []utils.Product{}, err
+		return utils.ProductPage{}, err
+	}
+	page := utils.ProductPage{
+		Products: products,
+		Total:    total,
 	}
 
-	jsonData, err := json.Marshal(products)
+	jsonData, err := json.Marshal(page)
 	if err == nil {
 		s.RedisRepo.SetCache(cacheKey, jsonData)
 	}
-	return products, nil
+	return page, nil
+}
+
+func (s *Service) SearchProducts(search utils.ProductSearch) ([]utils.ProductSearchResult, error) {
+	cacheKey := s.RedisRepo.CatalogKey(search.CacheKey())
+	cached, err := s.RedisRepo.GetData(cacheKey)
+	if err == nil {
+		var results []utils.ProductSearchResult
+		if err := json.Unmarshal([]byte(cached), &results); err == nil {
+			return results, nil
+		}
+	}
+
+	results, err := s.PSQLRepo.SearchProducts(search.TSQuery, search.Text, search.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, err := json.Marshal(results)
+	if err == nil {
+		s.RedisRepo.SetCache(cacheKey, jsonData)
+	}
+	return results, nil
 }
 
 func (s *Service) GetProduct(id string) (utils.Product, error) {
@@ -92,23 +118,29 @@ func (s *Service) GetProductStock(id string) (utils.ProductStock, error) {
 		return utils.ProductStock{}, err
 	}
 
+	productStock.Warehouses, err = s.PSQLRepo.GetWarehouseStock(product_id)
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+
 	jsonData, err := json.Marshal(productStock)
 	if err == nil {
 		s.RedisRepo.SetCache(cacheKey, jsonData)
 	}
-	return s.PSQLRepo.GetProductQuantity(product_id)
+	return productStock, nil
 }
 
-func (s *Service) CreateProduct(product *utils.Product) error {
+func (s *Service) CreateProduct(product *utils.Product, actorID int) error {
 	err := product.Validate()
 	if err != nil {
 		return err
 	}
 
-	err = s.PSQLRepo.PostProduct(product)
+	err = s.PSQLRepo.PostProduct(product, utils.NewMovement(utils.ReasonRestock, "", actorID))
 	if err != nil {
 		return err
 	}
+	s.RedisRepo.InvalidateCatalog()
 
 	// NATS product created
 
@@ -126,7 +158,7 @@ func (s *Service) CreateProduct(product *utils.Product) error {
 }
 
 func (s *Service) UpdateProduct(newProduct utils.Product) (utils.Product, error) {
-	err := newProduct.Validate()
+	err := newProduct.ValidateDetails()
 	if err != nil {
 		return utils.Product{}, err
 	}
@@ -137,10 +169,16 @@ func (s *Service) UpdateProduct(newProduct utils.Product) (utils.Product, error)
 	if err != nil {
 		return utils.Product{}, err
 	}
+	s.RedisRepo.InvalidateCatalog()
+
+	categoryIDs, err := s.PSQLRepo.GetProductCategoryIDs(product.ID)
+	if err != nil {
+		return utils.Product{}, err
+	}
 
 	// NATS product updated
 
-	productData, err := json.Marshal(product)
+	productData, err := json.Marshal(utils.ProductEvent{Product: product, CategoryIDs: categoryIDs})
 	if err != nil {
 		return utils.Product{}, err
 	}
@@ -153,6 +191,53 @@ func (s *Service) UpdateProduct(newProduct utils.Product) (utils.Product, error)
 	return product, nil
 }
 
+func (s *Service) AdjustStock(id string, adjustment utils.StockAdjustment, actorID int) (utils.ProductStock, error) {
+	err := adjustment.Validate()
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+
+	product, err := s.GetProduct(id)
+	if err != nil {
+		return utils.ProductStock{}, fmt.Errorf("product %s not found", id)
+	}
+
+	variants, err := s.PSQLRepo.GetProductVariants(product.ID)
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+	if len(variants) > 0 && adjustment.VariantID == nil {
+		return utils.ProductStock{}, fmt.Errorf("product %d has variants, variant_id is required", product.ID)
+	}
+
+	if adjustment.VariantID != nil {
+		variant, err := s.PSQLRepo.GetVariantByID(*adjustment.VariantID)
+		if err != nil || variant.ProductID != product.ID {
+			return utils.ProductStock{}, fmt.Errorf("variant %d not found", *adjustment.VariantID)
+		}
+	}
+	if adjustment.WarehouseID != nil {
+		_, err = s.PSQLRepo.GetWarehouseByID(*adjustment.WarehouseID)
+		if err != nil {
+			return utils.ProductStock{}, fmt.Errorf("warehouse %d not found", *adjustment.WarehouseID)
+		}
+	}
+
+	movement := utils.NewMovement(adjustment.Reason, adjustment.Reference, actorID)
+	err = s.trackStockLevel(product.ID, func(repo *psql.PostgresRepo) error {
+		return repo.AdjustStock(product.ID, adjustment, movement)
+	})
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+
+	s.RedisRepo.Delete("product_" + id)
+	s.RedisRepo.Delete("product_stock_" + id)
+	s.RedisRepo.InvalidateCatalog()
+
+	return s.GetProductStock(id)
+}
+
 func (s *Service) RemoveProduct(id string) error {
 	product_id, err := strconv.Atoi(id)
 	if err != nil {
@@ -165,6 +250,7 @@ func (s *Service) RemoveProduct(id string) error {
 	if err != nil {
 		return err
 	}
+	s.RedisRepo.InvalidateCatalog()
 
 	// NATS product deleted
 
@@ -175,3 +261,28 @@ func (s *Service) RemoveProduct(id string) error {
 
 	return nil
 }
+
+func (s *Service) GetStockLedger(id string, limit int) (utils.StockLedger, error) {
+	product, err := s.GetProduct(id)
+	if err != nil {
+		return utils.StockLedger{}, err
+	}
+
+	movements, err := s.PSQLRepo.GetStockMovements(product.ID, limit)
+	if err != nil {
+		return utils.StockLedger{}, err
+	}
+
+	ledgerTotal, onHand, err := s.PSQLRepo.GetStockBalances(product.ID)
+	if err != nil {
+		return utils.StockLedger{}, err
+	}
+
+	return utils.StockLedger{
+		ProductID:   product.ID,
+		OnHand:      onHand,
+		LedgerTotal: ledgerTotal,
+		InSync:      ledgerTotal == onHand,
+		Movements:   movements,
+	}, nil
+}
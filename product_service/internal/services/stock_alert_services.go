@@ -0,0 +1,100 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"order_processing_system/db/psql"
+	"order_processing_system/product_service/utils"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+func (s *Service) SetLowStockThreshold(id string, input utils.StockThresholdInput) (utils.ProductStock, error) {
+	product_id, err := strconv.Atoi(id)
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+
+	err = input.Validate()
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+
+	err = s.PSQLRepo.SetLowStockThreshold(product_id, input.Threshold)
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+
+	s.RedisRepo.Delete("product_stock_" + id)
+	return s.GetProductStock(id)
+}
+
+func (s *Service) SubscribeBackInStock(id string, user_id int) error {
+	product, err := s.GetProduct(id)
+	if err != nil {
+		return fmt.Errorf("product %s not found", id)
+	}
+	return s.PSQLRepo.PostStockSubscription(product.ID, user_id)
+}
+
+func (s *Service) UnsubscribeBackInStock(id string, user_id int) error {
+	product_id, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.PSQLRepo.DeleteStockSubscription(product_id, user_id)
+}
+
+// trackStockLevel runs a stock change and publishes the threshold events it
+// caused.
+func (s *Service) trackStockLevel(productID int, change func(repo *psql.PostgresRepo) error) error {
+	changes, err := s.PSQLRepo.WithStockLevels([]int{productID}, change)
+
+	// NATS stock level events
+
+	utils.PublishStockLevels(s.NATSClient.Publish, changes)
+	return err
+}
+
+// ListenStockEvents fans every restock out to the customers waiting for the
+// product, one notification per subscriber.
+func (s *Service) ListenStockEvents() error {
+	s.NATSClient.Subscribe(utils.SubjectRestocked, func(msg *nats.Msg) {
+		var level utils.StockLevel
+		err := json.Unmarshal(msg.Data, &level)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		notifications, err := s.PSQLRepo.ClaimStockSubscriptions(level.ProductID)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		for _, notification := range notifications {
+			notificationData, err := json.Marshal(notification)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			err = s.NATSClient.Publish(utils.SubjectBackInStock, notificationData)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+		log.Printf("Product with id %d restocked, notified %d subscribers", level.ProductID, len(notifications))
+	})
+
+	err := s.NATSClient.Conn.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Listening for 'product.restocked' messages...")
+	select {}
+}
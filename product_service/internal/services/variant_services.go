@@ -0,0 +1,96 @@
+package services
+
+import (
+	"encoding/json"
+	"order_processing_system/db/psql"
+	"order_processing_system/product_service/utils"
+	"strconv"
+)
+
+func (s *Service) GetVariants(productID string) ([]utils.ProductVariant, error) {
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.PSQLRepo.GetProductVariants(product.ID)
+}
+
+func (s *Service) CreateVariant(productID string, variant *utils.ProductVariant, actorID int) error {
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return err
+	}
+
+	variant.ProductID = product.ID
+	err = variant.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = s.trackStockLevel(product.ID, func(repo *psql.PostgresRepo) error {
+		return repo.PostVariant(variant, utils.NewMovement(utils.ReasonRestock, "", actorID))
+	})
+	if err != nil {
+		return err
+	}
+	s.RedisRepo.InvalidateCatalog()
+
+	return s.publishVariant("product.variant.created", *variant)
+}
+
+// UpdateVariant changes the details of a variant, its stock only changes
+// through stock adjustments.
+func (s *Service) UpdateVariant(productID string, variantID string, variant utils.ProductVariant) (utils.ProductVariant, error) {
+	product_id, err := strconv.Atoi(productID)
+	if err != nil {
+		return utils.ProductVariant{}, err
+	}
+	variant_id, err := strconv.Atoi(variantID)
+	if err != nil {
+		return utils.ProductVariant{}, err
+	}
+
+	variant.ID = variant_id
+	variant.ProductID = product_id
+	err = variant.ValidateDetails()
+	if err != nil {
+		return utils.ProductVariant{}, err
+	}
+
+	updated, err := s.PSQLRepo.PutVariant(variant)
+	if err != nil {
+		return utils.ProductVariant{}, err
+	}
+	s.RedisRepo.InvalidateCatalog()
+
+	return updated, s.publishVariant("product.variant.updated", updated)
+}
+
+func (s *Service) RemoveVariant(productID string, variantID string, actorID int) error {
+	product_id, err := strconv.Atoi(productID)
+	if err != nil {
+		return err
+	}
+	variant_id, err := strconv.Atoi(variantID)
+	if err != nil {
+		return err
+	}
+
+	err = s.trackStockLevel(product_id, func(repo *psql.PostgresRepo) error {
+		return repo.DeleteVariant(product_id, variant_id, utils.NewMovement(utils.ReasonManualAdjustment, "variant_deleted", actorID))
+	})
+	if err != nil {
+		return err
+	}
+	s.RedisRepo.InvalidateCatalog()
+
+	return s.publishVariant("product.variant.deleted", utils.ProductVariant{ID: variant_id, ProductID: product_id})
+}
+
+func (s *Service) publishVariant(subject string, variant utils.ProductVariant) error {
+	variantData, err := json.Marshal(variant)
+	if err != nil {
+		return err
+	}
+	return s.NATSClient.Publish(subject, variantData)
+}
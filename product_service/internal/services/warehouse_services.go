@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"order_processing_system/product_service/utils"
+	"strconv"
+)
+
+func (s *Service) GetWarehouses() ([]utils.Warehouse, error) {
+	return s.PSQLRepo.GetWarehouses()
+}
+
+func (s *Service) CreateWarehouse(warehouse *utils.Warehouse) error {
+	err := warehouse.Validate()
+	if err != nil {
+		return err
+	}
+	return s.PSQLRepo.PostWarehouse(warehouse)
+}
+
+func (s *Service) UpdateWarehouse(id string, warehouse utils.Warehouse) (utils.Warehouse, error) {
+	warehouse_id, err := strconv.Atoi(id)
+	if err != nil {
+		return utils.Warehouse{}, err
+	}
+
+	warehouse.ID = warehouse_id
+	err = warehouse.Validate()
+	if err != nil {
+		return utils.Warehouse{}, err
+	}
+
+	return s.PSQLRepo.PutWarehouse(warehouse)
+}
+
+// AdjustWarehouseStock applies a signed stock adjustment to the stock of a
+// product in one warehouse. Variants are stocked in the default warehouse
+// only, their stock is adjusted through AdjustStock with a variant.
+func (s *Service) AdjustWarehouseStock(warehouseID string, productID string, adjustment utils.StockAdjustment, actorID int) (utils.ProductStock, error) {
+	warehouse_id, err := strconv.Atoi(warehouseID)
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+
+	product_id, err := strconv.Atoi(productID)
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+
+	variants, err := s.PSQLRepo.GetProductVariants(product_id)
+	if err != nil {
+		return utils.ProductStock{}, err
+	}
+	if len(variants) > 0 || adjustment.VariantID != nil {
+		return utils.ProductStock{}, errors.New("variant products are adjusted through /stock/adjustments with variant_id")
+	}
+
+	adjustment.WarehouseID = &warehouse_id
+	return s.AdjustStock(productID, adjustment, actorID)
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"fmt"
+)
+
+type Category struct {
+	ID          int        `db:"id" json:"id"`
+	Name        string     `db:"name" json:"name"`
+	Description string     `db:"description" json:"description"`
+	ParentID    *int       `db:"parent_id" json:"parent_id"`
+	Children    []Category `db:"-" json:"children,omitempty"`
+}
+
+type CategoryAssignment struct {
+	CategoryIDs []int `json:"category_ids"`
+}
+
+type ProductEvent struct {
+	Product
+	CategoryIDs        []int `json:"category_ids"`
+	AddedCategoryIDs   []int `json:"added_category_ids,omitempty"`
+	RemovedCategoryIDs []int `json:"removed_category_ids,omitempty"`
+}
+
+func (c *Category) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if c.ParentID != nil && *c.ParentID == c.ID && c.ID != 0 {
+		return fmt.Errorf("category cannot be its own parent")
+	}
+	return nil
+}
+
+func BuildCategoryTree(categories []Category) []Category {
+	children := map[int][]Category{}
+	var roots []Category
+
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var attach func(nodes []Category) []Category
+	attach = func(nodes []Category) []Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+
+	tree := attach(roots)
+	if tree == nil {
+		return []Category{}
+	}
+	return tree
+}
+
+func DiffIDs(old, new []int) (added []int, removed []int) {
+	oldSet := map[int]bool{}
+	for _, id := range old {
+		oldSet[id] = true
+	}
+	newSet := map[int]bool{}
+	for _, id := range new {
+		newSet[id] = true
+		if !oldSet[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range old {
+		if !newSet[id] {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCategoryValidate(t *testing.T) {
+	parent := 3
+
+	tests := []struct {
+		name     string
+		category Category
+		wantErr  bool
+	}{
+		{"root", Category{Name: "Clothing"}, false},
+		{"child", Category{ID: 4, Name: "Shirts", ParentID: &parent}, false},
+		{"new child", Category{Name: "Shirts", ParentID: &parent}, false},
+		{"missing name", Category{ParentID: &parent}, true},
+		{"own parent", Category{ID: 3, Name: "Shirts", ParentID: &parent}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.category.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	one, two := 1, 2
+
+	tests := []struct {
+		name       string
+		categories []Category
+		want       []Category
+	}{
+		{
+			name: "no categories",
+			want: []Category{},
+		},
+		{
+			name: "nested",
+			categories: []Category{
+				{ID: 1, Name: "Clothing"},
+				{ID: 2, Name: "Shirts", ParentID: &one},
+				{ID: 3, Name: "T-shirts", ParentID: &two},
+				{ID: 4, Name: "Shoes", ParentID: &one},
+				{ID: 5, Name: "Books"},
+			},
+			want: []Category{
+				{ID: 1, Name: "Clothing", Children: []Category{
+					{ID: 2, Name: "Shirts", ParentID: &one, Children: []Category{
+						{ID: 3, Name: "T-shirts", ParentID: &two},
+					}},
+					{ID: 4, Name: "Shoes", ParentID: &one},
+				}},
+				{ID: 5, Name: "Books"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BuildCategoryTree(tt.categories); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildCategoryTree() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     []int
+		new     []int
+		added   []int
+		removed []int
+	}{
+		{"unchanged", []int{1, 2}, []int{1, 2}, nil, nil},
+		{"added", []int{1}, []int{1, 2, 3}, []int{2, 3}, nil},
+		{"removed", []int{1, 2, 3}, []int{2}, nil, []int{1, 3}},
+		{"replaced", []int{1, 2}, []int{2, 4}, []int{4}, []int{1}},
+		{"cleared", []int{1}, []int{}, nil, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := DiffIDs(tt.old, tt.new)
+			if !reflect.DeepEqual(added, tt.added) || !reflect.DeepEqual(removed, tt.removed) {
+				t.Errorf("DiffIDs() = %v, %v, want %v, %v", added, removed, tt.added, tt.removed)
+			}
+		})
+	}
+}
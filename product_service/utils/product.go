@@ -2,30 +2,322 @@ package utils
 
 import (
 	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 )
 
+// Availability decides whether a product can be ordered beyond its stock.
+const (
+	AvailabilityStock     = "stock"
+	AvailabilityBackorder = "backorder"
+	AvailabilityPreorder  = "preorder"
+)
+
+// DefaultTaxClass is the tax class of products that do not name one.
+const DefaultTaxClass = "standard"
+
+// MaxSKULength is the size of the sku columns of products and variants.
+const MaxSKULength = 64
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+var productSortColumns = map[string]string{
+	"id":    "id",
+	"name":  "name",
+	"price": "price",
+	"stock": "stock_quantity",
+}
+
 type Product struct {
-	ID            int     `db:"id" json:"id"`
-	Name          string  `db:"name" json:"name"`
-	Description   string  `db:"description" json:"description"`
-	Price         float64 `db:"price" json:"price"`
-	StockQuantity int     `db:"stock_quantity" json:"stock"`
+	ID            int        `db:"id" json:"id"`
+	SKU           *string    `db:"sku" json:"sku,omitempty"`
+	Name          string     `db:"name" json:"name"`
+	Description   string     `db:"description" json:"description"`
+	Price         float64    `db:"price" json:"price"`
+	StockQuantity int        `db:"stock_quantity" json:"stock"`
+	Availability  string     `db:"availability" json:"availability"`
+	ExpectedAt    *time.Time `db:"expected_at" json:"expected_at,omitempty"`
+	TaxClass      string     `db:"tax_class" json:"tax_class"`
+	// Weight is in kilograms, weight-based shipping rates use it.
+	Weight float64 `db:"weight" json:"weight"`
 }
 
+// ProductStock reports the stock on hand and the part of it that is not held
+// by active checkout reservations.
 type ProductStock struct {
-	ID            int `db:"id" json:"id"`
-	StockQuantity int `db:"stock_quantity" json:"stock"`
+	ID                int              `db:"id" json:"id"`
+	StockQuantity     int              `db:"stock_quantity" json:"stock"`
+	Reserved          int              `db:"-" json:"reserved"`
+	Available         int              `db:"-" json:"available"`
+	LowStockThreshold int              `db:"low_stock_threshold" json:"low_stock_threshold"`
+	Warehouses        []WarehouseStock `db:"-" json:"warehouses"`
 }
 
 func (p *Product) Validate() error {
+	err := p.ValidateDetails()
+	if err != nil {
+		return err
+	}
+	if p.StockQuantity < 0 || (p.StockQuantity == 0 && !p.AcceptsBackorders()) {
+		return fmt.Errorf("stock quantity must be greater than 0")
+	}
+	return nil
+}
+
+// ValidateDetails checks everything but the stock, which is only changed
+// through stock adjustments once the product exists.
+func (p *Product) ValidateDetails() error {
+	if p.SKU != nil {
+		sku := strings.TrimSpace(*p.SKU)
+		if len(sku) > MaxSKULength {
+			return fmt.Errorf("sku must be at most %d characters", MaxSKULength)
+		}
+		p.SKU = &sku
+		if sku == "" {
+			p.SKU = nil
+		}
+	}
 	if p.Name == "" {
 		return fmt.Errorf("name is required")
 	}
 	if p.Price <= 0 {
 		return fmt.Errorf("price must be greater than 0")
 	}
-	if p.StockQuantity <= 0 {
-		return fmt.Errorf("stock quantity must be greater than 0")
+	if p.Weight < 0 {
+		return fmt.Errorf("weight must not be negative")
+	}
+
+	if p.Availability == "" {
+		p.Availability = AvailabilityStock
+	}
+	switch p.Availability {
+	case AvailabilityStock, AvailabilityBackorder:
+	case AvailabilityPreorder:
+		if p.ExpectedAt == nil {
+			return fmt.Errorf("expected_at is required for pre-order products")
+		}
+	default:
+		return fmt.Errorf("availability must be one of %s, %s, %s", AvailabilityStock, AvailabilityBackorder, AvailabilityPreorder)
+	}
+
+	p.TaxClass = strings.ToLower(strings.TrimSpace(p.TaxClass))
+	if p.TaxClass == "" {
+		p.TaxClass = DefaultTaxClass
 	}
 	return nil
 }
+
+// AcceptsBackorders reports whether orders beyond the stock are accepted and
+// wait for incoming stock.
+func (p *Product) AcceptsBackorders() bool {
+	return p.Availability == AvailabilityBackorder || p.Availability == AvailabilityPreorder
+}
+
+type ProductSearchResult struct {
+	Product
+	Rank          float64 `db:"rank" json:"rank"`
+	NameHighlight string  `db:"name_highlight" json:"name_highlight"`
+	Snippet       string  `db:"snippet" json:"snippet"`
+}
+
+// HighlightStart and HighlightStop surround the matches of a search. They
+// are not HTML, so they survive escaping the product text and only then
+// become <mark> tags.
+const (
+	HighlightStart = "\uE000"
+	HighlightStop  = "\uE001"
+)
+
+var highlightReplacer = strings.NewReplacer(HighlightStart, "<mark>", HighlightStop, "</mark>")
+
+// EscapeHighlights turns the highlights into HTML: the product text is
+// escaped and only the match markers become <mark> tags.
+func (r *ProductSearchResult) EscapeHighlights() {
+	r.NameHighlight = highlightReplacer.Replace(html.EscapeString(r.NameHighlight))
+	r.Snippet = highlightReplacer.Replace(html.EscapeString(r.Snippet))
+}
+
+type ProductSearch struct {
+	Text    string
+	TSQuery string
+	Limit   int
+}
+
+type ProductQuery struct {
+	Page       int
+	Limit      int
+	Sort       string
+	MinPrice   *float64
+	MaxPrice   *float64
+	InStock    bool
+	CategoryID int
+}
+
+type ProductPage struct {
+	Products []Product `json:"products"`
+	Total    int       `json:"total"`
+}
+
+func ParseProductQuery(values url.Values) (ProductQuery, error) {
+	query := ProductQuery{
+		Page:  1,
+		Limit: DefaultPageLimit,
+		Sort:  values.Get("sort"),
+	}
+
+	if page := values.Get("page"); page != "" {
+		p, err := strconv.Atoi(page)
+		if err != nil || p < 1 {
+			return ProductQuery{}, fmt.Errorf("page must be a positive integer")
+		}
+		query.Page = p
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > MaxPageLimit {
+			return ProductQuery{}, fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+		}
+		query.Limit = l
+	}
+
+	if minPrice := values.Get("min_price"); minPrice != "" {
+		price, err := strconv.ParseFloat(minPrice, 64)
+		if err != nil || price < 0 {
+			return ProductQuery{}, fmt.Errorf("min_price must be a non-negative number")
+		}
+		query.MinPrice = &price
+	}
+
+	if maxPrice := values.Get("max_price"); maxPrice != "" {
+		price, err := strconv.ParseFloat(maxPrice, 64)
+		if err != nil || price < 0 {
+			return ProductQuery{}, fmt.Errorf("max_price must be a non-negative number")
+		}
+		query.MaxPrice = &price
+	}
+
+	if inStock := values.Get("in_stock"); inStock != "" {
+		b, err := strconv.ParseBool(inStock)
+		if err != nil {
+			return ProductQuery{}, fmt.Errorf("in_stock must be a boolean")
+		}
+		query.InStock = b
+	}
+
+	return query, query.Validate()
+}
+
+func (q *ProductQuery) Validate() error {
+	if _, _, err := q.SortColumn(); err != nil {
+		return err
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && *q.MinPrice > *q.MaxPrice {
+		return fmt.Errorf("min_price must not exceed max_price")
+	}
+	return nil
+}
+
+// SortColumn maps the "sort" parameter (e.g. "price" or "-price") to a
+// whitelisted column and direction, so it can be safely put into ORDER BY.
+func (q *ProductQuery) SortColumn() (string, string, error) {
+	if q.Sort == "" {
+		return "id", "ASC", nil
+	}
+
+	field, direction := q.Sort, "ASC"
+	if strings.HasPrefix(field, "-") {
+		field, direction = field[1:], "DESC"
+	}
+
+	column, ok := productSortColumns[field]
+	if !ok {
+		return "", "", fmt.Errorf("cannot sort by %q, allowed: name, price, stock", field)
+	}
+	return column, direction, nil
+}
+
+func (q *ProductQuery) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+func (q *ProductQuery) Values() url.Values {
+	values := url.Values{}
+	values.Set("page", strconv.Itoa(q.Page))
+	values.Set("limit", strconv.Itoa(q.Limit))
+	if q.Sort != "" {
+		values.Set("sort", q.Sort)
+	}
+	if q.MinPrice != nil {
+		values.Set("min_price", strconv.FormatFloat(*q.MinPrice, 'f', -1, 64))
+	}
+	if q.MaxPrice != nil {
+		values.Set("max_price", strconv.FormatFloat(*q.MaxPrice, 'f', -1, 64))
+	}
+	if q.InStock {
+		values.Set("in_stock", "true")
+	}
+	return values
+}
+
+func (q *ProductQuery) CacheKey() string {
+	if q.CategoryID != 0 {
+		return fmt.Sprintf("category_%d_products_%s", q.CategoryID, q.Values().Encode())
+	}
+	return "products_" + q.Values().Encode()
+}
+
+func ParseProductSearch(values url.Values) (ProductSearch, error) {
+	search := ProductSearch{
+		Text:  strings.TrimSpace(values.Get("q")),
+		Limit: DefaultPageLimit,
+	}
+
+	if search.Text == "" {
+		return ProductSearch{}, fmt.Errorf("search query is required")
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > MaxPageLimit {
+			return ProductSearch{}, fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+		}
+		search.Limit = l
+	}
+
+	// Every word becomes a prefix term ("lap" matches "laptop"); anything
+	// that is not a letter or digit is dropped so user input can never
+	// break the to_tsquery syntax.
+	words := strings.FieldsFunc(strings.ToLower(search.Text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ProductSearch{}, fmt.Errorf("search query must contain letters or digits")
+	}
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+	search.TSQuery = strings.Join(terms, " & ")
+
+	return search, nil
+}
+
+func (s *ProductSearch) Values() url.Values {
+	values := url.Values{}
+	values.Set("q", s.Text)
+	values.Set("limit", strconv.Itoa(s.Limit))
+	return values
+}
+
+func (s *ProductSearch) CacheKey() string {
+	return "products_search_" + s.Values().Encode()
+}
@@ -0,0 +1,383 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"order_processing_system/export"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats of product imports and exports.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+const (
+	MaxImportRows = 5000
+	// ImportChunkSize is the number of products committed together by
+	// imports that are not atomic.
+	ImportChunkSize = 100
+)
+
+// Outcomes of the rows of a product import.
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportValid   = "valid"
+	ImportInvalid = "invalid"
+	ImportFailed  = "failed"
+)
+
+// ProductCSVHeader names the columns of product files, exports write them in
+// this order and imports accept them in any order. The id is informative,
+// imports match products on their sku.
+var ProductCSVHeader = []string{
+	"id", "sku", "name", "description", "price", "stock", "availability", "expected_at", "tax_class", "weight",
+}
+
+// ProductImportRow is one product of an import file. Row counts the products
+// from 1, the CSV header aside. Err is set when the row could not be read.
+type ProductImportRow struct {
+	Row     int
+	Product Product
+	Err     error
+}
+
+type ProductImportOptions struct {
+	// DryRun only validates the rows.
+	DryRun bool
+	// Atomic writes all rows in one transaction, nothing is written when a
+	// row is invalid. Otherwise valid rows are committed in chunks.
+	Atomic bool
+}
+
+type ProductImportResult struct {
+	Row       int    `json:"row"`
+	SKU       string `json:"sku,omitempty"`
+	ProductID int    `json:"product_id,omitempty"`
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ProductImportReport struct {
+	DryRun  bool                  `json:"dry_run"`
+	Atomic  bool                  `json:"atomic"`
+	Created int                   `json:"created"`
+	Updated int                   `json:"updated"`
+	Invalid int                   `json:"invalid"`
+	Failed  int                   `json:"failed"`
+	Results []ProductImportResult `json:"results"`
+}
+
+// ProductImportEvent is published once per import with the ids of the
+// products it wrote. Imports are too large to send the products themselves.
+type ProductImportEvent struct {
+	Created []int `json:"created"`
+	Updated []int `json:"updated"`
+}
+
+func ParseImportOptions(values url.Values) (ProductImportOptions, error) {
+	options := ProductImportOptions{Atomic: true}
+
+	if dryRun := values.Get("dry_run"); dryRun != "" {
+		b, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			return ProductImportOptions{}, fmt.Errorf("dry_run must be a boolean")
+		}
+		options.DryRun = b
+	}
+
+	if atomic := values.Get("atomic"); atomic != "" {
+		b, err := strconv.ParseBool(atomic)
+		if err != nil {
+			return ProductImportOptions{}, fmt.Errorf("atomic must be a boolean")
+		}
+		options.Atomic = b
+	}
+
+	return options, nil
+}
+
+// ParseProductImport reads the products of a CSV file or a JSON array. A
+// malformed file is an error, a row that cannot be read only sets its Err.
+func ParseProductImport(format string, r io.Reader) ([]ProductImportRow, error) {
+	var rows []ProductImportRow
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = parseProductCSV(r)
+	case FormatJSON:
+		rows, err = parseProductJSON(r)
+	default:
+		return nil, fmt.Errorf("unknown import format %q, allowed: %s, %s", format, FormatCSV, FormatJSON)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("the import contains no products")
+	}
+	if len(rows) > MaxImportRows {
+		return nil, fmt.Errorf("at most %d products can be imported at once", MaxImportRows)
+	}
+	return rows, nil
+}
+
+func parseProductJSON(r io.Reader) ([]ProductImportRow, error) {
+	var items []json.RawMessage
+	err := json.NewDecoder(r).Decode(&items)
+	if err != nil {
+		return nil, errors.New("the import must be a JSON array of products")
+	}
+
+	rows := make([]ProductImportRow, len(items))
+	for i, item := range items {
+		rows[i].Row = i + 1
+
+		decoder := json.NewDecoder(bytes.NewReader(item))
+		decoder.DisallowUnknownFields()
+		rows[i].Err = decoder.Decode(&rows[i].Product)
+	}
+	return rows, nil
+}
+
+func parseProductCSV(r io.Reader) ([]ProductImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isProductColumn(name) {
+			return nil, fmt.Errorf("unknown column %q, allowed: %s", name, strings.Join(ProductCSVHeader, ", "))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("column %q repeats", name)
+		}
+		columns[name] = i
+	}
+
+	var rows []ProductImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		row := ProductImportRow{Row: len(rows) + 1}
+		if err != nil {
+			// a record with a wrong number of fields is still returned
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) || !errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				return nil, fmt.Errorf("invalid CSV row %d: %w", row.Row, err)
+			}
+			row.Err = fmt.Errorf("row has %d fields, the header has %d", len(record), len(header))
+		} else {
+			row.Product, row.Err = productFromRecord(record, columns)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func isProductColumn(name string) bool {
+	for _, column := range ProductCSVHeader {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+func productFromRecord(record []string, columns map[string]int) (Product, error) {
+	cell := func(name string) string {
+		i, ok := columns[name]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	product := Product{
+		Name:         cell("name"),
+		Description:  cell("description"),
+		Availability: cell("availability"),
+		TaxClass:     cell("tax_class"),
+	}
+
+	if sku := cell("sku"); sku != "" {
+		product.SKU = &sku
+	}
+
+	var err error
+	if price := cell("price"); price != "" {
+		product.Price, err = strconv.ParseFloat(price, 64)
+		if err != nil {
+			return Product{}, fmt.Errorf("price must be a number")
+		}
+	}
+
+	if stock := cell("stock"); stock != "" {
+		product.StockQuantity, err = strconv.Atoi(stock)
+		if err != nil {
+			return Product{}, fmt.Errorf("stock must be an integer")
+		}
+	}
+
+	if weight := cell("weight"); weight != "" {
+		product.Weight, err = strconv.ParseFloat(weight, 64)
+		if err != nil {
+			return Product{}, fmt.Errorf("weight must be a number")
+		}
+	}
+
+	if expectedAt := cell("expected_at"); expectedAt != "" {
+		t, err := parseExpectedAt(expectedAt)
+		if err != nil {
+			return Product{}, err
+		}
+		product.ExpectedAt = &t
+	}
+
+	return product, nil
+}
+
+// parseExpectedAt accepts a time or a date, which spreadsheets export.
+func parseExpectedAt(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.Parse(time.DateOnly, value)
+	if err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("expected_at must be a RFC 3339 time or a YYYY-MM-DD date")
+}
+
+// Check validates a row to be written. New products are validated in full,
+// existing ones like updates, since imports leave their stock alone.
+func (r *ProductImportRow) Check(exists bool) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if r.Product.SKU == nil || strings.TrimSpace(*r.Product.SKU) == "" {
+		return errors.New("sku is required")
+	}
+	if exists {
+		return r.Product.ValidateDetails()
+	}
+	return r.Product.Validate()
+}
+
+// ProductExporter writes exported products in one file format as they are
+// read.
+type ProductExporter interface {
+	ContentType() string
+	Write(product Product) error
+	Flush() error
+}
+
+// ProductCSVExporter writes the columns of ProductCSVHeader, which imports
+// read back.
+type ProductCSVExporter struct {
+	w *export.CSVWriter
+}
+
+// ProductJSONExporter writes a JSON array of products, one element at a time.
+type ProductJSONExporter struct {
+	w     io.Writer
+	count int
+}
+
+func NewProductExporter(format string, w io.Writer) (ProductExporter, error) {
+	switch format {
+	case FormatCSV, "":
+		return &ProductCSVExporter{w: export.NewCSVWriter(w, ProductCSVHeader)}, nil
+	case FormatJSON:
+		return &ProductJSONExporter{w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q, allowed: %s, %s", format, FormatCSV, FormatJSON)
+}
+
+func (e *ProductCSVExporter) ContentType() string {
+	return "text/csv"
+}
+
+func (e *ProductCSVExporter) Write(product Product) error {
+	sku := ""
+	if product.SKU != nil {
+		sku = *product.SKU
+	}
+	expectedAt := ""
+	if product.ExpectedAt != nil {
+		expectedAt = product.ExpectedAt.UTC().Format(time.RFC3339)
+	}
+
+	return e.w.Write([]string{
+		strconv.Itoa(product.ID),
+		sku,
+		product.Name,
+		product.Description,
+		strconv.FormatFloat(product.Price, 'f', 2, 64),
+		strconv.Itoa(product.StockQuantity),
+		product.Availability,
+		expectedAt,
+		product.TaxClass,
+		strconv.FormatFloat(product.Weight, 'f', -1, 64),
+	})
+}
+
+func (e *ProductCSVExporter) Flush() error {
+	return e.w.Flush()
+}
+
+func (e *ProductJSONExporter) ContentType() string {
+	return "application/json"
+}
+
+func (e *ProductJSONExporter) Write(product Product) error {
+	data, err := json.Marshal(product)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if e.count == 0 {
+		separator = "[\n"
+	}
+	e.count++
+
+	_, err = io.WriteString(e.w, separator)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *ProductJSONExporter) Flush() error {
+	closing := "\n]\n"
+	if e.count == 0 {
+		closing = "[]\n"
+	}
+	_, err := io.WriteString(e.w, closing)
+	return err
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseImportOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    ProductImportOptions
+		wantErr bool
+	}{
+		{name: "defaults", query: "", want: ProductImportOptions{Atomic: true}},
+		{name: "dry run in chunks", query: "dry_run=true&atomic=false", want: ProductImportOptions{DryRun: true}},
+		{name: "dry_run not a boolean", query: "dry_run=yes", wantErr: true},
+		{name: "atomic not a boolean", query: "atomic=maybe", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseImportOptions(values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseImportOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseImportOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProductImport(t *testing.T) {
+	sku := "MUG-1"
+	expected := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	mug := Product{SKU: &sku, Name: "Mug", Price: 9.5, StockQuantity: 3, Weight: 0.4}
+
+	tests := []struct {
+		name     string
+		format   string
+		input    string
+		want     []Product
+		wantRows []bool
+		wantErr  bool
+	}{
+		{
+			name:     "csv with columns in any order",
+			format:   FormatCSV,
+			input:    "Name, sku, stock, price, weight\nMug, MUG-1, 3, 9.5, 0.4\n",
+			want:     []Product{mug},
+			wantRows: []bool{true},
+		},
+		{
+			name:     "csv pre-order with a date",
+			format:   FormatCSV,
+			input:    "sku,name,price,availability,expected_at\nMUG-1,Mug,9.5,preorder,2026-12-01\n",
+			want:     []Product{{SKU: &sku, Name: "Mug", Price: 9.5, Availability: AvailabilityPreorder, ExpectedAt: &expected}},
+			wantRows: []bool{true},
+		},
+		{
+			name:     "csv rows that cannot be read",
+			format:   FormatCSV,
+			input:    "sku,name,price\nMUG-1,Mug,cheap\nMUG-2,Cup\nMUG-3,Bowl,4,extra\n",
+			want:     []Product{{}, {}, {}},
+			wantRows: []bool{false, false, false},
+		},
+		{name: "csv unknown column", format: FormatCSV, input: "sku,colour\nMUG-1,red\n", wantErr: true},
+		{name: "csv repeated column", format: FormatCSV, input: "sku,SKU\nMUG-1,MUG-2\n", wantErr: true},
+		{name: "csv header only", format: FormatCSV, input: "sku,name\n", wantErr: true},
+		{name: "empty csv", format: FormatCSV, input: "", wantErr: true},
+		{
+			name:     "json",
+			format:   FormatJSON,
+			input:    `[{"sku": "MUG-1", "name": "Mug", "price": 9.5, "stock": 3, "weight": 0.4}, {"sku": "MUG-2", "colour": "red"}]`,
+			want:     []Product{mug, {}},
+			wantRows: []bool{true, false},
+		},
+		{name: "json object", format: FormatJSON, input: `{"sku": "MUG-1"}`, wantErr: true},
+		{name: "empty json array", format: FormatJSON, input: `[]`, wantErr: true},
+		{name: "unknown format", format: "xml", input: "<products/>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseProductImport(tt.format, strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProductImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("ParseProductImport() returned %d rows, want %d", len(rows), len(tt.want))
+			}
+			for i, row := range rows {
+				if row.Row != i+1 {
+					t.Errorf("row %d: Row = %d", i+1, row.Row)
+				}
+				if (row.Err == nil) != tt.wantRows[i] {
+					t.Errorf("row %d: Err = %v, want readable %v", i+1, row.Err, tt.wantRows[i])
+				}
+				if row.Err == nil && !reflect.DeepEqual(row.Product, tt.want[i]) {
+					t.Errorf("row %d: Product = %+v, want %+v", i+1, row.Product, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestProductImportRowCheck(t *testing.T) {
+	sku, blank := "MUG-1", " "
+
+	tests := []struct {
+		name    string
+		row     ProductImportRow
+		exists  bool
+		wantErr bool
+	}{
+		{name: "new product", row: ProductImportRow{Product: Product{SKU: &sku, Name: "Mug", Price: 9.5, StockQuantity: 3}}},
+		{name: "update without stock", row: ProductImportRow{Product: Product{SKU: &sku, Name: "Mug", Price: 9.5}}, exists: true},
+		{name: "new product without stock", row: ProductImportRow{Product: Product{SKU: &sku, Name: "Mug", Price: 9.5}}, wantErr: true},
+		{name: "missing sku", row: ProductImportRow{Product: Product{Name: "Mug", Price: 9.5, StockQuantity: 3}}, wantErr: true},
+		{name: "blank sku", row: ProductImportRow{Product: Product{SKU: &blank, Name: "Mug", Price: 9.5, StockQuantity: 3}}, wantErr: true},
+		{name: "unreadable row", row: ProductImportRow{Err: errors.New("price must be a number")}, exists: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := tt.row
+			err := row.Check(tt.exists)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Exported files can be imported back.
+func TestProductExportRoundTrip(t *testing.T) {
+	sku := "MUG-1"
+	expected := time.Date(2026, 12, 1, 9, 30, 0, 0, time.UTC)
+	products := []Product{
+		{ID: 1, SKU: &sku, Name: "Mug", Description: "Stoneware, 350 ml", Price: 9.5, StockQuantity: 3, Availability: AvailabilityStock, Weight: 0.4},
+		{ID: 2, Name: "Cup", Price: 4, Availability: AvailabilityPreorder, ExpectedAt: &expected},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			exporter, err := NewProductExporter(format, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, product := range products {
+				if err := exporter.Write(product); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := exporter.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := ParseProductImport(format, &buf)
+			if err != nil {
+				t.Fatalf("ParseProductImport() error = %v", err)
+			}
+			if len(rows) != len(products) {
+				t.Fatalf("ParseProductImport() returned %d rows, want %d", len(rows), len(products))
+			}
+			for i, row := range rows {
+				if row.Err != nil {
+					t.Fatalf("row %d: %v", row.Row, row.Err)
+				}
+				want := products[i]
+				want.ID = 0
+				row.Product.ID = 0
+				if !reflect.DeepEqual(row.Product, want) {
+					t.Errorf("row %d: Product = %+v, want %+v", row.Row, row.Product, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseProductQuery(t *testing.T) {
+	minPrice, maxPrice := 5.0, 20.5
+
+	tests := []struct {
+		name    string
+		query   string
+		want    ProductQuery
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  ProductQuery{Page: 1, Limit: DefaultPageLimit},
+		},
+		{
+			name:  "all parameters",
+			query: "page=3&limit=50&sort=-price&min_price=5&max_price=20.5&in_stock=true",
+			want:  ProductQuery{Page: 3, Limit: 50, Sort: "-price", MinPrice: &minPrice, MaxPrice: &maxPrice, InStock: true},
+		},
+		{name: "page zero", query: "page=0", wantErr: true},
+		{name: "limit too high", query: "limit=101", wantErr: true},
+		{name: "negative price", query: "min_price=-1", wantErr: true},
+		{name: "min above max", query: "min_price=10&max_price=5", wantErr: true},
+		{name: "in_stock not a boolean", query: "in_stock=yes", wantErr: true},
+		{name: "unknown sort", query: "sort=description", wantErr: true},
+		{name: "sort injection", query: "sort=price%3B%20DROP%20TABLE%20product", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseProductQuery(values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProductQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseProductQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductQuerySortColumn(t *testing.T) {
+	tests := []struct {
+		sort      string
+		column    string
+		direction string
+	}{
+		{"", "id", "ASC"},
+		{"name", "name", "ASC"},
+		{"-price", "price", "DESC"},
+		{"stock", "stock_quantity", "ASC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			q := ProductQuery{Sort: tt.sort}
+			column, direction, err := q.SortColumn()
+			if err != nil || column != tt.column || direction != tt.direction {
+				t.Errorf("SortColumn() = %s, %s, %v, want %s, %s", column, direction, err, tt.column, tt.direction)
+			}
+		})
+	}
+}
+
+func TestProductQueryCacheKey(t *testing.T) {
+	parse := func(query string) ProductQuery {
+		values, _ := url.ParseQuery(query)
+		q, err := ParseProductQuery(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q
+	}
+
+	same := parse("limit=10&page=2&max_price=20")
+	reordered := parse("max_price=20.0&page=2&limit=10")
+	if got, want := same.CacheKey(), reordered.CacheKey(); got != want {
+		t.Errorf("equal queries have the keys %s and %s", got, want)
+	}
+
+	other := parse("limit=10&page=3&max_price=20")
+	if same.CacheKey() == other.CacheKey() {
+		t.Errorf("different pages share the key %s", same.CacheKey())
+	}
+
+	category := same
+	category.CategoryID = 4
+	if category.CacheKey() == same.CacheKey() {
+		t.Errorf("a category list shares the key %s", same.CacheKey())
+	}
+}
+
+// Product updates leave the stock to stock adjustments, creating a product
+// still needs stock to sell.
+func TestProductValidateStock(t *testing.T) {
+	product := Product{Name: "Mug", Price: 10}
+
+	if err := product.ValidateDetails(); err != nil {
+		t.Errorf("ValidateDetails() of a product without stock = %v, want nil", err)
+	}
+	if err := product.Validate(); err == nil {
+		t.Error("Validate() of a product without stock = nil, want an error")
+	}
+}
+
+func TestProductValidateAvailability(t *testing.T) {
+	expected := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		product    Product
+		wantErr    bool
+		wantStatus string
+		backorders bool
+	}{
+		{
+			name:       "defaults to stock",
+			product:    Product{Name: "Mug", Price: 10, StockQuantity: 5},
+			wantStatus: AvailabilityStock,
+		},
+		{
+			name:       "backorder without stock",
+			product:    Product{Name: "Mug", Price: 10, Availability: AvailabilityBackorder},
+			wantStatus: AvailabilityBackorder,
+			backorders: true,
+		},
+		{
+			name:       "pre-order with a date",
+			product:    Product{Name: "Mug", Price: 10, Availability: AvailabilityPreorder, ExpectedAt: &expected},
+			wantStatus: AvailabilityPreorder,
+			backorders: true,
+		},
+		{
+			name:    "pre-order without a date",
+			product: Product{Name: "Mug", Price: 10, Availability: AvailabilityPreorder},
+			wantErr: true,
+		},
+		{
+			name:    "unknown availability",
+			product: Product{Name: "Mug", Price: 10, StockQuantity: 5, Availability: "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "stock product without stock",
+			product: Product{Name: "Mug", Price: 10},
+			wantErr: true,
+		},
+		{
+			name:    "negative stock",
+			product: Product{Name: "Mug", Price: 10, StockQuantity: -1, Availability: AvailabilityBackorder},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := tt.product
+			err := product.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if product.Availability != tt.wantStatus {
+				t.Errorf("Availability = %q, want %q", product.Availability, tt.wantStatus)
+			}
+			if got := product.AcceptsBackorders(); got != tt.backorders {
+				t.Errorf("AcceptsBackorders() = %v, want %v", got, tt.backorders)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseProductSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		tsQuery string
+		limit   int
+		wantErr bool
+	}{
+		{name: "one word", query: "q=lap", tsQuery: "lap:*", limit: DefaultPageLimit},
+		{name: "words are lowercased and joined", query: "q=Gaming+Laptop&limit=5", tsQuery: "gaming:* & laptop:*", limit: 5},
+		{name: "operators are dropped", query: "q=" + url.QueryEscape("usb-c & (hub) | !cable:*"), tsQuery: "usb:* & c:* & hub:* & cable:*", limit: DefaultPageLimit},
+		{name: "letters of any script", query: "q=" + url.QueryEscape("café"), tsQuery: "café:*", limit: DefaultPageLimit},
+		{name: "missing", query: "", wantErr: true},
+		{name: "blank", query: "q=+++", wantErr: true},
+		{name: "no letters or digits", query: "q=" + url.QueryEscape("&|!"), wantErr: true},
+		{name: "limit too high", query: "q=lap&limit=101", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseProductSearch(values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProductSearch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got.TSQuery != tt.tsQuery || got.Limit != tt.limit) {
+				t.Errorf("ParseProductSearch() = %q limit %d, want %q limit %d", got.TSQuery, got.Limit, tt.tsQuery, tt.limit)
+			}
+		})
+	}
+}
+
+func TestProductSearchCacheKey(t *testing.T) {
+	a := ProductSearch{Text: "red shirt", Limit: 20}
+	b := ProductSearch{Text: "red_shirt", Limit: 20}
+	c := ProductSearch{Text: "red shirt", Limit: 10}
+
+	if a.CacheKey() == b.CacheKey() || a.CacheKey() == c.CacheKey() {
+		t.Errorf("different searches share a key: %s, %s, %s", a.CacheKey(), b.CacheKey(), c.CacheKey())
+	}
+}
+
+func TestEscapeHighlights(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"match", "Gaming " + HighlightStart + "Laptop" + HighlightStop, "Gaming <mark>Laptop</mark>"},
+		{"markup in the product text", "<b>" + HighlightStart + "Laptop" + HighlightStop + "</b> & more", "&lt;b&gt;<mark>Laptop</mark>&lt;/b&gt; &amp; more"},
+		{"script", `<script>alert("x")</script>`, "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ProductSearchResult{NameHighlight: tt.text, Snippet: tt.text}
+			result.EscapeHighlights()
+			if result.NameHighlight != tt.want || result.Snippet != tt.want {
+				t.Errorf("EscapeHighlights() = %q, %q, want %q", result.NameHighlight, result.Snippet, tt.want)
+			}
+		})
+	}
+}
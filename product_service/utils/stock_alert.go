@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+const (
+	SubjectStockLow    = "product.stock_low"
+	SubjectOutOfStock  = "product.out_of_stock"
+	SubjectRestocked   = "product.restocked"
+	SubjectIncreased   = "product.stock_increased"
+	SubjectBackInStock = "notification.back_in_stock"
+)
+
+// StockLevel is the stock on hand of a product over all warehouses and
+// variants, together with the threshold below which it should be reordered.
+type StockLevel struct {
+	ProductID int `db:"product_id" json:"product_id"`
+	OnHand    int `db:"on_hand" json:"stock"`
+	Threshold int `db:"low_stock_threshold" json:"low_stock_threshold"`
+}
+
+// StockLevelChange is the stock level of a product before and after a stock
+// change, both read in the transaction that made it.
+type StockLevelChange struct {
+	Before StockLevel
+	After  StockLevel
+}
+
+type StockThresholdInput struct {
+	Threshold int `json:"low_stock_threshold"`
+}
+
+func (t *StockThresholdInput) Validate() error {
+	if t.Threshold < 0 {
+		return fmt.Errorf("low_stock_threshold must not be negative")
+	}
+	return nil
+}
+
+// BackInStockNotification is published once per waiting subscriber when a
+// product comes back in stock.
+type BackInStockNotification struct {
+	SubscriptionID int    `db:"id" json:"subscription_id"`
+	ProductID      int    `db:"product_id" json:"product_id"`
+	ProductName    string `db:"product_name" json:"product_name"`
+	UserID         int    `db:"user_id" json:"user_id"`
+	Email          string `db:"email" json:"email"`
+}
+
+// StockLevelEvents returns the subjects to publish for a change of the stock
+// level. Low stock is only reported when the threshold is crossed, not on
+// every decrement below it; a threshold of 0 disables it. Every increase is
+// announced so that waiting backorders can be filled.
+func StockLevelEvents(before, after StockLevel) []string {
+	var subjects []string
+	if after.Threshold > 0 && before.OnHand > after.Threshold && after.OnHand <= after.Threshold {
+		subjects = append(subjects, SubjectStockLow)
+	}
+	if before.OnHand > 0 && after.OnHand <= 0 {
+		subjects = append(subjects, SubjectOutOfStock)
+	}
+	if before.OnHand <= 0 && after.OnHand > 0 {
+		subjects = append(subjects, SubjectRestocked)
+	}
+	if after.OnHand > before.OnHand {
+		subjects = append(subjects, SubjectIncreased)
+	}
+	return subjects
+}
+
+// PublishStockLevels publishes the events of stock level changes with the
+// level after the change. The changes are committed already, so a failed
+// publish is only logged.
+func PublishStockLevels(publish func(subject string, data []byte) error, changes []StockLevelChange) {
+	for _, change := range changes {
+		for _, subject := range StockLevelEvents(change.Before, change.After) {
+			levelData, err := json.Marshal(change.After)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			err = publish(subject, levelData)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStockLevelEvents(t *testing.T) {
+	level := func(onHand int, threshold int) StockLevel {
+		return StockLevel{ProductID: 1, OnHand: onHand, Threshold: threshold}
+	}
+
+	tests := []struct {
+		name   string
+		before StockLevel
+		after  StockLevel
+		want   []string
+	}{
+		{"above the threshold", level(20, 5), level(10, 5), nil},
+		{"crossing the threshold", level(6, 5), level(5, 5), []string{SubjectStockLow}},
+		{"below the threshold already", level(4, 5), level(3, 5), nil},
+		{"threshold disabled", level(1, 0), level(0, 0), []string{SubjectOutOfStock}},
+		{"crossing the threshold to zero", level(10, 5), level(0, 5), []string{SubjectStockLow, SubjectOutOfStock}},
+		{"oversold", level(0, 5), level(-2, 5), nil},
+		{"restocked", level(0, 5), level(10, 5), []string{SubjectRestocked, SubjectIncreased}},
+		{"restocked from backorders", level(-3, 5), level(2, 5), []string{SubjectRestocked, SubjectIncreased}},
+		{"increased", level(3, 5), level(4, 5), []string{SubjectIncreased}},
+		{"raised threshold", level(4, 2), level(4, 5), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StockLevelEvents(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StockLevelEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishStockLevels(t *testing.T) {
+	type published struct {
+		subject string
+		level   StockLevel
+	}
+	var got []published
+	publish := func(subject string, data []byte) error {
+		var level StockLevel
+		if err := json.Unmarshal(data, &level); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, published{subject, level})
+		return errors.New("not connected")
+	}
+
+	sold := StockLevelChange{Before: StockLevel{ProductID: 1, OnHand: 1}, After: StockLevel{ProductID: 1, OnHand: 0}}
+	unchanged := StockLevelChange{Before: StockLevel{ProductID: 2, OnHand: 3}, After: StockLevel{ProductID: 2, OnHand: 3}}
+	restocked := StockLevelChange{Before: StockLevel{ProductID: 3}, After: StockLevel{ProductID: 3, OnHand: 5}}
+	PublishStockLevels(publish, []StockLevelChange{sold, unchanged, restocked})
+
+	want := []published{
+		{SubjectOutOfStock, sold.After},
+		{SubjectRestocked, restocked.After},
+		{SubjectIncreased, restocked.After},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("published %+v, want %+v", got, want)
+	}
+}
+
+func TestStockThresholdInputValidate(t *testing.T) {
+	for _, tt := range []struct {
+		threshold int
+		wantErr   bool
+	}{{0, false}, {10, false}, {-1, true}} {
+		input := StockThresholdInput{Threshold: tt.threshold}
+		if err := input.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%d) error = %v, wantErr %v", tt.threshold, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	ReasonOrder            = "order"
+	ReasonCancellation     = "cancellation"
+	ReasonManualAdjustment = "manual_adjustment"
+	ReasonRestock          = "restock"
+	ReasonReturn           = "return"
+)
+
+var ErrNegativeStock = errors.New("adjustment would make stock negative")
+
+// StockMovement is one row of the append-only stock ledger. Every change of a
+// warehouse or variant stock level is recorded with its signed delta.
+type StockMovement struct {
+	ID          int       `db:"id" json:"id"`
+	ProductID   int       `db:"product_id" json:"product_id"`
+	VariantID   *int      `db:"variant_id" json:"variant_id,omitempty"`
+	WarehouseID *int      `db:"warehouse_id" json:"warehouse_id,omitempty"`
+	Delta       int       `db:"delta" json:"delta"`
+	Reason      string    `db:"reason" json:"reason"`
+	ReferenceID *string   `db:"reference_id" json:"reference_id,omitempty"`
+	ActorID     *int      `db:"actor_id" json:"actor_id,omitempty"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+type StockLedger struct {
+	ProductID   int             `json:"product_id"`
+	OnHand      int             `json:"on_hand"`
+	LedgerTotal int             `json:"ledger_total"`
+	InSync      bool            `json:"in_sync"`
+	Movements   []StockMovement `json:"movements"`
+}
+
+// StockAdjustment is a manual change of a single stock location. Without a
+// warehouse or variant the default warehouse is adjusted.
+type StockAdjustment struct {
+	Delta       int    `json:"delta"`
+	Reason      string `json:"reason"`
+	WarehouseID *int   `json:"warehouse_id,omitempty"`
+	VariantID   *int   `json:"variant_id,omitempty"`
+	Reference   string `json:"reference,omitempty"`
+}
+
+func (a *StockAdjustment) Validate() error {
+	if a.Delta == 0 {
+		return fmt.Errorf("delta must not be zero")
+	}
+	switch a.Reason {
+	case ReasonManualAdjustment, ReasonRestock, ReasonReturn:
+	default:
+		return fmt.Errorf("reason must be one of %s, %s, %s", ReasonManualAdjustment, ReasonRestock, ReasonReturn)
+	}
+	if a.WarehouseID != nil && a.VariantID != nil {
+		return fmt.Errorf("warehouse_id and variant_id are mutually exclusive")
+	}
+	if len(a.Reference) > 255 {
+		return fmt.Errorf("reference must be at most 255 characters")
+	}
+	return nil
+}
+
+// NewMovement describes why stock is about to change. The repository fills
+// in the product, location and delta when it applies the change.
+func NewMovement(reason string, referenceID string, actorID int) StockMovement {
+	movement := StockMovement{Reason: reason}
+	if referenceID != "" {
+		movement.ReferenceID = &referenceID
+	}
+	if actorID != 0 {
+		movement.ActorID = &actorID
+	}
+	return movement
+}
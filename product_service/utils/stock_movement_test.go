@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewMovement(t *testing.T) {
+	tests := []struct {
+		name        string
+		referenceID string
+		actorID     int
+		wantRef     bool
+		wantActor   bool
+	}{
+		{"reference and actor", "order_12", 7, true, true},
+		{"system change", "", 0, false, false},
+		{"actor only", "", 7, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			movement := NewMovement(ReasonRestock, tt.referenceID, tt.actorID)
+			if movement.Reason != ReasonRestock {
+				t.Errorf("reason = %s, want %s", movement.Reason, ReasonRestock)
+			}
+			if (movement.ReferenceID != nil) != tt.wantRef || (tt.wantRef && *movement.ReferenceID != tt.referenceID) {
+				t.Errorf("reference = %v, want %q", movement.ReferenceID, tt.referenceID)
+			}
+			if (movement.ActorID != nil) != tt.wantActor || (tt.wantActor && *movement.ActorID != tt.actorID) {
+				t.Errorf("actor = %v, want %d", movement.ActorID, tt.actorID)
+			}
+		})
+	}
+}
+
+func TestStockAdjustmentValidate(t *testing.T) {
+	one := 1
+
+	tests := []struct {
+		name       string
+		adjustment StockAdjustment
+		wantErr    bool
+	}{
+		{"restock", StockAdjustment{Delta: 5, Reason: ReasonRestock}, false},
+		{"shrinkage", StockAdjustment{Delta: -2, Reason: ReasonManualAdjustment, Reference: "count_2024_03"}, false},
+		{"warehouse", StockAdjustment{Delta: 1, Reason: ReasonReturn, WarehouseID: &one}, false},
+		{"variant", StockAdjustment{Delta: 1, Reason: ReasonReturn, VariantID: &one}, false},
+		{"zero delta", StockAdjustment{Reason: ReasonRestock}, true},
+		{"reason of the system", StockAdjustment{Delta: -1, Reason: ReasonOrder}, true},
+		{"missing reason", StockAdjustment{Delta: 1}, true},
+		{"warehouse and variant", StockAdjustment{Delta: 1, Reason: ReasonRestock, WarehouseID: &one, VariantID: &one}, true},
+		{"long reference", StockAdjustment{Delta: 1, Reason: ReasonRestock, Reference: strings.Repeat("x", 256)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.adjustment.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
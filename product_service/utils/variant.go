@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrVariantHasOrders keeps variants that were ordered from being deleted,
+// their order lines refer to them.
+var ErrVariantHasOrders = errors.New("variant has orders")
+
+type VariantAttributes map[string]string
+
+type ProductVariant struct {
+	ID            int               `db:"id" json:"id"`
+	ProductID     int               `db:"product_id" json:"product_id"`
+	SKU           string            `db:"sku" json:"sku"`
+	Attributes    VariantAttributes `db:"attributes" json:"attributes"`
+	Price         *float64          `db:"price" json:"price"`
+	StockQuantity int               `db:"stock_quantity" json:"stock"`
+}
+
+func (a VariantAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(a)
+}
+
+func (a *VariantAttributes) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*a = VariantAttributes{}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into variant attributes", src)
+	}
+	return json.Unmarshal(data, a)
+}
+
+func (v *ProductVariant) Validate() error {
+	err := v.ValidateDetails()
+	if err != nil {
+		return err
+	}
+	if v.StockQuantity < 0 {
+		return fmt.Errorf("stock quantity must not be negative")
+	}
+	return nil
+}
+
+// ValidateDetails checks everything but the stock, which is only changed
+// through stock adjustments once the variant exists.
+func (v *ProductVariant) ValidateDetails() error {
+	v.SKU = strings.TrimSpace(v.SKU)
+	if v.SKU == "" {
+		return fmt.Errorf("sku is required")
+	}
+	if v.Price != nil && *v.Price <= 0 {
+		return fmt.Errorf("price override must be greater than 0")
+	}
+	return nil
+}
+
+// EffectivePrice returns the variant's own price when it overrides the
+// parent product's price, otherwise the parent price.
+func (v *ProductVariant) EffectivePrice(product Product) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
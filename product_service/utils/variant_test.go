@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProductVariantValidate(t *testing.T) {
+	price, free := 12.5, 0.0
+
+	tests := []struct {
+		name    string
+		variant ProductVariant
+		sku     string
+		wantErr bool
+	}{
+		{"valid", ProductVariant{SKU: "TS-RED-M", StockQuantity: 3}, "TS-RED-M", false},
+		{"sku is trimmed", ProductVariant{SKU: "  TS-RED-M "}, "TS-RED-M", false},
+		{"price override", ProductVariant{SKU: "TS-RED-M", Price: &price}, "TS-RED-M", false},
+		{"blank sku", ProductVariant{SKU: "   "}, "", true},
+		{"free override", ProductVariant{SKU: "TS-RED-M", Price: &free}, "TS-RED-M", true},
+		{"negative stock", ProductVariant{SKU: "TS-RED-M", StockQuantity: -1}, "TS-RED-M", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.variant.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.variant.SKU != tt.sku {
+				t.Errorf("sku = %q, want %q", tt.variant.SKU, tt.sku)
+			}
+		})
+	}
+}
+
+// Updates leave the stock to stock adjustments, it is not validated.
+func TestProductVariantValidateDetails(t *testing.T) {
+	variant := ProductVariant{SKU: "TS-RED-M", StockQuantity: -1}
+	if err := variant.ValidateDetails(); err != nil {
+		t.Errorf("ValidateDetails() = %v, want nil", err)
+	}
+}
+
+func TestProductVariantEffectivePrice(t *testing.T) {
+	price := 12.5
+	product := Product{Price: 10}
+
+	if got := (&ProductVariant{}).EffectivePrice(product); got != 10 {
+		t.Errorf("EffectivePrice() = %v, want the product price 10", got)
+	}
+	if got := (&ProductVariant{Price: &price}).EffectivePrice(product); got != 12.5 {
+		t.Errorf("EffectivePrice() = %v, want the override 12.5", got)
+	}
+}
+
+func TestVariantAttributesScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    VariantAttributes
+		wantErr bool
+	}{
+		{"bytes", []byte(`{"color":"red"}`), VariantAttributes{"color": "red"}, false},
+		{"string", `{"size":"M"}`, VariantAttributes{"size": "M"}, false},
+		{"null", nil, VariantAttributes{}, false},
+		{"other type", 42, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got VariantAttributes
+			err := got.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVariantAttributesValue(t *testing.T) {
+	value, err := VariantAttributes(nil).Value()
+	if err != nil || string(value.([]byte)) != "{}" {
+		t.Errorf("Value() of no attributes = %s, %v, want {}", value, err)
+	}
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Warehouse struct {
+	ID        int     `db:"id" json:"id"`
+	Name      string  `db:"name" json:"name"`
+	Code      string  `db:"code" json:"code"`
+	Latitude  float64 `db:"latitude" json:"latitude"`
+	Longitude float64 `db:"longitude" json:"longitude"`
+	Priority  int     `db:"priority" json:"priority"`
+}
+
+type WarehouseStock struct {
+	WarehouseID   int     `db:"warehouse_id" json:"warehouse_id"`
+	WarehouseCode string  `db:"warehouse_code" json:"warehouse_code"`
+	WarehouseName string  `db:"warehouse_name" json:"warehouse_name"`
+	Latitude      float64 `db:"latitude" json:"-"`
+	Longitude     float64 `db:"longitude" json:"-"`
+	Priority      int     `db:"priority" json:"-"`
+	Quantity      int     `db:"quantity" json:"quantity"`
+}
+
+func (w *Warehouse) Validate() error {
+	w.Code = strings.ToUpper(strings.TrimSpace(w.Code))
+	if w.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if w.Code == "" {
+		return fmt.Errorf("code is required")
+	}
+	if w.Latitude < -90 || w.Latitude > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90")
+	}
+	if w.Longitude < -180 || w.Longitude > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180")
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestWarehouseValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		warehouse Warehouse
+		wantErr   bool
+	}{
+		{"valid", Warehouse{Name: "Berlin", Code: " ber ", Latitude: 52.52, Longitude: 13.40}, false},
+		{"missing name", Warehouse{Code: "BER"}, true},
+		{"missing code", Warehouse{Name: "Berlin", Code: "  "}, true},
+		{"latitude out of range", Warehouse{Name: "Berlin", Code: "BER", Latitude: 91}, true},
+		{"longitude out of range", Warehouse{Name: "Berlin", Code: "BER", Longitude: -181}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.warehouse.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tt.warehouse.Code != "BER" {
+				t.Errorf("code = %q, want BER", tt.warehouse.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"order_processing_system/user_service/user_utils"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type AddressHandler interface {
+	AddressList(w http.ResponseWriter, r *http.Request)
+	AddressDetail(w http.ResponseWriter, r *http.Request)
+	AddressCreate(w http.ResponseWriter, r *http.Request)
+	AddressUpdate(w http.ResponseWriter, r *http.Request)
+	AddressDelete(w http.ResponseWriter, r *http.Request)
+	AddressSetDefault(w http.ResponseWriter, r *http.Request)
+}
+
+func (c *Controller) AddressList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := c.addressOwner(w, r)
+	if !ok {
+		return
+	}
+
+	addresses, err := c.s.GetAddresses(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(addresses)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) AddressDetail(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	addressID := vars["address_id"]
+
+	userID, ok := c.addressOwner(w, r)
+	if !ok {
+		return
+	}
+
+	address, err := c.s.GetAddress(userID, addressID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(address)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) AddressCreate(w http.ResponseWriter, r *http.Request) {
+	var address user_utils.Address
+	err := json.NewDecoder(r.Body).Decode(&address)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := c.addressOwner(w, r)
+	if !ok {
+		return
+	}
+
+	err = c.s.CreateAddress(userID, &address)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	err = json.NewEncoder(w).Encode(address)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) AddressUpdate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	addressID := vars["address_id"]
+
+	var newAddress user_utils.Address
+	err := json.NewDecoder(r.Body).Decode(&newAddress)
+	if err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := c.addressOwner(w, r)
+	if !ok {
+		return
+	}
+
+	address, err := c.s.UpdateAddress(userID, addressID, newAddress)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	err = json.NewEncoder(w).Encode(address)
+	if err != nil {
+		c.ch <- err
+		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) AddressDelete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	addressID := vars["address_id"]
+
+	userID, ok := c.addressOwner(w, r)
+	if !ok {
+		return
+	}
+
+	err := c.s.DeleteAddress(userID, addressID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	respMsg := fmt.Sprintf("Address %s deleted successfully", addressID)
+	w.Write([]byte(respMsg))
+}
+
+func (c *Controller) AddressSetDefault(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	addressID := vars["address_id"]
+
+	userID, ok := c.addressOwner(w, r)
+	if !ok {
+		return
+	}
+
+	err := c.s.SetDefaultAddress(userID, addressID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	respMsg := fmt.Sprintf("Address %s is now the default address", addressID)
+	w.Write([]byte(respMsg))
+}
+
+// addressOwner returns the user whose address book the request is about.
+// Users only reach their own address book, admins every one.
+func (c *Controller) addressOwner(w http.ResponseWriter, r *http.Request) (int, bool) {
+	authHeader := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+
+	token := strings.TrimPrefix(authHeader, prefix)
+	token = strings.TrimSpace(token)
+
+	email, err := c.s.GetEmail(token)
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	requestUser, err := c.s.GetRegisteredUser(email)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return 0, false
+	}
+
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return 0, false
+	}
+
+	if userID != requestUser.ID && !requestUser.IsAdmin {
+		http.Error(w, "Forbidden access to another user", http.StatusForbidden)
+		return 0, false
+	}
+	return userID, true
+}
@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
+	"order_processing_system/cart"
 	"order_processing_system/user_service/internal/services"
 	"order_processing_system/user_service/user_utils"
 	"strings"
@@ -81,6 +83,15 @@ func (c *Controller) Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
 		return
 	}
+
+	// a guest cart sent along with the login is merged into the user's cart
+	if cartID := r.Header.Get("X-Cart-ID"); cart.IsID(cartID) {
+		err = c.s.MergeGuestCart(cartID, user.ID)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
 	resp := map[string]string{
 		"access_token":  accessToken,
 		"refresh_token": refreshToken,
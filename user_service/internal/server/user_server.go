@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"order_processing_system/openapi"
 	"order_processing_system/user_service/internal/controllers"
 
 	"github.com/gorilla/mux"
@@ -11,6 +12,12 @@ import (
 func NewServer(c *controllers.Controller) *http.Server {
 	r := mux.NewRouter().StrictSlash(true)
 
+	spec := openapi.MustLoad("user")
+	r.Use(spec.ValidateRequest)
+
+	r.HandleFunc("/openapi.json", spec.Handler).Methods("GET")
+	r.HandleFunc("/docs", openapi.SwaggerUI).Methods("GET")
+
 	userRouter := r.PathPrefix("/api/users").Subrouter()
 
 	userRouter.HandleFunc("/register", c.RegisterUser).Methods("POST")
@@ -20,6 +27,13 @@ func NewServer(c *controllers.Controller) *http.Server {
 	userRouter.HandleFunc("/{id}", c.UpdateUserProfile).Methods("PUT")
 	userRouter.HandleFunc("/logout", c.Logout).Methods("POST")
 
+	userRouter.HandleFunc("/{id}/addresses", c.AddressList).Methods("GET")
+	userRouter.HandleFunc("/{id}/addresses", c.AddressCreate).Methods("POST")
+	userRouter.HandleFunc("/{id}/addresses/{address_id}", c.AddressDetail).Methods("GET")
+	userRouter.HandleFunc("/{id}/addresses/{address_id}", c.AddressUpdate).Methods("PUT")
+	userRouter.HandleFunc("/{id}/addresses/{address_id}", c.AddressDelete).Methods("DELETE")
+	userRouter.HandleFunc("/{id}/addresses/{address_id}/default", c.AddressSetDefault).Methods("PUT")
+
 	fmt.Println("http://localhost:8003/api/users/")
 
 	serv := &http.Server{
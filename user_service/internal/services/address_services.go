@@ -0,0 +1,68 @@
+package services
+
+import (
+	"fmt"
+	"order_processing_system/user_service/user_utils"
+	"strconv"
+)
+
+func (s *Service) GetAddresses(userID int) ([]user_utils.Address, error) {
+	return s.PSQLRepo.GetUserAddresses(userID)
+}
+
+func (s *Service) GetAddress(userID int, id string) (user_utils.Address, error) {
+	address_id, err := strconv.Atoi(id)
+	if err != nil {
+		return user_utils.Address{}, err
+	}
+
+	address, err := s.PSQLRepo.GetUserAddress(userID, address_id)
+	if err != nil {
+		return user_utils.Address{}, fmt.Errorf("address %d not found", address_id)
+	}
+	return address, nil
+}
+
+func (s *Service) CreateAddress(userID int, address *user_utils.Address) error {
+	err := address.Validate()
+	if err != nil {
+		return err
+	}
+
+	address.UserID = userID
+	return s.PSQLRepo.PostUserAddress(address)
+}
+
+func (s *Service) UpdateAddress(userID int, id string, address user_utils.Address) (user_utils.Address, error) {
+	current, err := s.GetAddress(userID, id)
+	if err != nil {
+		return user_utils.Address{}, err
+	}
+
+	err = address.Validate()
+	if err != nil {
+		return user_utils.Address{}, err
+	}
+
+	address.ID = current.ID
+	address.UserID = userID
+	// the default only moves by making another address the default
+	address.IsDefault = address.IsDefault || current.IsDefault
+	return s.PSQLRepo.PutUserAddress(address)
+}
+
+func (s *Service) SetDefaultAddress(userID int, id string) error {
+	address_id, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.PSQLRepo.SetDefaultAddress(userID, address_id)
+}
+
+func (s *Service) DeleteAddress(userID int, id string) error {
+	address_id, err := strconv.Atoi(id)
+	if err != nil {
+		return err
+	}
+	return s.PSQLRepo.DeleteUserAddress(userID, address_id)
+}
@@ -70,6 +70,21 @@ func (s *Service) GenerateTokens(user user_utils.User) (string, string, error) {
 	return accessToken, refreshToken, nil
 }
 
+// MergeGuestCart moves the guest cart a user filled before logging in into
+// their own cart. A guest cart that expired leaves nothing to merge.
+func (s *Service) MergeGuestCart(cartID string, userID int) error {
+	items, err := s.RedisRepo.GetGuestCart(cartID)
+	if err != nil || items == nil {
+		return err
+	}
+
+	err = s.PSQLRepo.MergeCartItems(userID, items)
+	if err != nil {
+		return err
+	}
+	return s.RedisRepo.DeleteGuestCart(cartID)
+}
+
 func (s *Service) GetEmail(token string) (string, error) {
 	return s.RedisRepo.GetUserEmail(token)
 }
@@ -0,0 +1,62 @@
+package user_utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PostalAddress is where an order is delivered. Orders keep their own copy,
+// so that editing the address book does not change placed orders.
+type PostalAddress struct {
+	Name       string `db:"name" json:"name"`
+	Line1      string `db:"line1" json:"line1"`
+	Line2      string `db:"line2" json:"line2,omitempty"`
+	City       string `db:"city" json:"city"`
+	PostalCode string `db:"postal_code" json:"postal_code"`
+	// Region is the state or province code, such as "CA" in the US.
+	Region string `db:"region" json:"region,omitempty"`
+	// Country is the ISO 3166-1 alpha-2 country code.
+	Country string `db:"country" json:"country"`
+	Phone   string `db:"phone" json:"phone,omitempty"`
+}
+
+type Address struct {
+	ID     int    `db:"id" json:"id"`
+	UserID int    `db:"user_id" json:"user_id"`
+	Label  string `db:"label" json:"label,omitempty"`
+	PostalAddress
+	IsDefault bool      `db:"is_default" json:"is_default"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+func (a *PostalAddress) Validate() error {
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.Region = strings.ToUpper(strings.TrimSpace(a.Region))
+
+	if strings.TrimSpace(a.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.TrimSpace(a.Line1) == "" {
+		return fmt.Errorf("line1 is required")
+	}
+	if strings.TrimSpace(a.City) == "" {
+		return fmt.Errorf("city is required")
+	}
+	if strings.TrimSpace(a.PostalCode) == "" {
+		return fmt.Errorf("postal_code is required")
+	}
+	if len(a.Country) != 2 {
+		return fmt.Errorf("country must be a two-letter country code")
+	}
+	return nil
+}
+
+// TaxRegion is the region the address is taxed in, the country optionally
+// followed by the state or province ("US-CA").
+func (a PostalAddress) TaxRegion() string {
+	if a.Region == "" {
+		return a.Country
+	}
+	return a.Country + "-" + a.Region
+}
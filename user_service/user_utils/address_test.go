@@ -0,0 +1,60 @@
+package user_utils
+
+import "testing"
+
+func TestPostalAddressValidate(t *testing.T) {
+	valid := PostalAddress{Name: "Ada Lovelace", Line1: "12 St James's Square", City: "London", PostalCode: "SW1Y 4JH", Country: "GB"}
+	with := func(change func(a *PostalAddress)) PostalAddress {
+		a := valid
+		change(&a)
+		return a
+	}
+
+	tests := []struct {
+		name    string
+		address PostalAddress
+		wantErr bool
+	}{
+		{name: "valid", address: valid},
+		{name: "missing name", address: with(func(a *PostalAddress) { a.Name = " " }), wantErr: true},
+		{name: "missing line1", address: with(func(a *PostalAddress) { a.Line1 = "" }), wantErr: true},
+		{name: "missing city", address: with(func(a *PostalAddress) { a.City = "" }), wantErr: true},
+		{name: "missing postal code", address: with(func(a *PostalAddress) { a.PostalCode = "" }), wantErr: true},
+		{name: "country name", address: with(func(a *PostalAddress) { a.Country = "United Kingdom" }), wantErr: true},
+		{name: "missing country", address: with(func(a *PostalAddress) { a.Country = "" }), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := tt.address
+			err := address.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPostalAddressTaxRegion(t *testing.T) {
+	tests := []struct {
+		name    string
+		country string
+		region  string
+		want    string
+	}{
+		{"country only", "de", "", "DE"},
+		{"country and state", " us ", "ca", "US-CA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := PostalAddress{Name: "A", Line1: "B", City: "C", PostalCode: "D", Country: tt.country, Region: tt.region}
+			if err := address.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if got := address.TaxRegion(); got != tt.want {
+				t.Errorf("TaxRegion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
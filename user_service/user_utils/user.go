@@ -2,7 +2,6 @@ package user_utils
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"time"
 
@@ -10,17 +9,9 @@ import (
 
 	"github.com/badoux/checkmail"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/joho/godotenv"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func init() {
-	err := godotenv.Load("db/configs/.env")
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-}
-
 type User struct {
 	ID        int       `db:"id" json:"id"`
 	Username  string    `db:"username" json:"username"`